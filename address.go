@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidChecksum reports a mixed-case address whose capitalisation does
+// not match its EIP-55 checksum, which usually means a mistyped character.
+var ErrInvalidChecksum = fmt.Errorf("%w: EIP-55 checksum mismatch", ErrInvalidWalletAddress)
+
+// ValidateAddress checks that address is 0x followed by 40 hex characters.
+// All-lowercase and all-uppercase addresses carry no checksum and are
+// accepted as is; mixed-case ones must match their EIP-55 checksum.
+func ValidateAddress(address string) error {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return ErrInvalidWalletAddress
+	}
+	digits := address[2:]
+	if _, err := hex.DecodeString(digits); err != nil {
+		return ErrInvalidWalletAddress
+	}
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return nil
+	}
+	if checksumAddress(digits) != address {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+// NormalizeAddress validates address and returns its EIP-55 checksummed form.
+func NormalizeAddress(address string) (string, error) {
+	if err := ValidateAddress(address); err != nil {
+		return "", err
+	}
+	return checksumAddress(address[2:]), nil
+}
+
+// displayAddress returns the EIP-55 form of a well-formed address whatever its
+// case, and anything else unchanged. Unlike NormalizeAddress it does not reject
+// a bad checksum, since it is meant for addresses reported by Etherscan.
+func displayAddress(address string) string {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return address
+	}
+	if _, err := hex.DecodeString(address[2:]); err != nil {
+		return address
+	}
+	return checksumAddress(address[2:])
+}
+
+// checksumAddress applies EIP-55 to 40 hex digits: a letter is uppercased
+// when the matching nibble of the keccak256 hash of the lowercase digits is 8
+// or more.
+func checksumAddress(digits string) string {
+	lower := strings.ToLower(digits)
+	hash := keccak256([]byte(lower))
+
+	out := []byte("0x" + lower)
+	for i := 0; i < len(lower); i++ {
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if lower[i] >= 'a' && nibble >= 8 {
+			out[i+2] = lower[i] - 'a' + 'A'
+		}
+	}
+	return string(out)
+}
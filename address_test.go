@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Test vectors from EIP-55.
+var eip55Addresses = []string{
+	"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+	"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+	"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+}
+
+func TestValidateAddress(t *testing.T) {
+	for _, address := range eip55Addresses {
+		if err := ValidateAddress(address); err != nil {
+			t.Errorf("ValidateAddress(%s): %v", address, err)
+		}
+		if err := ValidateAddress(strings.ToLower(address)); err != nil {
+			t.Errorf("ValidateAddress(lowercase %s): %v", address, err)
+		}
+		if err := ValidateAddress("0x" + strings.ToUpper(address[2:])); err != nil {
+			t.Errorf("ValidateAddress(uppercase %s): %v", address, err)
+		}
+	}
+
+	badChecksum := "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"
+	if err := ValidateAddress(badChecksum); !errors.Is(err, ErrInvalidChecksum) || !errors.Is(err, ErrInvalidWalletAddress) {
+		t.Fatalf("expected ErrInvalidChecksum wrapping ErrInvalidWalletAddress, got %v", err)
+	}
+	for _, invalid := range []string{"", "0x1234", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed00", "0xZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZ"} {
+		if err := ValidateAddress(invalid); !errors.Is(err, ErrInvalidWalletAddress) || errors.Is(err, ErrInvalidChecksum) {
+			t.Errorf("ValidateAddress(%q): expected ErrInvalidWalletAddress, got %v", invalid, err)
+		}
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	for _, address := range eip55Addresses {
+		got, err := NormalizeAddress(strings.ToLower(address))
+		if err != nil || got != address {
+			t.Errorf("NormalizeAddress(%s): got %q (err %v)", strings.ToLower(address), got, err)
+		}
+	}
+	if _, err := NormalizeAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"); !errors.Is(err, ErrInvalidChecksum) {
+		t.Fatalf("expected a bad checksum to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+const (
+	// erc20ApprovalTopic is keccak256("Approval(address,address,uint256)").
+	// ERC-721 approvals share it but index the token ID as a fourth topic.
+	erc20ApprovalTopic = "0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"
+
+	// approvalLogPageSize is the most logs Etherscan's getLogs returns at once.
+	approvalLogPageSize = 1000
+)
+
+// unlimitedAllowance is the threshold from which an allowance is reported as
+// unlimited: wallets approve "infinite" amounts as the maximum uint256, and
+// anything at least half of it will never run out either.
+var unlimitedAllowance = new(big.Int).Lsh(big.NewInt(1), 255)
+
+// Approval is an ERC-20 allowance the wallet granted a spender and has not
+// revoked, as of the latest Approval event for that token and spender.
+type Approval struct {
+	Token       string `json:"token"`
+	TokenName   string `json:"token_name,omitempty"`
+	TokenSymbol string `json:"token_symbol,omitempty"`
+	Spender     string `json:"spender"`
+	// Allowance is scaled by the token's decimals when they are known, and
+	// equals RawAllowance otherwise.
+	Allowance    string    `json:"allowance"`
+	RawAllowance string    `json:"raw_allowance"`
+	Decimals     *int      `json:"decimals,omitempty"`
+	Unlimited    bool      `json:"unlimited,omitempty"`
+	BlockNumber  uint64    `json:"block_number"`
+	Timestamp    time.Time `json:"timestamp"`
+	Hash         string    `json:"hash"`
+}
+
+type ApprovalsResponse struct {
+	Address   string     `json:"address"`
+	Approvals []Approval `json:"approvals"`
+	// Truncated is set when the wallet has more Approval events than the
+	// page cap allows; the latest approvals may then be missing.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type eventLog struct {
+	Address         string   `json:"address"`
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+	BlockNumber     string   `json:"blockNumber"`
+	TimeStamp       string   `json:"timeStamp"`
+	TransactionHash string   `json:"transactionHash"`
+}
+
+// GetApprovals lists the wallet's outstanding ERC-20 approvals on the
+// configured chain, unlimited ones first and then newest first. Each token
+// and spender pair is reported by its latest Approval event; pairs whose
+// latest approval is zero were revoked and are left out. Spending through
+// transferFrom lowers an allowance without always emitting an event, so an
+// allowance is the most the spender may still move, not necessarily what it
+// can move now.
+func (t *WalletTracker) GetApprovals(ctx context.Context, walletAddress string) (*ApprovalsResponse, error) {
+	if err := ValidateAddress(walletAddress); err != nil {
+		return nil, err
+	}
+
+	logs, truncated, err := t.fetchApprovalLogs(ctx, t.chainID, walletAddress)
+	if err != nil && !errors.Is(err, ErrNoTransactions) {
+		return nil, err
+	}
+
+	approvals := latestApprovals(logs)
+	tokens := make(map[string]TokenMetadata)
+	for i := range approvals {
+		meta, ok := tokens[approvals[i].Token]
+		if !ok {
+			if meta, err = t.lookupTokenMetadata(ctx, t.chainID, walletAddress, approvals[i].Token); err != nil {
+				return nil, fmt.Errorf("looking up token %s: %w", approvals[i].Token, err)
+			}
+			tokens[approvals[i].Token] = meta
+		}
+		approvals[i].TokenName = firstNonEmpty(meta.Name, meta.Symbol)
+		approvals[i].TokenSymbol = meta.Symbol
+		if meta.HasDecimals {
+			raw, _ := new(big.Int).SetString(approvals[i].RawAllowance, 10)
+			approvals[i].Allowance = formatTokenBalance(raw, meta.Decimals)
+			decimals := meta.Decimals
+			approvals[i].Decimals = &decimals
+		}
+	}
+
+	return &ApprovalsResponse{Address: walletAddress, Approvals: approvals, Truncated: truncated}, nil
+}
+
+// fetchApprovalLogs returns the ERC-20 Approval events the wallet emitted as
+// owner, oldest first, paged by block with fetchForward.
+func (t *WalletTracker) fetchApprovalLogs(ctx context.Context, chainID int64, walletAddress string) ([]eventLog, bool, error) {
+	params := url.Values{}
+	params.Set("module", "logs")
+	params.Set("action", "getLogs")
+	params.Set("toBlock", "latest")
+	params.Set("topic0", erc20ApprovalTopic)
+	params.Set("topic0_1_opr", "and")
+	params.Set("topic1", "0x"+strings.Repeat("0", 24)+strings.ToLower(strings.TrimPrefix(walletAddress, "0x")))
+	return fetchForward(ctx, t, chainID, params, "fromBlock", 0, approvalLogPageSize, eventLog.blockNumber)
+}
+
+// blockNumber parses the log's hex block number.
+func (l eventLog) blockNumber() (uint64, error) {
+	block, err := parseHexUint64(l.BlockNumber)
+	if err != nil {
+		return 0, fmt.Errorf("parsing block number: %w", err)
+	}
+	return block, nil
+}
+
+// latestApprovals keeps the latest ERC-20 approval of each token and spender
+// in logs (oldest first), dropping revoked ones.
+func latestApprovals(logs []eventLog) []Approval {
+	type approvalKey struct{ token, spender string }
+	latest := make(map[approvalKey]Approval)
+	for _, log := range logs {
+		if len(log.Topics) != 3 || len(log.Topics[2]) < 40 {
+			continue
+		}
+		value, err := parseHexBig(log.Data)
+		if err != nil || len(strings.TrimPrefix(log.Data, "0x")) == 0 {
+			continue
+		}
+		block, _ := parseHexUint64(log.BlockNumber)
+		ts, _ := parseHexUint64(log.TimeStamp)
+
+		spender := "0x" + strings.ToLower(log.Topics[2][len(log.Topics[2])-40:])
+		latest[approvalKey{strings.ToLower(log.Address), spender}] = Approval{
+			Token:        displayAddress(log.Address),
+			Spender:      displayAddress(spender),
+			Allowance:    value.String(),
+			RawAllowance: value.String(),
+			Unlimited:    value.Cmp(unlimitedAllowance) >= 0,
+			BlockNumber:  block,
+			Timestamp:    time.Unix(int64(ts), 0).UTC(),
+			Hash:         log.TransactionHash,
+		}
+	}
+
+	approvals := make([]Approval, 0, len(latest))
+	for _, approval := range latest {
+		if approval.RawAllowance != "0" {
+			approvals = append(approvals, approval)
+		}
+	}
+	sort.Slice(approvals, func(i, j int) bool {
+		a, b := approvals[i], approvals[j]
+		if a.Unlimited != b.Unlimited {
+			return a.Unlimited
+		}
+		if a.BlockNumber != b.BlockNumber {
+			return a.BlockNumber > b.BlockNumber
+		}
+		if a.Token != b.Token {
+			return a.Token < b.Token
+		}
+		return a.Spender < b.Spender
+	})
+	return approvals
+}
+
+type ApprovalsRequest struct {
+	WalletAddress string `json:"wallet_address" description:"The cryptocurrency wallet address whose token approvals to audit"`
+}
+
+func registerApprovals(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_approvals", "List the outstanding ERC-20 token approvals (allowances) a wallet has granted, flagging unlimited ones", trackCall(ctx, tracker, func(ctx context.Context, req ApprovalsRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := tracker.GetApprovals(ctx, wallet)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatApprovalsResponse(resp)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatApprovalsResponse(resp *ApprovalsResponse) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallet Address: %s\n", resp.Address))
+	if len(resp.Approvals) == 0 {
+		builder.WriteString("No outstanding token approvals found.\n")
+	} else {
+		builder.WriteString("Outstanding approvals:\n")
+	}
+	for _, approval := range resp.Approvals {
+		token := tokenLabel(TokenBalance{Address: approval.Token, Name: approval.TokenName, Symbol: approval.TokenSymbol}, LabelContract)
+		amount := approval.Allowance
+		switch {
+		case approval.Unlimited:
+			amount = "UNLIMITED"
+		case approval.Decimals == nil:
+			amount += " (raw, decimals unknown)"
+		}
+		builder.WriteString(fmt.Sprintf("- %s: %s may spend %s (approved %s, tx %s)\n", token, approval.Spender, amount, approval.Timestamp.Format(time.RFC3339), approval.Hash))
+	}
+	if resp.Truncated {
+		builder.WriteString("Warning: approval history truncated; the most recent approvals may be missing.\n")
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
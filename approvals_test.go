@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetApprovals(t *testing.T) {
+	const (
+		tokenA   = "0xc0ffee0000000000000000000000000000000000"
+		tokenB   = "0xbeef000000000000000000000000000000000000"
+		nft      = "0x4444444444444444444444444444444444444444"
+		spender1 = "0x3333333333333333333333333333333333333333"
+		spender2 = "0x5555555555555555555555555555555555555555"
+	)
+	topic := func(address string) string {
+		return "0x" + strings.Repeat("0", 24) + address[2:]
+	}
+	owner := topic(testWalletA)
+	approval := func(token, spender, data, block string) string {
+		return fmt.Sprintf(`{"address":"%s","topics":["%s","%s","%s"],"data":"%s","blockNumber":"%s","timeStamp":"0x65000000","transactionHash":"0x%s"}`,
+			token, erc20ApprovalTopic, owner, topic(spender), data, block, strings.TrimPrefix(block, "0x"))
+	}
+	unlimited := "0x" + strings.Repeat("f", 64)
+
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("action") == "getLogs":
+			if q.Get("topic0") != erc20ApprovalTopic || q.Get("topic1") != owner {
+				t.Errorf("unexpected log filter %s", r.URL.RawQuery)
+			}
+			fmt.Fprintf(w, `{"status":"1","message":"OK","result":[%s]}`, strings.Join([]string{
+				approval(tokenA, spender1, unlimited, "0x10"),
+				approval(tokenA, spender2, "0x64", "0x11"),
+				approval(tokenB, spender1, "0x4c4b40", "0x12"),
+				approval(tokenA, spender2, "0x0", "0x13"),
+				// An ERC-721 approval, with the token ID as a fourth topic.
+				fmt.Sprintf(`{"address":"%s","topics":["%s","%s","%s","0x01"],"data":"0x","blockNumber":"0x14","timeStamp":"0x65000000","transactionHash":"0x14"}`, nft, erc20ApprovalTopic, owner, topic(spender2)),
+			}, ","))
+		case q.Get("action") == "tokentx" && strings.EqualFold(q.Get("contractaddress"), tokenB):
+			fmt.Fprintf(w, `{"status":"1","message":"OK","result":[{"contractAddress":"%s","tokenName":"Beta","tokenSymbol":"BET","tokenDecimal":"6","value":"1"}]}`, tokenB)
+		default:
+			fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+		}
+	})
+
+	resp, err := tracker.GetApprovals(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetApprovals returned error: %v", err)
+	}
+	if len(resp.Approvals) != 2 {
+		t.Fatalf("expected the revoked and ERC-721 approvals to be dropped, got %+v", resp.Approvals)
+	}
+	if got := resp.Approvals[0]; !strings.EqualFold(got.Token, tokenA) || got.Spender != spender1 || !got.Unlimited || got.Decimals != nil {
+		t.Fatalf("expected the unlimited approval first, got %+v", got)
+	}
+	if got := resp.Approvals[1]; !strings.EqualFold(got.Token, tokenB) || got.Allowance != "5" || got.RawAllowance != "5000000" || got.Decimals == nil || got.TokenSymbol != "BET" {
+		t.Fatalf("expected a scaled allowance of 5 BET, got %+v", got)
+	}
+
+	content := formatApprovalsResponse(resp)
+	if !strings.Contains(content, "may spend UNLIMITED") || !strings.Contains(content, "Beta (BET, "+displayAddress(tokenB)+"): "+spender1+" may spend 5 ") {
+		t.Fatalf("unexpected output:\n%s", content)
+	}
+}
+
+func TestGetApprovalsNoRecords(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"0","message":"No records found","result":[]}`)
+	})
+
+	resp, err := tracker.GetApprovals(context.Background(), testWalletA)
+	if err != nil || len(resp.Approvals) != 0 {
+		t.Fatalf("expected no approvals, got %+v, %v", resp, err)
+	}
+	if content := formatApprovalsResponse(resp); !strings.HasSuffix(content, "No outstanding token approvals found.") {
+		t.Fatalf("unexpected output %q", content)
+	}
+}
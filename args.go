@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const defaultMaxBatchSize = 100
+
+var (
+	ErrMissingArgument = errors.New("missing required argument")
+	ErrBatchTooLarge   = errors.New("too many items")
+)
+
+// The tool handlers validate their arguments before touching the tracker so
+// that malformed input comes back as a readable tool error rather than an
+// upstream failure. The schemas generated from the request structs are not
+// enforced by the MCP library.
+
+// walletArg checks a required address argument and returns it trimmed. EIP-681
+// payment URIs are accepted as long as they do not name a chain other than
+// the tracker's own, which is the only chain most tools query. ENS names are
+// rejected.
+func (t *WalletTracker) walletArg(name, raw string) (string, error) {
+	address, chain, _, err := t.walletChainArg(name, raw)
+	if err != nil {
+		return "", err
+	}
+	if isENSName(address) {
+		return "", fmt.Errorf("%s %q: %w: ENS names are not accepted here; resolve %s with ens_resolve_batch first", name, raw, ErrInvalidWalletAddress, address)
+	}
+	if home := t.chain(); chain.ID != home.ID {
+		return "", fmt.Errorf("%s %q: payment URI is for %s, but only %s is supported here", name, raw, chain.Name, home.Name)
+	}
+	return address, nil
+}
+
+// walletChainArg is walletArg for tools that can query any supported chain:
+// the chain comes from an EIP-681 payment URI, or is the tracker's chain for a
+// plain address; explicit reports whether a URI named the chain with
+// @chainid. ENS names, plain or as a URI target, are returned normalized for
+// GetWalletTokens to resolve.
+func (t *WalletTracker) walletChainArg(name, raw string) (string, Chain, bool, error) {
+	address := strings.TrimSpace(raw)
+	if address == "" {
+		return "", Chain{}, false, fmt.Errorf("%w: %s", ErrMissingArgument, name)
+	}
+
+	chain, explicit := t.chain(), false
+	if isPaymentURI(address) {
+		uri, err := ParsePaymentURI(address)
+		if err != nil {
+			return "", Chain{}, false, fmt.Errorf("%s: %w", name, err)
+		}
+		if uri.ChainID != 0 {
+			if chain, err = LookupChain(strconv.FormatInt(uri.ChainID, 10)); err != nil {
+				return "", Chain{}, false, fmt.Errorf("%s %q: %w", name, raw, err)
+			}
+			explicit = true
+		}
+		address = uri.Address
+	}
+
+	if isENSName(address) {
+		return normalizeENSName(address), chain, explicit, nil
+	}
+	if err := ValidateAddress(address); err != nil {
+		if errors.Is(err, ErrInvalidChecksum) {
+			return "", Chain{}, false, fmt.Errorf("%s %q: %w: check the address for typos, or pass it all lowercase", name, raw, err)
+		}
+		return "", Chain{}, false, fmt.Errorf("%s %q: %w: expected 0x followed by 40 hex characters", name, raw, err)
+	}
+	return address, chain, explicit, nil
+}
+
+// chainArg resolves an optional chain argument by name or chain ID. An empty
+// value keeps current, the chain already selected (e.g. by a payment URI);
+// naming a different chain than a payment URI's explicit @chainid is an
+// error, even when the URI names the tracker's own chain.
+func (t *WalletTracker) chainArg(name, raw string, current Chain, explicit bool) (Chain, error) {
+	if strings.TrimSpace(raw) == "" {
+		return current, nil
+	}
+	chain, err := LookupChain(raw)
+	if err != nil {
+		return Chain{}, fmt.Errorf("%s: %w", name, err)
+	}
+	if explicit && current.ID != chain.ID {
+		return Chain{}, fmt.Errorf("%s %q conflicts with the payment URI's chain %s", name, raw, current.Name)
+	}
+	return chain, nil
+}
+
+// batchArg checks a required list argument against the configured batch size.
+func (t *WalletTracker) batchArg(name string, items []string) error {
+	if len(items) == 0 {
+		return fmt.Errorf("%w: %s", ErrMissingArgument, name)
+	}
+	if len(items) > t.maxBatchSize {
+		return fmt.Errorf("%w in %s: %d (max %d)", ErrBatchTooLarge, name, len(items), t.maxBatchSize)
+	}
+	return nil
+}
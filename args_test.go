@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWalletArg(t *testing.T) {
+	tracker, err := NewWalletTracker("test-key")
+	if err != nil {
+		t.Fatalf("Failed to create wallet tracker: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		raw  string
+		want error
+	}{
+		{"empty", "", ErrMissingArgument},
+		{"whitespace", "   ", ErrMissingArgument},
+		{"missing prefix", "1111111111111111111111111111111111111111", ErrInvalidWalletAddress},
+		{"too short", "0x1234", ErrInvalidWalletAddress},
+		{"not hex", "0xZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZ", ErrInvalidWalletAddress},
+		{"ens name", "vitalik.eth", ErrInvalidWalletAddress},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tracker.walletArg("wallet_address", tc.raw)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+			if !strings.Contains(err.Error(), "wallet_address") {
+				t.Fatalf("expected the error to name the argument, got %q", err)
+			}
+		})
+	}
+
+	got, err := tracker.walletArg("wallet_address", " "+testWalletA+"\n")
+	if err != nil || got != testWalletA {
+		t.Fatalf("expected trimmed address %s, got %q (err %v)", testWalletA, got, err)
+	}
+}
+
+func TestChainArg(t *testing.T) {
+	tracker, err := NewWalletTracker("test-key")
+	if err != nil {
+		t.Fatalf("Failed to create wallet tracker: %v", err)
+	}
+	polygon, _ := LookupChain("polygon")
+
+	got, err := tracker.chainArg("chain", "", defaultChain, false)
+	if err != nil || got.ID != defaultChain.ID {
+		t.Fatalf("expected the default chain for an empty argument, got %+v (err %v)", got, err)
+	}
+	if got, err = tracker.chainArg("chain", "Polygon", defaultChain, false); err != nil || got.ID != 137 {
+		t.Fatalf("expected polygon by name, got %+v (err %v)", got, err)
+	}
+	if got, err = tracker.chainArg("chain", "137", polygon, true); err != nil || got.ID != 137 {
+		t.Fatalf("expected a chain matching the payment URI to pass, got %+v (err %v)", got, err)
+	}
+	if got, err = tracker.chainArg("chain", "", polygon, true); err != nil || got.ID != 137 {
+		t.Fatalf("expected the payment URI's chain to be kept, got %+v (err %v)", got, err)
+	}
+	if _, err := tracker.chainArg("chain", "bsc", polygon, true); err == nil || !strings.Contains(err.Error(), "conflicts") {
+		t.Fatalf("expected a conflict with the payment URI's chain, got %v", err)
+	}
+	// ethereum:0x…@1 names the tracker's own chain explicitly.
+	if _, err := tracker.chainArg("chain", "polygon", defaultChain, true); err == nil || !strings.Contains(err.Error(), "conflicts") {
+		t.Fatalf("expected an explicit @1 to conflict with polygon, got %v", err)
+	}
+	if _, err := tracker.chainArg("chain", "dogechain", defaultChain, false); !errors.Is(err, ErrUnsupportedChain) {
+		t.Fatalf("expected ErrUnsupportedChain, got %v", err)
+	}
+}
+
+func TestBatchArg(t *testing.T) {
+	tracker, err := NewWalletTracker("test-key", WithMaxBatchSize(2))
+	if err != nil {
+		t.Fatalf("Failed to create wallet tracker: %v", err)
+	}
+
+	if err := tracker.batchArg("names", nil); !errors.Is(err, ErrMissingArgument) {
+		t.Fatalf("expected ErrMissingArgument for an empty list, got %v", err)
+	}
+	if err := tracker.batchArg("names", []string{"a.eth", "b.eth", "c.eth"}); !errors.Is(err, ErrBatchTooLarge) {
+		t.Fatalf("expected ErrBatchTooLarge, got %v", err)
+	}
+	if err := tracker.batchArg("names", []string{"a.eth", "b.eth"}); err != nil {
+		t.Fatalf("expected a list at the limit to pass, got %v", err)
+	}
+}
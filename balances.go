@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+const defaultTokenBalanceConcurrency = 3
+
+// GetTokenBalancesFor returns the wallet's current balance of each requested
+// token contract using Etherscan's tokenbalance action, skipping the full
+// transfer history scan. Every requested contract is reported, including those
+// with a zero balance, in the order given.
+func (t *WalletTracker) GetTokenBalancesFor(ctx context.Context, walletAddress string, contracts []string) (*WalletResponse, error) {
+	if err := ValidateAddress(walletAddress); err != nil {
+		return nil, err
+	}
+	if len(contracts) > t.maxBatchSize {
+		return nil, fmt.Errorf("%w in contracts: %d (max %d)", ErrBatchTooLarge, len(contracts), t.maxBatchSize)
+	}
+
+	seen := make(map[string]bool, len(contracts))
+	unique := make([]string, 0, len(contracts))
+	for _, contract := range contracts {
+		contract = strings.TrimSpace(contract)
+		if err := ValidateAddress(contract); err != nil {
+			return nil, fmt.Errorf("contract address %q: %w", contract, err)
+		}
+		if key := strings.ToLower(contract); !seen[key] {
+			seen[key] = true
+			unique = append(unique, contract)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tokens := make([]TokenBalance, len(unique))
+	sem := make(chan struct{}, defaultTokenBalanceConcurrency)
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	for i, contract := range unique {
+		wg.Add(1)
+		go func(i int, contract string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			token, err := t.fetchTokenBalance(ctx, t.chainID, walletAddress, contract)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("fetching balance of %s: %w", contract, err)
+					cancel()
+				})
+				return
+			}
+			tokens[i] = token
+		}(i, contract)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return &WalletResponse{
+		Address: walletAddress,
+		Tokens:  tokens,
+	}, nil
+}
+
+// fetchTokenBalance reads the exact balance of one token and labels it with the
+// cached metadata of the token, else with the metadata from the wallet's most
+// recent transfer of it, completed by the metadata resolver if needed.
+func (t *WalletTracker) fetchTokenBalance(ctx context.Context, chainID int64, walletAddress, contract string) (TokenBalance, error) {
+	raw, err := t.fetchRawTokenBalance(ctx, chainID, walletAddress, contract)
+	if err != nil {
+		return TokenBalance{}, err
+	}
+
+	meta, err := t.lookupTokenMetadata(ctx, chainID, walletAddress, contract)
+	if err != nil {
+		return TokenBalance{}, err
+	}
+
+	return TokenBalance{
+		Address:    contract,
+		Name:       firstNonEmpty(meta.Name, meta.Symbol),
+		Symbol:     meta.Symbol,
+		Balance:    formatTokenBalance(raw, meta.Decimals),
+		RawBalance: raw.String(),
+		Decimals:   meta.Decimals,
+	}, nil
+}
+
+// lookupTokenMetadata returns the cached metadata of contract, filling gaps
+// from the wallet's most recent transfer of it and then the metadata
+// resolver. Decimals overrides take precedence.
+func (t *WalletTracker) lookupTokenMetadata(ctx context.Context, chainID int64, walletAddress, contract string) (TokenMetadata, error) {
+	meta, _ := t.tokenMetadata.Get(chainID, contract)
+	if !meta.complete() {
+		found, err := t.fetchTokenMetadata(ctx, chainID, walletAddress, contract)
+		switch {
+		case err == nil:
+			t.tokenMetadata.Remember(chainID, contract, found.metadata())
+		case !errors.Is(err, ErrNoTransactions):
+			return TokenMetadata{}, err
+		}
+		if meta, err = t.tokenMetadata.Resolve(ctx, chainID, contract); err != nil {
+			t.logger.Warn("Resolving token metadata failed", "contract", contract, "error", err)
+		}
+	}
+	if d, ok := t.decimalOverrides[strings.ToLower(contract)]; ok {
+		meta.Decimals, meta.HasDecimals = d, true
+	}
+	return meta, nil
+}
+
+func (t *WalletTracker) fetchRawTokenBalance(ctx context.Context, chainID int64, walletAddress, contract string) (*big.Int, error) {
+	params := url.Values{}
+	params.Set("module", "account")
+	params.Set("action", "tokenbalance")
+	params.Set("contractaddress", contract)
+	params.Set("address", walletAddress)
+	params.Set("tag", "latest")
+
+	return t.queryBalance(ctx, chainID, params)
+}
+
+// fetchTokenMetadata returns the wallet's latest transfer of contract, which
+// carries the token's name, symbol and decimals.
+func (t *WalletTracker) fetchTokenMetadata(ctx context.Context, chainID int64, walletAddress, contract string) (tokenTransaction, error) {
+	params := accountListParams("tokentx", walletAddress)
+	params.Set("contractaddress", contract)
+	params.Set("page", "1")
+	params.Set("offset", "1")
+	params.Set("sort", "desc")
+
+	var txs []tokenTransaction
+	if err := t.fetchList(ctx, chainID, params, &txs); err != nil {
+		return tokenTransaction{}, err
+	}
+	if len(txs) == 0 {
+		return tokenTransaction{}, ErrNoTransactions
+	}
+	return txs[0], nil
+}
+
+// GetTokenBalance returns the wallet's current balance of a single token using
+// Etherscan's tokenbalance action. The token's name, symbol and decimals come
+// from the wallet's latest transfer of it and are cached per contract; a token
+// the wallet never transferred is reported by contract with no decimals.
+func (t *WalletTracker) GetTokenBalance(ctx context.Context, walletAddress, contract string) (*TokenBalanceResponse, error) {
+	if err := ValidateAddress(walletAddress); err != nil {
+		return nil, err
+	}
+	contract = strings.TrimSpace(contract)
+	if err := ValidateAddress(contract); err != nil {
+		return nil, fmt.Errorf("contract address %q: %w", contract, err)
+	}
+
+	token, err := t.fetchTokenBalance(ctx, t.chainID, walletAddress, contract)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenBalanceResponse{Address: walletAddress, Token: token}, nil
+}
+
+type TokenBalanceResponse struct {
+	Address string       `json:"address"`
+	Token   TokenBalance `json:"token"`
+}
+
+type TokenBalanceRequest struct {
+	WalletAddress   string `json:"wallet_address" description:"The cryptocurrency wallet address to check"`
+	ContractAddress string `json:"contract_address" description:"The ERC-20 token contract address"`
+}
+
+func registerTokenBalance(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_token_balance", "Get a wallet's exact current balance of a single ERC-20 token", trackCall(ctx, tracker, func(ctx context.Context, req TokenBalanceRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+		contract, err := tracker.walletArg("contract_address", req.ContractAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := tracker.GetTokenBalance(ctx, wallet, contract)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatTokenBalanceResponse(resp)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatTokenBalanceResponse(resp *TokenBalanceResponse) string {
+	token := resp.Token
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallet Address: %s\n", resp.Address))
+	builder.WriteString(fmt.Sprintf("Token: %s\n", tokenLabel(token, LabelContract)))
+	builder.WriteString(fmt.Sprintf("Balance: %s\n", token.Balance))
+	builder.WriteString(fmt.Sprintf("Raw balance: %s (%d decimals)\n", token.RawBalance, token.Decimals))
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+type BalancesForRequest struct {
+	WalletAddress     string   `json:"wallet_address" description:"The cryptocurrency wallet address to check"`
+	ContractAddresses []string `json:"contract_addresses" description:"ERC-20 token contract addresses to report balances for (at most the server's batch size, 100 by default)"`
+}
+
+func registerBalancesFor(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_balances_for", "Get a wallet's exact balances for a known list of ERC-20 tokens", trackCall(ctx, tracker, func(ctx context.Context, req BalancesForRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+		if err := tracker.batchArg("contract_addresses", req.ContractAddresses); err != nil {
+			return nil, err
+		}
+
+		walletResp, err := tracker.GetTokenBalancesFor(ctx, wallet, req.ContractAddresses)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatWalletResponse(walletResp, formatOptions{Labels: LabelDefault})
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGetTokenBalancesForReportsZeroBalances(t *testing.T) {
+	usdc := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	unused := "0x6B175474E89094C44Da98b954EedeAC495271d0F"
+
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		contract := q.Get("contractaddress")
+		switch q.Get("action") {
+		case "tokenbalance":
+			if contract == usdc {
+				fmt.Fprint(w, `{"status":"1","message":"OK","result":"1234500000"}`)
+				return
+			}
+			fmt.Fprint(w, `{"status":"1","message":"OK","result":"0"}`)
+		case "tokentx":
+			if contract == usdc {
+				fmt.Fprint(w, `{"status":"1","message":"OK","result":[{"tokenName":"USD Coin","tokenSymbol":"USDC","tokenDecimal":"6","value":"1"}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+		default:
+			t.Errorf("unexpected action %q", q.Get("action"))
+		}
+	})
+
+	resp, err := tracker.GetTokenBalancesFor(context.Background(), testWalletA, []string{usdc, unused, usdc})
+	if err != nil {
+		t.Fatalf("GetTokenBalancesFor returned error: %v", err)
+	}
+	if len(resp.Tokens) != 2 {
+		t.Fatalf("expected 2 deduplicated tokens, got %d: %+v", len(resp.Tokens), resp.Tokens)
+	}
+	if got := resp.Tokens[0]; got.Symbol != "USDC" || got.Balance != "1234.5" {
+		t.Fatalf("unexpected USDC balance: %+v", got)
+	}
+	if got := resp.Tokens[1]; got.Address != unused || got.Balance != "0" {
+		t.Fatalf("expected explicit zero balance for unused token, got %+v", got)
+	}
+}
+
+func TestGetTokenBalanceCachesMetadata(t *testing.T) {
+	usdc := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+
+	var metadataCalls atomic.Int32
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch q.Get("action") {
+		case "tokenbalance":
+			fmt.Fprint(w, `{"status":"1","message":"OK","result":"1234500000"}`)
+		case "tokentx":
+			metadataCalls.Add(1)
+			fmt.Fprint(w, `{"status":"1","message":"OK","result":[{"tokenName":"USD Coin","tokenSymbol":"USDC","tokenDecimal":"6","value":"1"}]}`)
+		default:
+			t.Errorf("unexpected action %q", q.Get("action"))
+		}
+	})
+
+	for _, wallet := range []string{testWalletA, testWalletB} {
+		resp, err := tracker.GetTokenBalance(context.Background(), wallet, usdc)
+		if err != nil {
+			t.Fatalf("GetTokenBalance returned error: %v", err)
+		}
+		if got := resp.Token; got.Symbol != "USDC" || got.Balance != "1234.5" || got.Decimals != 6 || got.RawBalance != "1234500000" {
+			t.Fatalf("unexpected balance: %+v", got)
+		}
+	}
+	if n := metadataCalls.Load(); n != 1 {
+		t.Fatalf("expected the token's metadata to be fetched once, got %d calls", n)
+	}
+
+	resp, _ := tracker.GetTokenBalance(context.Background(), testWalletA, usdc)
+	text := formatTokenBalanceResponse(resp)
+	if !strings.Contains(text, "Token: USD Coin (USDC, "+usdc+")\nBalance: 1234.5\nRaw balance: 1234500000 (6 decimals)") {
+		t.Fatalf("unexpected output:\n%s", text)
+	}
+
+	if _, err := tracker.GetTokenBalance(context.Background(), testWalletA, "0x1234"); !errors.Is(err, ErrInvalidWalletAddress) {
+		t.Fatalf("expected an invalid contract to be rejected, got %v", err)
+	}
+}
+
+func TestGetTokenBalancesForHonoursBatchSize(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("action") {
+		case "tokenbalance":
+			fmt.Fprint(w, `{"status":"1","message":"OK","result":"0"}`)
+		default:
+			fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+		}
+	})
+	WithMaxBatchSize(60)(tracker)
+
+	contracts := make([]string, 60)
+	for i := range contracts {
+		contracts[i] = fmt.Sprintf("0x%040x", i+1)
+	}
+	resp, err := tracker.GetTokenBalancesFor(context.Background(), testWalletA, contracts)
+	if err != nil || len(resp.Tokens) != 60 {
+		t.Fatalf("expected a batch within the configured size to pass, got %v", err)
+	}
+	if _, err := tracker.GetTokenBalancesFor(context.Background(), testWalletA, append(contracts, "0x"+strings.Repeat("f", 40))); !errors.Is(err, ErrBatchTooLarge) {
+		t.Fatalf("expected ErrBatchTooLarge past the configured size, got %v", err)
+	}
+	if _, err := tracker.ResolveENSNames(context.Background(), make([]string, 61)); !errors.Is(err, ErrBatchTooLarge) {
+		t.Fatalf("expected ErrBatchTooLarge for ENS names past the configured size, got %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+const defaultBatchConcurrency = 4
+
+// BatchOptions controls how GetWalletsTokens fans out over several addresses.
+type BatchOptions struct {
+	// FailFast cancels outstanding fetches and returns the first error
+	// encountered instead of collecting per-address errors.
+	FailFast bool
+}
+
+// WalletResult holds the outcome of a single address within a batch query.
+type WalletResult struct {
+	Address string
+	Wallet  *WalletResponse
+	Err     error
+}
+
+func (t *WalletTracker) GetWalletsTokens(ctx context.Context, addresses []string, opts BatchOptions) ([]WalletResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]WalletResult, len(addresses))
+	sem := make(chan struct{}, defaultBatchConcurrency)
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	for i, address := range addresses {
+		results[i].Address = address
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, address string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			wallet, err := t.GetWalletTokens(ctx, address)
+			results[i].Wallet = wallet
+			results[i].Err = err
+			if err != nil && opts.FailFast {
+				once.Do(func() {
+					firstErr = fmt.Errorf("fetching wallet %s: %w", address, err)
+					cancel()
+				})
+			}
+		}(i, address)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// CombinedWallets is the portfolio view across the wallets of a batch that
+// were fetched successfully: native and token balances summed per token.
+type CombinedWallets struct {
+	Wallets       int    `json:"wallets"`
+	NativeSymbol  string `json:"native_symbol,omitempty"`
+	NativeBalance string `json:"native_balance,omitempty"`
+	// NativeRawBalance and NativeDecimals are as in WalletResponse.
+	NativeRawBalance string         `json:"native_raw_balance,omitempty"`
+	NativeDecimals   int            `json:"native_decimals,omitempty"`
+	Tokens           []TokenBalance `json:"tokens"`
+	TotalUSD         string         `json:"total_usd,omitempty"`
+}
+
+// CombineWalletResults sums the successful results' balances by contract
+// address. Raw balances are added exactly and reformatted with the token's
+// decimals; USD values are summed where every holding of a token is priced.
+func CombineWalletResults(results []WalletResult) *CombinedWallets {
+	combined := &CombinedWallets{Tokens: []TokenBalance{}}
+	native := new(big.Int)
+	totalUSD := new(big.Rat)
+	hasUSD := false
+
+	type aggregate struct {
+		token    TokenBalance
+		raw      *big.Int
+		usd      *big.Rat
+		unpriced bool
+	}
+	aggregates := make(map[string]*aggregate)
+
+	for _, result := range results {
+		if result.Err != nil || result.Wallet == nil {
+			continue
+		}
+		wallet := result.Wallet
+		combined.Wallets++
+		combined.NativeSymbol = firstNonEmpty(combined.NativeSymbol, wallet.NativeSymbol)
+		if wei, ok := new(big.Int).SetString(wallet.NativeRawBalance, 10); ok {
+			native.Add(native, wei)
+		} else if wei, ok := parseDecimalUnits(wallet.NativeBalance, nativeDecimals); ok {
+			native.Add(native, wei)
+		}
+		if usd, ok := new(big.Rat).SetString(wallet.TotalUSD); ok {
+			totalUSD.Add(totalUSD, usd)
+			hasUSD = true
+		}
+
+		for _, token := range wallet.Tokens {
+			raw, ok := new(big.Int).SetString(token.RawBalance, 10)
+			if !ok {
+				continue
+			}
+			key := strings.ToLower(token.Address)
+			agg, ok := aggregates[key]
+			if !ok {
+				agg = &aggregate{token: token, raw: new(big.Int), usd: new(big.Rat)}
+				agg.token.TransferCount = 0
+				agg.token.SkippedTransfers = 0
+				aggregates[key] = agg
+			}
+			agg.raw.Add(agg.raw, raw)
+			agg.token.TransferCount += token.TransferCount
+			agg.token.SkippedTransfers += token.SkippedTransfers
+			agg.token.Approximate = agg.token.Approximate || token.Approximate
+			if token.FirstSeen != nil && (agg.token.FirstSeen == nil || token.FirstSeen.Before(*agg.token.FirstSeen)) {
+				agg.token.FirstSeen = token.FirstSeen
+			}
+			if token.LastActivity != nil && (agg.token.LastActivity == nil || token.LastActivity.After(*agg.token.LastActivity)) {
+				agg.token.LastActivity = token.LastActivity
+			}
+			// The combined value is only as fresh as its stalest price.
+			if token.PriceUpdatedAt != nil && (agg.token.PriceUpdatedAt == nil || token.PriceUpdatedAt.Before(*agg.token.PriceUpdatedAt)) {
+				agg.token.PriceUpdatedAt = token.PriceUpdatedAt
+			}
+			if usd, ok := new(big.Rat).SetString(token.USDValue); ok {
+				agg.usd.Add(agg.usd, usd)
+			} else {
+				agg.unpriced = true
+			}
+		}
+	}
+
+	for _, agg := range aggregates {
+		token := agg.token
+		token.RawBalance = agg.raw.String()
+		token.Balance = formatTokenBalance(agg.raw, token.Decimals)
+		token.USDValue = ""
+		if !agg.unpriced {
+			token.USDValue = agg.usd.FloatString(usdDecimals)
+		} else {
+			token.PriceSource, token.PriceUpdatedAt = "", nil
+		}
+		combined.Tokens = append(combined.Tokens, token)
+	}
+	sort.Slice(combined.Tokens, func(i, j int) bool {
+		a, b := strings.ToLower(combined.Tokens[i].Name), strings.ToLower(combined.Tokens[j].Name)
+		if a != b {
+			return a < b
+		}
+		return strings.ToLower(combined.Tokens[i].Address) < strings.ToLower(combined.Tokens[j].Address)
+	})
+
+	if combined.NativeSymbol != "" {
+		combined.NativeBalance = formatTokenBalance(native, nativeDecimals)
+		combined.NativeRawBalance = native.String()
+		combined.NativeDecimals = nativeDecimals
+	}
+	if hasUSD {
+		combined.TotalUSD = totalUSD.FloatString(usdDecimals)
+		tokens := make([]*TokenBalance, len(combined.Tokens))
+		for i := range combined.Tokens {
+			tokens[i] = &combined.Tokens[i]
+		}
+		setPortfolioShares(tokens, totalUSD)
+	}
+	return combined
+}
+
+// parseDecimalUnits converts a formatted balance such as "1.5" back to base
+// units.
+func parseDecimalUnits(formatted string, decimals int) (*big.Int, bool) {
+	value, ok := new(big.Rat).SetString(formatted)
+	if !ok {
+		return nil, false
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	value.Mul(value, new(big.Rat).SetInt(scale))
+	if !value.IsInt() {
+		return nil, false
+	}
+	return new(big.Int).Set(value.Num()), true
+}
+
+type WalletsTrackerRequest struct {
+	WalletAddresses []string `json:"wallet_addresses" description:"The wallet addresses to track together, e.g. every address of one portfolio"`
+	FailFast        bool     `json:"fail_fast,omitempty" description:"Fail the whole call on the first wallet that cannot be fetched, instead of reporting per-wallet errors"`
+}
+
+func registerWalletsTracker(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallets_tracker", "Track several wallets at once, with per-wallet balances and a combined portfolio", trackCall(ctx, tracker, func(ctx context.Context, req WalletsTrackerRequest) (*mcp_golang.ToolResponse, error) {
+		if err := tracker.batchArg("wallet_addresses", req.WalletAddresses); err != nil {
+			return nil, err
+		}
+
+		addresses := make([]string, len(req.WalletAddresses))
+		for i, raw := range req.WalletAddresses {
+			addresses[i] = strings.TrimSpace(raw)
+		}
+		results, err := tracker.GetWalletsTokens(ctx, addresses, BatchOptions{FailFast: req.FailFast})
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatWalletsResults(results, CombineWalletResults(results))
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatWalletsResults(results []WalletResult, combined *CombinedWallets) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallets: %d (%d fetched)\n", len(results), combined.Wallets))
+	for _, result := range results {
+		if result.Err != nil {
+			builder.WriteString(fmt.Sprintf("- %s: error: %v\n", result.Address, result.Err))
+			continue
+		}
+		wallet := result.Wallet
+		builder.WriteString(fmt.Sprintf("- %s: %s %s, %d token(s)\n", wallet.Address, wallet.NativeBalance, firstNonEmpty(wallet.NativeSymbol, defaultChain.NativeSymbol), len(wallet.Tokens)))
+	}
+
+	builder.WriteString("\nCombined:\n")
+	if combined.NativeBalance != "" {
+		builder.WriteString(fmt.Sprintf("%s: %s\n", combined.NativeSymbol, combined.NativeBalance))
+	}
+	if len(combined.Tokens) == 0 {
+		builder.WriteString("No token balances found.")
+	} else {
+		builder.WriteString("Tokens:\n")
+		for _, token := range combined.Tokens {
+			builder.WriteString(fmt.Sprintf("- %s: %s%s%s\n", tokenLabel(token, LabelDefault), token.Balance, approximateSuffix(token), usdSuffix(token)))
+		}
+		if combined.TotalUSD != "" {
+			builder.WriteString(fmt.Sprintf("Total value of priced tokens: $%s\n", combined.TotalUSD))
+		}
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
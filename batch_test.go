@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+const (
+	testWalletA = "0x1111111111111111111111111111111111111111"
+	testWalletB = "0x2222222222222222222222222222222222222222"
+)
+
+func TestGetWalletsTokensCollectsErrors(t *testing.T) {
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+	}))
+
+	results, err := tracker.GetWalletsTokens(context.Background(), []string{testWalletA, "bogus", testWalletB}, BatchOptions{})
+	if err != nil {
+		t.Fatalf("GetWalletsTokens returned error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Fatalf("expected valid addresses to succeed, got %v and %v", results[0].Err, results[2].Err)
+	}
+	if !errors.Is(results[1].Err, ErrInvalidWalletAddress) {
+		t.Fatalf("expected ErrInvalidWalletAddress for bogus address, got %v", results[1].Err)
+	}
+}
+
+func TestGetWalletsTokensFailFast(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		// Hold the request open until the batch cancels it.
+		<-r.Context().Done()
+	})
+
+	start := time.Now()
+	_, err := tracker.GetWalletsTokens(context.Background(), []string{testWalletA, "bogus"}, BatchOptions{FailFast: true})
+	if !errors.Is(err, ErrInvalidWalletAddress) {
+		t.Fatalf("expected ErrInvalidWalletAddress, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("fail-fast batch did not cancel in-flight fetches promptly (took %s)", elapsed)
+	}
+}
+
+func TestCombineWalletResults(t *testing.T) {
+	usdc := "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	results := []WalletResult{
+		{Address: testWalletA, Wallet: &WalletResponse{
+			Address: testWalletA, NativeBalance: "1.5", NativeSymbol: "ETH", TotalUSD: "10.00",
+			Tokens: []TokenBalance{{Address: usdc, Name: "USD Coin", Symbol: "USDC", Decimals: 6, RawBalance: "2500000", Balance: "2.5", USDValue: "2.50", TransferCount: 2}},
+		}},
+		{Address: "bogus", Err: ErrInvalidWalletAddress},
+		{Address: testWalletB, Wallet: &WalletResponse{
+			Address: testWalletB, NativeBalance: "0.25", NativeSymbol: "ETH", TotalUSD: "7.50",
+			Tokens: []TokenBalance{{Address: strings.ToUpper(usdc), Name: "USD Coin", Symbol: "USDC", Decimals: 6, RawBalance: "7500000", Balance: "7.5", USDValue: "7.50", TransferCount: 1}},
+		}},
+	}
+
+	combined := CombineWalletResults(results)
+	if combined.Wallets != 2 || combined.NativeBalance != "1.75" {
+		t.Fatalf("expected 2 wallets holding 1.75 ETH, got %+v", combined)
+	}
+	if combined.NativeRawBalance != "1750000000000000000" || combined.NativeDecimals != 18 {
+		t.Fatalf("expected the combined balance in wei, got %q, %d", combined.NativeRawBalance, combined.NativeDecimals)
+	}
+	// The exact wei balance wins over the rounded display value.
+	results[0].Wallet.NativeRawBalance = "1500000000000000001"
+	if exact := CombineWalletResults(results); exact.NativeRawBalance != "1750000000000000001" {
+		t.Fatalf("expected wei to be summed exactly, got %q", exact.NativeRawBalance)
+	}
+	if len(combined.Tokens) != 1 {
+		t.Fatalf("expected USDC to be merged across wallets, got %+v", combined.Tokens)
+	}
+	if got := combined.Tokens[0]; got.RawBalance != "10000000" || got.Balance != "10" || got.USDValue != "10.00" || got.TransferCount != 3 {
+		t.Fatalf("unexpected combined USDC balance: %+v", got)
+	}
+	if combined.TotalUSD != "17.50" {
+		t.Fatalf("expected total $17.50, got %q", combined.TotalUSD)
+	}
+
+	text := formatWalletsResults(results, combined)
+	for _, want := range []string{"Wallets: 3 (2 fetched)", "- bogus: error: ", "ETH: 1.75", "USD Coin (USDC): 10"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected output to contain %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestCombineWalletResultsOrdersSameNamedTokensByAddress(t *testing.T) {
+	// A spoofed token can copy a real token's name; the contract decides.
+	usdc := TokenBalance{Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", Name: "USD Coin", RawBalance: "1"}
+	fake := TokenBalance{Address: "0x0000000000000000000000000000000000000bad", Name: "usd coin", RawBalance: "1"}
+	for _, tokens := range [][]TokenBalance{{usdc, fake}, {fake, usdc}} {
+		combined := CombineWalletResults([]WalletResult{{Address: testWalletA, Wallet: &WalletResponse{Address: testWalletA, Tokens: tokens}}})
+		if len(combined.Tokens) != 2 || combined.Tokens[0].Address != fake.Address {
+			t.Fatalf("expected the tokens ordered by address, got %+v", combined.Tokens)
+		}
+	}
+}
+
+func TestWalletsTrackerToolFailFast(t *testing.T) {
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+	}))
+
+	httpTransport := newMCPHTTPTransport()
+	server := mcp_golang.NewServer(httpTransport)
+	if err := registerWalletsTracker(context.Background(), server, tracker); err != nil {
+		t.Fatalf("registerWalletsTracker returned error: %v", err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve returned error: %v", err)
+	}
+	srv := httptest.NewServer(httpTransport)
+	t.Cleanup(srv.Close)
+
+	call := func(failFast bool) string {
+		_, body := postMCP(t, srv.URL, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"wallets_tracker","arguments":{"wallet_addresses":["%s","bogus"],"fail_fast":%t}}}`, testWalletA, failFast))
+		return body
+	}
+
+	if body := call(false); !strings.Contains(body, "- bogus: error: ") || !strings.Contains(body, "(1 fetched)") {
+		t.Fatalf("expected a per-wallet error, got %s", body)
+	}
+	if body := call(true); strings.Contains(body, "Wallets: ") || !strings.Contains(body, "invalid") {
+		t.Fatalf("expected the whole call to fail, got %s", body)
+	}
+}
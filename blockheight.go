@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const defaultBlockHeightTTL = 5 * time.Second
+
+// BlockHeightProvider reports the latest block number of the tracker's chain.
+// Features needing the chain head go through WalletTracker.BlockHeight rather
+// than asking an endpoint directly, so the source stays swappable and cached.
+type BlockHeightProvider interface {
+	BlockHeight(ctx context.Context) (uint64, error)
+}
+
+// etherscanBlockHeight reads the head through Etherscan's eth_blockNumber proxy.
+type etherscanBlockHeight struct {
+	tracker *WalletTracker
+	chainID int64
+}
+
+func (p *etherscanBlockHeight) BlockHeight(ctx context.Context) (uint64, error) {
+	params := url.Values{}
+	params.Set("module", "proxy")
+	params.Set("action", "eth_blockNumber")
+
+	apiResp, err := p.tracker.queryEtherscan(ctx, p.chainID, params)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := apiResp.statusErr(); err != nil {
+		return 0, err
+	}
+	var raw string
+	if err := json.Unmarshal(apiResp.Result, &raw); err != nil {
+		return 0, fmt.Errorf("parsing block number: %w", err)
+	}
+	return parseHexUint64(raw)
+}
+
+type rpcBlockHeight struct {
+	rpc *rpcClient
+}
+
+func (p *rpcBlockHeight) BlockHeight(ctx context.Context) (uint64, error) {
+	var raw string
+	if err := p.rpc.call(ctx, "eth_blockNumber", nil, &raw); err != nil {
+		return 0, fmt.Errorf("fetching block number: %w", err)
+	}
+	return parseHexUint64(raw)
+}
+
+// cachedBlockHeight remembers the last height for ttl. Blocks arrive every
+// few seconds at most, so a slightly stale head is fine for every caller.
+type cachedBlockHeight struct {
+	provider BlockHeightProvider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	height    uint64
+	fetchedAt time.Time
+}
+
+func newCachedBlockHeight(provider BlockHeightProvider, ttl time.Duration) *cachedBlockHeight {
+	return &cachedBlockHeight{provider: provider, ttl: ttl, now: time.Now}
+}
+
+func (c *cachedBlockHeight) BlockHeight(ctx context.Context) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.fetchedAt.IsZero() && c.now().Sub(c.fetchedAt) < c.ttl {
+		return c.height, nil
+	}
+
+	height, err := c.provider.BlockHeight(ctx)
+	if err != nil {
+		return 0, err
+	}
+	c.height = height
+	c.fetchedAt = c.now()
+	return height, nil
+}
+
+// BlockHeight returns the latest block number of the tracker's configured
+// chain from the configured provider: WithBlockHeightProvider, else the
+// JSON-RPC endpoint when the chain is Ethereum mainnet, the only chain it
+// serves, else Etherscan.
+func (t *WalletTracker) BlockHeight(ctx context.Context) (uint64, error) {
+	return t.blockHeight.BlockHeight(ctx)
+}
+
+// chainHead returns the latest block number of chainID: BlockHeight for the
+// tracker's own chain, and Etherscan's eth_blockNumber proxy for any other.
+func (t *WalletTracker) chainHead(ctx context.Context, chainID int64) (uint64, error) {
+	if chainID == t.chainID {
+		return t.BlockHeight(ctx)
+	}
+	return (&etherscanBlockHeight{tracker: t, chainID: chainID}).BlockHeight(ctx)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type fakeBlockHeight struct {
+	height uint64
+	calls  int
+}
+
+func (f *fakeBlockHeight) BlockHeight(ctx context.Context) (uint64, error) {
+	f.calls++
+	f.height++
+	return f.height, nil
+}
+
+func TestBlockHeightIsCached(t *testing.T) {
+	fake := &fakeBlockHeight{height: 100}
+	tracker, err := NewWalletTracker("key", WithBlockHeightProvider(fake))
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+
+	cache := tracker.blockHeight.(*cachedBlockHeight)
+	now := time.Unix(1700000000, 0)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if height, err := tracker.BlockHeight(context.Background()); err != nil || height != 101 {
+			t.Fatalf("expected cached height 101, got %d (err %v)", height, err)
+		}
+	}
+	if fake.calls != 1 {
+		t.Fatalf("expected one provider call within the TTL, got %d", fake.calls)
+	}
+
+	now = now.Add(defaultBlockHeightTTL)
+	if height, _ := tracker.BlockHeight(context.Background()); height != 102 || fake.calls != 2 {
+		t.Fatalf("expected a refresh after the TTL, got height %d after %d calls", height, fake.calls)
+	}
+}
+
+func TestEtherscanBlockHeight(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("module") != "proxy" || q.Get("action") != "eth_blockNumber" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":83,"result":"0x1406f40"}`)
+	})
+
+	height, err := tracker.BlockHeight(context.Background())
+	if err != nil || height != 21000000 {
+		t.Fatalf("expected height 21000000, got %d (err %v)", height, err)
+	}
+}
+
+func TestRPCBlockHeight(t *testing.T) {
+	tracker := newRPCTestTracker(t, func(method string, params []json.RawMessage) (string, *rpcError) {
+		if method != "eth_blockNumber" {
+			t.Errorf("unexpected method %s", method)
+		}
+		return `"0x10"`, nil
+	})
+
+	height, err := tracker.BlockHeight(context.Background())
+	if err != nil || height != 16 {
+		t.Fatalf("expected height 16, got %d (err %v)", height, err)
+	}
+}
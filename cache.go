@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores recent GetWalletTokens results so that repeated lookups of the
+// same wallet skip Etherscan. Implementations must be safe for concurrent use
+// and must not return entries older than their TTL.
+type Cache interface {
+	Get(key string) (*WalletResponse, bool)
+	Set(key string, resp *WalletResponse)
+}
+
+// defaultWalletCacheTTL is how long the server caches wallet lookups unless
+// WALLET_CACHE_TTL says otherwise.
+const defaultWalletCacheTTL = 30 * time.Second
+
+type ttlCacheEntry struct {
+	resp    *WalletResponse
+	expires time.Time
+}
+
+// ttlCache is an in-memory Cache whose entries expire after a fixed TTL.
+// Expired entries are dropped when read, and swept at most once per TTL when
+// writing so that wallets looked up once do not accumulate.
+type ttlCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]ttlCacheEntry
+	lastSweep time.Time
+}
+
+// NewTTLCache returns an in-memory Cache that keeps results for ttl.
+func NewTTLCache(ttl time.Duration) Cache {
+	return &ttlCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]ttlCacheEntry),
+	}
+}
+
+func (c *ttlCache) Get(key string) (*WalletResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.resp, true
+}
+
+func (c *ttlCache) Set(key string, resp *WalletResponse) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if now.Sub(c.lastSweep) >= c.ttl {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+	c.entries[key] = ttlCacheEntry{resp: resp, expires: now.Add(c.ttl)}
+}
+
+// walletCacheKey identifies a lookup by chain and address, plus the query
+// options that change the response's shape.
+func walletCacheKey(q queryOptions, walletAddress string) string {
+	minBalance := ""
+	if q.minBalance != nil {
+		minBalance = q.minBalance.RatString()
+	}
+	return fmt.Sprintf("%d:%s:%d-%d:%t:%t:%s", q.chain.ID, strings.ToLower(walletAddress), q.startBlock, q.endBlock, q.wrappedNativeSummary, q.includeSpam, minBalance)
+}
+
+// clone copies the response deeply enough that callers may modify the copy's
+// tokens without affecting a cached original.
+func (r *WalletResponse) clone() *WalletResponse {
+	c := *r
+	c.Tokens = append([]TokenBalance(nil), r.Tokens...)
+	if r.WrappedNative != nil {
+		wrapped := *r.WrappedNative
+		c.WrappedNative = &wrapped
+	}
+	return &c
+}
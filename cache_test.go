@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetWalletTokensCache(t *testing.T) {
+	var calls atomic.Int32
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[{"contractAddress":"0xc0ffee0000000000000000000000000000000000","tokenName":"Test","tokenSymbol":"TST","tokenDecimal":"0","value":"3","from":"0x3333333333333333333333333333333333333333","to":"%s"}]}`, testWalletA)
+	}))
+	cache := NewTTLCache(time.Minute).(*ttlCache)
+	now := time.Unix(1700000000, 0)
+	cache.now = func() time.Time { return now }
+	WithCache(cache)(tracker)
+
+	lookup := func(opts ...QueryOption) *WalletResponse {
+		t.Helper()
+		resp, err := tracker.GetWalletTokens(context.Background(), testWalletA, opts...)
+		if err != nil {
+			t.Fatalf("GetWalletTokens returned error: %v", err)
+		}
+		return resp
+	}
+
+	first := lookup()
+	first.Tokens[0].Balance = "modified by caller"
+	if got := lookup(); got.Tokens[0].Balance != "3" {
+		t.Fatalf("expected the cached copy to be unaffected by callers, got %+v", got.Tokens)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected the second lookup to hit the cache, got %d token queries", n)
+	}
+
+	polygon, _ := LookupChain("polygon")
+	lookup(OnChain(polygon))
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected another chain to miss the cache, got %d token queries", n)
+	}
+
+	now = now.Add(time.Minute)
+	lookup()
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("expected an expired entry to miss the cache, got %d token queries", n)
+	}
+}
+
+func TestTTLCacheSweepsExpiredEntries(t *testing.T) {
+	cache := NewTTLCache(time.Minute).(*ttlCache)
+	now := time.Unix(1700000000, 0)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", &WalletResponse{})
+	now = now.Add(2 * time.Minute)
+	cache.Set("b", &WalletResponse{})
+	if _, ok := cache.entries["a"]; ok {
+		t.Fatal("expected the expired entry to be swept on write")
+	}
+	if _, ok := cache.Get("b"); !ok {
+		t.Fatal("expected a fresh entry to be returned")
+	}
+}
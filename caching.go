@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var _ WalletService = (*CachingWalletService)(nil)
+
+// CachingWalletService is a WalletService decorator that caches successful
+// GetWalletTokens results for a TTL and collapses concurrent lookups of the
+// same wallet into one call to the wrapped service, so a hot wallet queried
+// by many agents at once costs a single upstream lookup. Failures are not
+// cached.
+type CachingWalletService struct {
+	next       WalletService
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]ttlCacheEntry
+	flights map[string]*walletFlight
+}
+
+// walletFlight is a lookup in progress; done is closed once resp and err are
+// set.
+type walletFlight struct {
+	done chan struct{}
+	resp *WalletResponse
+	err  error
+}
+
+// NewCachingWalletService wraps next, keeping results for ttl and at most
+// maxEntries of them, dropping those closest to expiry first. A ttl of zero
+// or less disables the cache but still collapses concurrent lookups; a
+// maxEntries of zero or less leaves the cache unbounded.
+func NewCachingWalletService(next WalletService, ttl time.Duration, maxEntries int) *CachingWalletService {
+	return &CachingWalletService{
+		next:       next,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]ttlCacheEntry),
+		flights:    make(map[string]*walletFlight),
+	}
+}
+
+// GetWalletTokens returns the cached response for the lookup, waits for a
+// matching lookup already in flight, or else calls the wrapped service. A
+// caller whose context ends stops waiting without affecting the others; if
+// the lookup it waited on failed only because that caller's context ended,
+// it retries in its place.
+func (c *CachingWalletService) GetWalletTokens(ctx context.Context, walletAddress string, opts ...QueryOption) (*WalletResponse, error) {
+	var q queryOptions
+	for _, opt := range opts {
+		opt(&q)
+	}
+	key := fmt.Sprintf("%s:%s:%t", walletCacheKey(q, walletAddress), q.sortBy, q.sortDesc)
+
+	for {
+		c.mu.Lock()
+		if entry, ok := c.entries[key]; ok {
+			if c.now().Before(entry.expires) {
+				c.mu.Unlock()
+				return entry.resp.clone(), nil
+			}
+			delete(c.entries, key)
+		}
+		if flight, ok := c.flights[key]; ok {
+			c.mu.Unlock()
+			select {
+			case <-flight.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if isContextError(flight.err) && ctx.Err() == nil {
+				continue
+			}
+			if flight.err != nil {
+				return nil, flight.err
+			}
+			return flight.resp.clone(), nil
+		}
+
+		flight := &walletFlight{done: make(chan struct{})}
+		c.flights[key] = flight
+		c.mu.Unlock()
+
+		return c.lookup(ctx, key, flight, walletAddress, opts)
+	}
+}
+
+func (c *CachingWalletService) lookup(ctx context.Context, key string, flight *walletFlight, walletAddress string, opts []QueryOption) (*WalletResponse, error) {
+	flight.resp, flight.err = c.next.GetWalletTokens(ctx, walletAddress, opts...)
+	if flight.err == nil {
+		// Keep a private copy, so the caller may modify the response.
+		flight.resp = flight.resp.clone()
+	}
+
+	c.mu.Lock()
+	delete(c.flights, key)
+	if flight.err == nil && c.ttl > 0 {
+		c.store(key, flight.resp)
+	}
+	c.mu.Unlock()
+	close(flight.done)
+
+	if flight.err != nil {
+		return nil, flight.err
+	}
+	return flight.resp.clone(), nil
+}
+
+// store caches resp under key, making room first when the cache is full.
+// Entries share one TTL, so the one closest to expiry is also the oldest.
+// c.mu must be held.
+func (c *CachingWalletService) store(key string, resp *WalletResponse) {
+	now := c.now()
+	if _, ok := c.entries[key]; !ok && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		oldest := ""
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+				continue
+			}
+			if oldest == "" || entry.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			delete(c.entries, oldest)
+		}
+	}
+	c.entries[key] = ttlCacheEntry{resp: resp, expires: now.Add(c.ttl)}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
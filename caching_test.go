@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingWalletService answers every lookup with a one-token response once
+// release is closed, counting the calls it receives.
+type countingWalletService struct {
+	calls   atomic.Int32
+	release chan struct{}
+	err     error
+}
+
+func (s *countingWalletService) GetWalletTokens(ctx context.Context, walletAddress string, opts ...QueryOption) (*WalletResponse, error) {
+	s.calls.Add(1)
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &WalletResponse{Address: walletAddress, Tokens: []TokenBalance{{Symbol: "TST", Balance: "1"}}}, nil
+}
+
+func TestCachingWalletServiceCollapsesConcurrentLookups(t *testing.T) {
+	upstream := &countingWalletService{release: make(chan struct{})}
+	service := NewCachingWalletService(upstream, time.Minute, 10)
+
+	const callers = 20
+	var wg sync.WaitGroup
+	results := make([]*WalletResponse, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := service.GetWalletTokens(context.Background(), testWalletA)
+			if err != nil {
+				t.Errorf("GetWalletTokens returned error: %v", err)
+			}
+			results[i] = resp
+		}(i)
+	}
+	// Let every caller reach the cache before the upstream answers.
+	time.Sleep(50 * time.Millisecond)
+	close(upstream.release)
+	wg.Wait()
+
+	if n := upstream.calls.Load(); n != 1 {
+		t.Fatalf("expected concurrent lookups to share one upstream call, got %d", n)
+	}
+	results[0].Tokens[0].Balance = "changed"
+	if results[1].Tokens[0].Balance != "1" {
+		t.Fatal("expected every caller to get its own copy")
+	}
+
+	if _, err := service.GetWalletTokens(context.Background(), testWalletA); err != nil || upstream.calls.Load() != 1 {
+		t.Fatalf("expected a cached result, got %v after %d calls", err, upstream.calls.Load())
+	}
+	if _, err := service.GetWalletTokens(context.Background(), testWalletA, OnChain(polygon(t))); err != nil || upstream.calls.Load() != 2 {
+		t.Fatalf("expected another chain to miss the cache, got %v after %d calls", err, upstream.calls.Load())
+	}
+}
+
+func TestCachingWalletServiceExpiryAndEviction(t *testing.T) {
+	upstream := &countingWalletService{release: make(chan struct{})}
+	close(upstream.release)
+	service := NewCachingWalletService(upstream, time.Minute, 1)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	service.GetWalletTokens(ctx, testWalletA)
+	service.GetWalletTokens(ctx, testWalletA)
+	if n := upstream.calls.Load(); n != 1 {
+		t.Fatalf("expected a cache hit, got %d calls", n)
+	}
+
+	service.GetWalletTokens(ctx, testWalletB)
+	service.GetWalletTokens(ctx, testWalletA)
+	if n := upstream.calls.Load(); n != 3 {
+		t.Fatalf("expected the first wallet to be evicted, got %d calls", n)
+	}
+
+	now = now.Add(time.Minute)
+	service.GetWalletTokens(ctx, testWalletA)
+	if n := upstream.calls.Load(); n != 4 {
+		t.Fatalf("expected the entry to expire, got %d calls", n)
+	}
+}
+
+func TestCachingWalletServiceDoesNotCacheErrors(t *testing.T) {
+	upstream := &countingWalletService{release: make(chan struct{}), err: ErrRateLimited}
+	close(upstream.release)
+	service := NewCachingWalletService(upstream, time.Minute, 0)
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.GetWalletTokens(context.Background(), testWalletA); !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("expected ErrRateLimited, got %v", err)
+		}
+	}
+	if n := upstream.calls.Load(); n != 2 {
+		t.Fatalf("expected failures to be retried, got %d calls", n)
+	}
+}
+
+func TestCachingWalletServiceCancelledLeader(t *testing.T) {
+	upstream := &countingWalletService{release: make(chan struct{})}
+	service := NewCachingWalletService(upstream, 0, 0)
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, err := service.GetWalletTokens(leaderCtx, testWalletA)
+		leaderDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	followerDone := make(chan error, 1)
+	go func() {
+		_, err := service.GetWalletTokens(context.Background(), testWalletA)
+		followerDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-leaderDone; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the leader to be cancelled, got %v", err)
+	}
+	close(upstream.release)
+	if err := <-followerDone; err != nil {
+		t.Fatalf("expected the follower to retry, got %v", err)
+	}
+	if n := upstream.calls.Load(); n != 2 {
+		t.Fatalf("expected one retry, got %d calls", n)
+	}
+}
+
+func polygon(t *testing.T) Chain {
+	t.Helper()
+	chain, err := LookupChain("polygon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chain
+}
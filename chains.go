@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrUnsupportedChain = errors.New("unsupported chain")
+
+// Chain describes an EVM network reachable through the Etherscan V2 API.
+// WrappedNative is the canonical wrapped native token contract (e.g. WETH).
+type Chain struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	NativeSymbol  string `json:"native_symbol"`
+	WrappedNative string `json:"wrapped_native"`
+}
+
+var supportedChains = []Chain{
+	{ID: 1, Name: "ethereum", NativeSymbol: "ETH", WrappedNative: "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"},
+	{ID: 10, Name: "optimism", NativeSymbol: "ETH", WrappedNative: "0x4200000000000000000000000000000000000006"},
+	{ID: 56, Name: "bsc", NativeSymbol: "BNB", WrappedNative: "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c"},
+	{ID: 137, Name: "polygon", NativeSymbol: "POL", WrappedNative: "0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270"},
+	{ID: 8453, Name: "base", NativeSymbol: "ETH", WrappedNative: "0x4200000000000000000000000000000000000006"},
+	{ID: 42161, Name: "arbitrum", NativeSymbol: "ETH", WrappedNative: "0x82aF49447D8e07e3bd95BD5c56f38Ee7a84b1fA9"},
+	{ID: 43114, Name: "avalanche", NativeSymbol: "AVAX", WrappedNative: "0xB31f66AA3C1e785363F0875A1B74E27b85FD66c7"},
+}
+
+var defaultChain = supportedChains[0]
+
+// LookupChain resolves a chain by name (e.g. "polygon") or numeric chain ID
+// (e.g. "137"). Unknown chains yield ErrUnsupportedChain.
+func LookupChain(idOrName string) (Chain, error) {
+	key := strings.ToLower(strings.TrimSpace(idOrName))
+	if id, err := strconv.ParseInt(key, 10, 64); err == nil {
+		for _, c := range supportedChains {
+			if c.ID == id {
+				return c, nil
+			}
+		}
+	} else {
+		for _, c := range supportedChains {
+			if c.Name == key {
+				return c, nil
+			}
+		}
+	}
+	return Chain{}, fmt.Errorf("%w: %q", ErrUnsupportedChain, idOrName)
+}
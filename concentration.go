@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// ErrPricingDisabled is returned by lookups that need USD prices when no
+// PriceProvider is configured.
+var ErrPricingDisabled = errors.New("usd pricing is disabled")
+
+// concentrationThreshold is the share of value, in percent, that
+// TokensFor90Pct counts tokens up to.
+var concentrationThreshold = big.NewRat(90, 1)
+
+// ConcentrationMetrics describes how a wallet's USD value is spread over its
+// tokens. Only priced tokens count; the native balance and unpriced tokens
+// are left out, as they are from TotalUSD.
+type ConcentrationMetrics struct {
+	Address  string `json:"address"`
+	TotalUSD string `json:"total_usd,omitempty"`
+	// PricedTokens and UnpricedTokens count the tokens with and without a
+	// USD value.
+	PricedTokens   int `json:"priced_tokens"`
+	UnpricedTokens int `json:"unpriced_tokens,omitempty"`
+	// TopToken labels the most valuable token and TopTokenPct is its share
+	// of TotalUSD, e.g. "62.50".
+	TopToken    string `json:"top_token,omitempty"`
+	TopTokenPct string `json:"top_token_pct,omitempty"`
+	// Herfindahl is the sum of the squared shares, as fractions: 1 for a
+	// single token, 1/n for n tokens of equal value.
+	Herfindahl string `json:"herfindahl,omitempty"`
+	// TokensFor90Pct is the fewest tokens, largest first, whose shares add
+	// up to at least 90% of TotalUSD.
+	TokensFor90Pct int `json:"tokens_for_90_pct,omitempty"`
+	// Truncated is passed on from the balances: they may be incomplete.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// GetConcentration computes the wallet's concentration metrics on the
+// configured chain from the portfolio shares of its priced tokens. It fails
+// with ErrPricingDisabled without a PriceProvider.
+func (t *WalletTracker) GetConcentration(ctx context.Context, walletAddress string) (*ConcentrationMetrics, error) {
+	if t.prices == nil {
+		return nil, ErrPricingDisabled
+	}
+	resp, err := t.GetWalletTokens(ctx, walletAddress)
+	if err != nil && !errors.Is(err, ErrNoTransactions) {
+		return nil, err
+	}
+	if resp == nil {
+		return &ConcentrationMetrics{Address: walletAddress}, nil
+	}
+
+	metrics := concentrationOf(resp.Tokens)
+	metrics.Address = resp.Address
+	metrics.TotalUSD = resp.TotalUSD
+	metrics.Truncated = resp.Truncated
+	return metrics, nil
+}
+
+// concentrationOf computes the metrics from each token's PortfolioPct, as set
+// by setPortfolioShares.
+func concentrationOf(tokens []TokenBalance) *ConcentrationMetrics {
+	type share struct {
+		token TokenBalance
+		pct   *big.Rat
+	}
+	var shares []share
+	metrics := &ConcentrationMetrics{}
+	for _, token := range tokens {
+		pct, ok := new(big.Rat).SetString(token.PortfolioPct)
+		if !ok {
+			metrics.UnpricedTokens++
+			continue
+		}
+		shares = append(shares, share{token: token, pct: pct})
+	}
+	metrics.PricedTokens = len(shares)
+	if len(shares) == 0 {
+		return metrics
+	}
+	sort.SliceStable(shares, func(i, j int) bool {
+		return shares[i].pct.Cmp(shares[j].pct) > 0
+	})
+
+	metrics.TopToken = tokenLabel(shares[0].token, LabelDefault)
+	metrics.TopTokenPct = shares[0].pct.FloatString(usdDecimals)
+
+	herfindahl, cumulative := new(big.Rat), new(big.Rat)
+	hundred := big.NewRat(100, 1)
+	for _, s := range shares {
+		fraction := new(big.Rat).Quo(s.pct, hundred)
+		herfindahl.Add(herfindahl, fraction.Mul(fraction, fraction))
+		if cumulative.Cmp(concentrationThreshold) < 0 {
+			cumulative.Add(cumulative, s.pct)
+			metrics.TokensFor90Pct++
+		}
+	}
+	metrics.Herfindahl = herfindahl.FloatString(4)
+	return metrics
+}
+
+type WalletConcentrationRequest struct {
+	WalletAddress string `json:"wallet_address" description:"The cryptocurrency wallet address to analyze"`
+}
+
+func registerWalletConcentration(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_concentration", "Measure how concentrated a wallet's USD value is: the top token's share, the Herfindahl index and how many tokens make up 90% of value. Requires pricing", trackCall(ctx, tracker, func(ctx context.Context, req WalletConcentrationRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		metrics, err := tracker.GetConcentration(ctx, wallet)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatConcentration(metrics)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatConcentration(m *ConcentrationMetrics) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallet Address: %s\n", m.Address))
+	if m.PricedTokens == 0 {
+		builder.WriteString("No priced tokens found, so concentration cannot be measured.\n")
+	} else {
+		builder.WriteString(fmt.Sprintf("Total value of priced tokens: $%s across %d token(s)\n", m.TotalUSD, m.PricedTokens))
+		builder.WriteString(fmt.Sprintf("Top token: %s (%s%%)\n", m.TopToken, m.TopTokenPct))
+		builder.WriteString(fmt.Sprintf("Herfindahl index: %s (1 means a single token)\n", m.Herfindahl))
+		builder.WriteString(fmt.Sprintf("Tokens making up 90%% of value: %d\n", m.TokensFor90Pct))
+	}
+	if m.UnpricedTokens > 0 {
+		builder.WriteString(fmt.Sprintf("%d token(s) without a known price are not included.\n", m.UnpricedTokens))
+	}
+	if m.Truncated {
+		builder.WriteString("Warning: the transfer history is longer than the server fetches; balances only reflect the earliest transfers.\n")
+	}
+	return strings.TrimRight(builder.String(), "\n")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestConcentrationOf(t *testing.T) {
+	tokens := []TokenBalance{
+		{Name: "Small", Symbol: "SML", PortfolioPct: "5.00"},
+		{Name: "Big", Symbol: "BIG", PortfolioPct: "60.00"},
+		{Name: "Unpriced", Symbol: "UNP"},
+		{Name: "Mid", Symbol: "MID", PortfolioPct: "35.00"},
+	}
+
+	m := concentrationOf(tokens)
+	// 0.6² + 0.35² + 0.05² = 0.36 + 0.1225 + 0.0025.
+	if m.PricedTokens != 3 || m.UnpricedTokens != 1 || m.TopToken != "Big (BIG)" || m.TopTokenPct != "60.00" || m.Herfindahl != "0.4850" || m.TokensFor90Pct != 2 {
+		t.Fatalf("unexpected metrics %+v", m)
+	}
+
+	if m := concentrationOf([]TokenBalance{{Name: "Only", PortfolioPct: "100.00"}}); m.Herfindahl != "1.0000" || m.TokensFor90Pct != 1 {
+		t.Fatalf("expected a single token to be fully concentrated, got %+v", m)
+	}
+	if m := concentrationOf([]TokenBalance{{Name: "Unpriced"}}); m.PricedTokens != 0 || m.Herfindahl != "" {
+		t.Fatalf("expected no metrics without priced tokens, got %+v", m)
+	}
+}
+
+func TestGetConcentration(t *testing.T) {
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+			{"contractAddress":"0xc0ffee0000000000000000000000000000000000","tokenName":"Coffee","tokenSymbol":"CAF","tokenDecimal":"0","value":"3","from":"0x3333333333333333333333333333333333333333","to":"%[1]s"},
+			{"contractAddress":"0xbeef000000000000000000000000000000000000","tokenName":"Beef","tokenSymbol":"BEF","tokenDecimal":"0","value":"1","from":"0x3333333333333333333333333333333333333333","to":"%[1]s"}]}`, testWalletA)
+	}))
+
+	if _, err := tracker.GetConcentration(context.Background(), testWalletA); !errors.Is(err, ErrPricingDisabled) {
+		t.Fatalf("expected ErrPricingDisabled without pricing, got %v", err)
+	}
+
+	tracker.prices = &fakePriceProvider{prices: map[string]string{
+		"0xc0ffee0000000000000000000000000000000000": "1",
+		"0xbeef000000000000000000000000000000000000": "1",
+	}}
+	m, err := tracker.GetConcentration(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetConcentration returned error: %v", err)
+	}
+	if m.TotalUSD != "4.00" || m.TopToken != "Coffee (CAF)" || m.TopTokenPct != "75.00" || m.Herfindahl != "0.6250" || m.TokensFor90Pct != 2 {
+		t.Fatalf("unexpected metrics %+v", m)
+	}
+	if content := formatConcentration(m); !strings.Contains(content, "Top token: Coffee (CAF) (75.00%)") || !strings.Contains(content, "Tokens making up 90% of value: 2") {
+		t.Fatalf("unexpected output:\n%s", content)
+	}
+}
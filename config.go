@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// TrackerConfig is the tracker's effective configuration after defaults and
+// options are applied. It never carries secrets: the API key is only reported
+// as set, and endpoint URLs are cut down to scheme and host because providers
+// commonly embed keys in the path or query.
+type TrackerConfig struct {
+	BaseURL   string `json:"base_url"`
+	APIKeySet bool   `json:"api_key_set"`
+	Chain     string `json:"chain"`
+	// CustomHTTPClient is set when Etherscan calls go through a client given
+	// with WithHTTPClient, whose own settings replace HTTPTimeout and
+	// MaxConnsPerHost; both are then left empty.
+	CustomHTTPClient    bool   `json:"custom_http_client,omitempty"`
+	HTTPTimeout         string `json:"http_timeout,omitempty"`
+	ToolTimeout         string `json:"tool_timeout"`
+	RateLimit           int    `json:"rate_limit"`
+	MaxConnsPerHost     int    `json:"max_conns_per_host,omitempty"`
+	MaxBatchSize        int    `json:"max_batch_size"`
+	MaxResponseBytes    int64  `json:"max_response_bytes"`
+	RPCEndpoint         string `json:"rpc_endpoint,omitempty"`
+	RPCTimeout          string `json:"rpc_timeout,omitempty"`
+	RPCRetries          int    `json:"rpc_retries,omitempty"`
+	ENSEnabled          bool   `json:"ens_enabled"`
+	ENSConcurrency      int    `json:"ens_concurrency"`
+	ENSCacheSize        int    `json:"ens_cache_size"`
+	ENSCacheTTL         string `json:"ens_cache_ttl"`
+	ENSNegativeTTL      string `json:"ens_negative_ttl"`
+	BlockHeightCacheTTL string `json:"block_height_cache_ttl"`
+	PricesEnabled       bool   `json:"prices_enabled"`
+	WalletCacheEnabled  bool   `json:"wallet_cache_enabled"`
+	BalanceStrategy     string `json:"balance_strategy"`
+	DecimalOverrides    int    `json:"decimal_overrides"`
+}
+
+func (t *WalletTracker) GetConfig() TrackerConfig {
+	cfg := TrackerConfig{
+		BaseURL:             redactURL(t.baseURL),
+		APIKeySet:           t.apiKey != "",
+		Chain:               fmt.Sprintf("%s (%d)", t.chain().Name, t.chainID),
+		ToolTimeout:         t.toolTimeout.String(),
+		RateLimit:           t.rateLimit,
+		MaxBatchSize:        t.maxBatchSize,
+		MaxResponseBytes:    t.maxRespBytes,
+		ENSEnabled:          t.ens != nil,
+		ENSConcurrency:      t.ensConcurrency,
+		ENSCacheSize:        t.ensCacheSize,
+		ENSCacheTTL:         t.ensCacheTTL.String(),
+		ENSNegativeTTL:      t.ensNegativeTTL.String(),
+		BlockHeightCacheTTL: defaultBlockHeightTTL.String(),
+		PricesEnabled:       t.prices != nil,
+		WalletCacheEnabled:  t.cache != nil,
+		BalanceStrategy:     string(t.balanceStrategy),
+		DecimalOverrides:    len(t.decimalOverrides),
+	}
+	if t.customClient {
+		cfg.CustomHTTPClient = true
+	} else {
+		cfg.HTTPTimeout = t.client.Timeout.String()
+		cfg.MaxConnsPerHost = t.maxConnsPerHost
+	}
+	if t.rpc != nil {
+		cfg.RPCEndpoint = redactURL(t.rpc.url)
+		cfg.RPCTimeout = t.rpc.client.Timeout.String()
+		cfg.RPCRetries = t.rpc.retries
+	}
+	return cfg
+}
+
+// redactURL keeps only the scheme and host of raw.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "[redacted]"
+	}
+	redacted := u.Scheme + "://" + u.Host
+	if strings.Trim(u.Path, "/") != "" || u.RawQuery != "" {
+		redacted += "/[redacted]"
+	}
+	return redacted
+}
+
+type ServerConfigRequest struct{}
+
+func registerServerConfig(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("server_config", "Show the server's effective configuration (secrets redacted)", trackCall(ctx, tracker, func(ctx context.Context, req ServerConfigRequest) (*mcp_golang.ToolResponse, error) {
+		content := formatTrackerConfig(tracker.GetConfig())
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatTrackerConfig(cfg TrackerConfig) string {
+	var builder strings.Builder
+	builder.WriteString("Server configuration:\n")
+	builder.WriteString(fmt.Sprintf("- Etherscan endpoint: %s\n", cfg.BaseURL))
+	if cfg.APIKeySet {
+		builder.WriteString("- API key: set\n")
+	} else {
+		builder.WriteString("- API key: not set\n")
+	}
+	builder.WriteString(fmt.Sprintf("- Chain: %s\n", cfg.Chain))
+	if cfg.CustomHTTPClient {
+		builder.WriteString("- HTTP client: custom (timeout and connection limits set by the client)\n")
+	} else {
+		builder.WriteString(fmt.Sprintf("- HTTP timeout: %s\n", cfg.HTTPTimeout))
+	}
+	if cfg.RateLimit > 0 {
+		builder.WriteString(fmt.Sprintf("- Rate limit: %d calls/s\n", cfg.RateLimit))
+	} else {
+		builder.WriteString("- Rate limit: disabled\n")
+	}
+	if !cfg.CustomHTTPClient {
+		builder.WriteString(fmt.Sprintf("- Max connections per host: %d\n", cfg.MaxConnsPerHost))
+	}
+	builder.WriteString(fmt.Sprintf("- Max batch size: %d\n", cfg.MaxBatchSize))
+	builder.WriteString(fmt.Sprintf("- Max response size: %d bytes\n", cfg.MaxResponseBytes))
+	if cfg.RPCEndpoint != "" {
+		builder.WriteString(fmt.Sprintf("- JSON-RPC endpoint: %s (timeout %s, %d retries)\n", cfg.RPCEndpoint, cfg.RPCTimeout, cfg.RPCRetries))
+	} else {
+		builder.WriteString("- JSON-RPC endpoint: not configured\n")
+	}
+	if cfg.ENSEnabled {
+		builder.WriteString(fmt.Sprintf("- ENS resolution: enabled (concurrency %d, cache %d entries, TTL %s, negative TTL %s)\n",
+			cfg.ENSConcurrency, cfg.ENSCacheSize, cfg.ENSCacheTTL, cfg.ENSNegativeTTL))
+	} else {
+		builder.WriteString("- ENS resolution: disabled\n")
+	}
+	builder.WriteString(fmt.Sprintf("- Block height cache TTL: %s\n", cfg.BlockHeightCacheTTL))
+	if cfg.PricesEnabled {
+		builder.WriteString(fmt.Sprintf("- USD prices: enabled (cached %s)\n", defaultPriceTTL))
+	} else {
+		builder.WriteString("- USD prices: disabled\n")
+	}
+	if cfg.WalletCacheEnabled {
+		builder.WriteString("- Wallet cache: enabled\n")
+	} else {
+		builder.WriteString("- Wallet cache: disabled\n")
+	}
+	builder.WriteString(fmt.Sprintf("- Decimals overrides: %d\n", cfg.DecimalOverrides))
+
+	return strings.TrimRight(builder.String(), "\n")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetConfigRedactsSecrets(t *testing.T) {
+	const (
+		apiKey    = "SECRETAPIKEY123"
+		rpcSecret = "infura-project-secret"
+	)
+	tracker, err := NewWalletTracker(apiKey,
+		WithRPCURL("https://mainnet.infura.io/v3/"+rpcSecret),
+		WithMaxBatchSize(25),
+	)
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+
+	cfg := tracker.GetConfig()
+	if !cfg.APIKeySet || cfg.MaxBatchSize != 25 || cfg.HTTPTimeout != "10s" || cfg.Chain != "ethereum (1)" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if cfg.RPCEndpoint != "https://mainnet.infura.io/[redacted]" || !cfg.ENSEnabled {
+		t.Fatalf("unexpected RPC settings: %+v", cfg)
+	}
+
+	encoded, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("marshalling config: %v", err)
+	}
+	for _, out := range []string{string(encoded), formatTrackerConfig(cfg)} {
+		if strings.Contains(out, apiKey) || strings.Contains(out, rpcSecret) {
+			t.Fatalf("config output leaks a secret:\n%s", out)
+		}
+	}
+}
+
+func TestGetConfigCustomHTTPClient(t *testing.T) {
+	tracker, err := NewWalletTracker("key",
+		WithHTTPClient(&http.Client{Timeout: 3 * time.Second}),
+		WithHTTPTimeout(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+
+	cfg := tracker.GetConfig()
+	if !cfg.CustomHTTPClient || cfg.HTTPTimeout != "" || cfg.MaxConnsPerHost != 0 {
+		t.Fatalf("expected the HTTP settings to be left to the custom client, got %+v", cfg)
+	}
+	content := formatTrackerConfig(cfg)
+	if !strings.Contains(content, "HTTP client: custom") || strings.Contains(content, "Max connections per host") {
+		t.Fatalf("unexpected output:\n%s", content)
+	}
+}
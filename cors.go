@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// corsPolicy decides which browser origins may call the HTTP API. The zero
+// value allows none, leaving browsers to the same-origin policy.
+type corsPolicy struct {
+	anyOrigin bool
+	origins   map[string]bool
+}
+
+func newCORSPolicy(origins []string) corsPolicy {
+	policy := corsPolicy{origins: make(map[string]bool)}
+	for _, origin := range origins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		switch origin {
+		case "":
+		case "*":
+			policy.anyOrigin = true
+		default:
+			policy.origins[strings.ToLower(origin)] = true
+		}
+	}
+	return policy
+}
+
+func (p corsPolicy) allows(origin string) bool {
+	return origin != "" && (p.anyOrigin || p.origins[strings.ToLower(origin)])
+}
+
+// middleware adds CORS headers for allowed origins and answers preflight
+// OPTIONS requests itself, so they never reach a handler.
+func (p corsPolicy) middleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if p.allows(origin) {
+				h := w.Header()
+				if p.anyOrigin {
+					h.Set("Access-Control-Allow-Origin", "*")
+				} else {
+					h.Set("Access-Control-Allow-Origin", origin)
+					h.Add("Vary", "Origin")
+				}
+				h.Set("Access-Control-Expose-Headers", "X-Total-Count")
+			}
+
+			if r.Method == http.MethodOptions {
+				if p.allows(origin) {
+					w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+					if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+						w.Header().Set("Access-Control-Allow-Headers", headers)
+					}
+					w.Header().Set("Access-Control-Max-Age", "600")
+				}
+				w.Header().Set("Allow", "GET, OPTIONS")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSDisabledByDefault(t *testing.T) {
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"0","message":"No transactions found","result":[]}`))
+	}))
+	router := setupRoutes(tracker, tracker)
+
+	req := httptest.NewRequest("GET", "/wallet/"+testWalletA, nil)
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no CORS headers by default, got %q", got)
+	}
+
+	req = httptest.NewRequest("OPTIONS", "/wallet/"+testWalletA, nil)
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("expected a bare 204 preflight, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestCORSAllowlist(t *testing.T) {
+	calls := 0
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"status":"0","message":"No transactions found","result":[]}`))
+	}))
+	WithCORSOrigins([]string{" https://dashboard.example.com/ ", ""})(tracker)
+	router := setupRoutes(tracker, tracker)
+
+	req := httptest.NewRequest("OPTIONS", "/wallet/"+testWalletA, nil)
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 for preflight, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://dashboard.example.com" {
+		t.Fatalf("expected the origin to be allowed, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "GET, OPTIONS" {
+		t.Fatalf("unexpected allowed methods %q", got)
+	}
+	if calls != 0 {
+		t.Fatalf("expected the preflight not to reach Etherscan, got %d calls", calls)
+	}
+
+	req = httptest.NewRequest("GET", "/wallet/"+testWalletA, nil)
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://dashboard.example.com" {
+		t.Fatalf("expected the origin to be allowed, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Expose-Headers"); got != "X-Total-Count" {
+		t.Fatalf("expected X-Total-Count to be exposed, got %q", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Origin" {
+		t.Fatalf("expected Vary: Origin, got %q", got)
+	}
+
+	req = httptest.NewRequest("GET", "/wallet/"+testWalletA, nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected an unlisted origin to be refused, got %q", got)
+	}
+}
+
+func TestCORSWildcard(t *testing.T) {
+	policy := newCORSPolicy([]string{"*"})
+	if !policy.allows("https://anything.example") {
+		t.Fatal("expected the wildcard to allow any origin")
+	}
+	if policy.allows("") {
+		t.Fatal("expected requests without an Origin to get no CORS headers")
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	req.Header.Set("Origin", "https://anything.example")
+	policy.middleware()(http.HandlerFunc(healthzHandler)).ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("expected *, got %q", got)
+	}
+}
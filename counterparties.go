@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+const (
+	defaultCounterpartyLimit = 10
+	maxCounterpartyLimit     = 100
+	nativeDecimals           = 18
+	// maxCounterpartyENSLookups bounds how many of the top counterparties
+	// are reverse-resolved to ENS names.
+	maxCounterpartyENSLookups = 10
+)
+
+type Counterparty struct {
+	Address         string `json:"address"`
+	TransferCount   int    `json:"transfer_count"`
+	TokenTransfers  int    `json:"token_transfers"`
+	NativeTransfers int    `json:"native_transfers"`
+	NativeValue     string `json:"native_value"`
+	ENSName         string `json:"ens_name,omitempty"`
+}
+
+type CounterpartiesResponse struct {
+	Address        string         `json:"address"`
+	NativeSymbol   string         `json:"native_symbol"`
+	Counterparties []Counterparty `json:"counterparties"`
+	// Truncated is set when the token or native transfer history is longer
+	// than the server pages through; the ranking then only covers its start.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type counterpartyAggregate struct {
+	address         string
+	tokenTransfers  int
+	nativeTransfers int
+	nativeValue     *big.Int
+}
+
+// GetCounterparties returns the addresses the wallet most frequently transfers
+// tokens or native currency with, ordered by transfer count and then native value.
+func (t *WalletTracker) GetCounterparties(ctx context.Context, walletAddress string, limit int) (*CounterpartiesResponse, error) {
+	if err := ValidateAddress(walletAddress); err != nil {
+		return nil, err
+	}
+
+	tokenTxs, tokensTruncated, err := t.fetchTokenTransactions(ctx, t.chainID, walletAddress, 0, 0)
+	if err != nil && !errors.Is(err, ErrNoTransactions) {
+		return nil, err
+	}
+	normalTxs, normalTruncated, err := t.fetchNormalTransactions(ctx, t.chainID, walletAddress)
+	if err != nil && !errors.Is(err, ErrNoTransactions) {
+		return nil, err
+	}
+
+	resp := &CounterpartiesResponse{
+		Address:        walletAddress,
+		NativeSymbol:   t.chain().NativeSymbol,
+		Counterparties: summarizeCounterparties(walletAddress, tokenTxs, normalTxs, limit),
+		Truncated:      tokensTruncated || normalTruncated,
+	}
+	t.nameCounterparties(ctx, resp.Counterparties)
+	return resp, nil
+}
+
+// nameCounterparties fills in the primary ENS names of the top counterparties
+// when an ENS resolver is configured. Names are best effort: a failed lookup
+// leaves the counterparty unnamed rather than failing the request.
+func (t *WalletTracker) nameCounterparties(ctx context.Context, counterparties []Counterparty) {
+	if _, ok := t.ens.(ENSReverseResolver); !ok {
+		return
+	}
+	if len(counterparties) > maxCounterpartyENSLookups {
+		counterparties = counterparties[:maxCounterpartyENSLookups]
+	}
+
+	var wg sync.WaitGroup
+	for i := range counterparties {
+		wg.Add(1)
+		go func(cp *Counterparty) {
+			defer wg.Done()
+			name, err := t.LookupENSName(ctx, cp.Address)
+			if err != nil {
+				if !errors.Is(err, ErrENSNameNotFound) {
+					t.logger.Debug("ens reverse lookup failed", "address", cp.Address, "error", err)
+				}
+				return
+			}
+			cp.ENSName = name
+		}(&counterparties[i])
+	}
+	wg.Wait()
+}
+
+func summarizeCounterparties(walletAddress string, tokenTxs []tokenTransaction, normalTxs []normalTransaction, limit int) []Counterparty {
+	if limit <= 0 {
+		limit = defaultCounterpartyLimit
+	}
+	if limit > maxCounterpartyLimit {
+		limit = maxCounterpartyLimit
+	}
+
+	wallet := strings.ToLower(walletAddress)
+	aggregates := make(map[string]*counterpartyAggregate)
+
+	lookup := func(from, to string) *counterpartyAggregate {
+		other := counterpartyOf(wallet, strings.ToLower(from), strings.ToLower(to))
+		if other == "" {
+			return nil
+		}
+		agg, ok := aggregates[other]
+		if !ok {
+			agg = &counterpartyAggregate{address: other, nativeValue: big.NewInt(0)}
+			aggregates[other] = agg
+		}
+		return agg
+	}
+
+	for _, tx := range tokenTxs {
+		if agg := lookup(tx.From, tx.To); agg != nil {
+			agg.tokenTransfers++
+		}
+	}
+
+	for _, tx := range normalTxs {
+		if tx.IsError == "1" {
+			continue
+		}
+		agg := lookup(tx.From, tx.To)
+		if agg == nil {
+			continue
+		}
+		agg.nativeTransfers++
+		if value, ok := new(big.Int).SetString(tx.Value, 10); ok {
+			agg.nativeValue.Add(agg.nativeValue, value)
+		}
+	}
+
+	list := make([]*counterpartyAggregate, 0, len(aggregates))
+	for _, agg := range aggregates {
+		list = append(list, agg)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		ci := list[i].tokenTransfers + list[i].nativeTransfers
+		cj := list[j].tokenTransfers + list[j].nativeTransfers
+		if ci != cj {
+			return ci > cj
+		}
+		if cmp := list[i].nativeValue.Cmp(list[j].nativeValue); cmp != 0 {
+			return cmp > 0
+		}
+		return list[i].address < list[j].address
+	})
+
+	if len(list) > limit {
+		list = list[:limit]
+	}
+
+	result := make([]Counterparty, 0, len(list))
+	for _, agg := range list {
+		result = append(result, Counterparty{
+			Address:         agg.address,
+			TransferCount:   agg.tokenTransfers + agg.nativeTransfers,
+			TokenTransfers:  agg.tokenTransfers,
+			NativeTransfers: agg.nativeTransfers,
+			NativeValue:     formatTokenBalance(agg.nativeValue, nativeDecimals),
+		})
+	}
+	return result
+}
+
+// counterpartyOf returns the side of a transfer that is not the wallet, or an
+// empty string for self-transfers, contract creations and unrelated transfers.
+func counterpartyOf(wallet, from, to string) string {
+	switch {
+	case from == wallet && to == wallet:
+		return ""
+	case from == wallet:
+		return to
+	case to == wallet:
+		return from
+	}
+	return ""
+}
+
+type CounterpartiesRequest struct {
+	WalletAddress string `json:"wallet_address" description:"The cryptocurrency wallet address to analyze"`
+	Limit         int    `json:"limit,omitempty" description:"Maximum number of counterparties to return (default 10, max 100)"`
+}
+
+func registerCounterparties(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_counterparties", "List the addresses a wallet most frequently transfers tokens or native currency with", trackCall(ctx, tracker, func(ctx context.Context, req CounterpartiesRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := tracker.GetCounterparties(ctx, wallet, req.Limit)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatCounterpartiesResponse(resp)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatCounterpartiesResponse(resp *CounterpartiesResponse) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallet Address: %s\n", resp.Address))
+	if len(resp.Counterparties) == 0 {
+		builder.WriteString("No counterparties found.\n")
+	} else {
+		builder.WriteString("Counterparties:\n")
+	}
+	for _, cp := range resp.Counterparties {
+		address := cp.Address
+		if cp.ENSName != "" {
+			address = fmt.Sprintf("%s (%s)", cp.Address, cp.ENSName)
+		}
+		builder.WriteString(fmt.Sprintf("- %s: %d transfers (%d token, %d native), %s %s\n",
+			address, cp.TransferCount, cp.TokenTransfers, cp.NativeTransfers, cp.NativeValue, resp.NativeSymbol))
+	}
+	if resp.Truncated {
+		builder.WriteString("Warning: the transfer history is longer than the server pages through; the ranking only covers its earliest transfers.\n")
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSummarizeCounterparties(t *testing.T) {
+	wallet := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	alice := "0x1111111111111111111111111111111111111111"
+	bob := "0x2222222222222222222222222222222222222222"
+
+	tokenTxs := []tokenTransaction{
+		{From: alice, To: wallet},
+		{From: wallet, To: alice},
+		{From: wallet, To: bob},
+		{From: wallet, To: wallet},
+	}
+	normalTxs := []normalTransaction{
+		{From: wallet, To: bob, Value: "1500000000000000000"},
+		{From: bob, To: wallet, Value: "500000000000000000"},
+		{From: wallet, To: alice, Value: "1000000000000000000", IsError: "1"},
+		{From: wallet, To: "", Value: "0"},
+	}
+
+	got := summarizeCounterparties(wallet, tokenTxs, normalTxs, 0)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 counterparties, got %d: %+v", len(got), got)
+	}
+
+	if got[0].Address != bob || got[0].TransferCount != 3 || got[0].NativeValue != "2" {
+		t.Fatalf("unexpected top counterparty: %+v", got[0])
+	}
+	if got[1].Address != alice || got[1].TokenTransfers != 2 || got[1].NativeTransfers != 0 {
+		t.Fatalf("unexpected second counterparty: %+v", got[1])
+	}
+
+	if limited := summarizeCounterparties(wallet, tokenTxs, normalTxs, 1); len(limited) != 1 {
+		t.Fatalf("expected limit to cap results at 1, got %d", len(limited))
+	}
+}
+
+func TestGetCounterpartiesResolvesENSNames(t *testing.T) {
+	const (
+		alice    = "0x5555555555555555555555555555555555555555"
+		mallory  = "0x2222222222222222222222222222222222222222"
+		carol    = "0x3333333333333333333333333333333333333333"
+		resolver = "0x4444444444444444444444444444444444444444"
+	)
+	word := func(address string) string {
+		return fmt.Sprintf(`"0x%064s"`, strings.TrimPrefix(address, "0x"))
+	}
+	abiString := func(s string) string {
+		data := hex.EncodeToString([]byte(s))
+		for len(data)%64 != 0 {
+			data += "0"
+		}
+		return fmt.Sprintf(`"0x%064x%064x%s"`, 32, len(s), data)
+	}
+	node := func(name string) string {
+		return hex.EncodeToString(ensNamehash(name))
+	}
+
+	var nameCalls atomic.Int32
+	tracker := newRPCTestTracker(t, func(method string, params []json.RawMessage) (string, *rpcError) {
+		var call struct{ To, Data string }
+		if method != "eth_call" || json.Unmarshal(params[0], &call) != nil {
+			return "", &rpcError{Code: -32601, Message: "unexpected method " + method}
+		}
+		selector, arg := call.Data[2:10], call.Data[10:]
+		switch {
+		case strings.EqualFold(call.To, ensRegistryAddress) && arg == node(ensReverseName(carol)):
+			return word(zeroAddress), nil
+		case strings.EqualFold(call.To, ensRegistryAddress):
+			return word(resolver), nil
+		case selector == ensNameSelector:
+			nameCalls.Add(1)
+			// Mallory claims alice's name, which does not resolve to them.
+			return abiString("alice.eth"), nil
+		case selector == ensAddrSelector && arg == node("alice.eth"):
+			return word(alice), nil
+		}
+		return "", &rpcError{Code: -32000, Message: "unexpected call " + call.Data}
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("action") != "tokentx" {
+			fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+			{"from":"%[1]s","to":"%[2]s"},{"from":"%[2]s","to":"%[1]s"},{"from":"%[1]s","to":"%[2]s"},
+			{"from":"%[3]s","to":"%[1]s"},{"from":"%[1]s","to":"%[3]s"},
+			{"from":"%[4]s","to":"%[1]s"}
+		]}`, testWalletA, alice, mallory, carol)
+	}))
+	t.Cleanup(srv.Close)
+	tracker.baseURL = srv.URL
+
+	for i := 0; i < 2; i++ {
+		resp, err := tracker.GetCounterparties(context.Background(), testWalletA, 0)
+		if err != nil {
+			t.Fatalf("GetCounterparties returned error: %v", err)
+		}
+		if len(resp.Counterparties) != 3 {
+			t.Fatalf("expected 3 counterparties, got %+v", resp.Counterparties)
+		}
+		for _, cp := range resp.Counterparties {
+			want := ""
+			if cp.Address == alice {
+				want = "alice.eth"
+			}
+			if cp.ENSName != want {
+				t.Fatalf("expected %s to be named %q, got %q", cp.Address, want, cp.ENSName)
+			}
+		}
+		if content := formatCounterpartiesResponse(resp); !strings.Contains(content, "- "+alice+" (alice.eth): 3 transfers") {
+			t.Fatalf("unexpected output:\n%s", content)
+		}
+	}
+	if got := nameCalls.Load(); got != 2 {
+		t.Fatalf("expected the second lookup to be cached, got %d reverse record reads", got)
+	}
+}
+
+func TestGetCounterpartiesReportsTruncation(t *testing.T) {
+	other := "0x3333333333333333333333333333333333333333"
+	var startBlocks []string
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("action") == "tokentx" {
+			fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+			return
+		}
+		startBlocks = append(startBlocks, q.Get("startblock"))
+		// Every page is full, so the history outlasts the page cap.
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+			{"blockNumber":"%[1]d","from":"%[2]s","to":"%[3]s","value":"1000000000000000000"},
+			{"blockNumber":"%[4]d","from":"%[2]s","to":"%[3]s","value":"1000000000000000000"}
+		]}`, 10*len(startBlocks), testWalletA, other, 10*len(startBlocks)+5)
+	})
+	tracker.chainID = 137
+	tracker.txPageSize = 2
+	tracker.maxTxPages = 2
+
+	resp, err := tracker.GetCounterparties(context.Background(), testWalletA, 0)
+	if err != nil {
+		t.Fatalf("GetCounterparties returned error: %v", err)
+	}
+	if len(startBlocks) != 2 || startBlocks[1] != "15" {
+		t.Fatalf("expected txlist to be paged by block, got start blocks %v", startBlocks)
+	}
+	if !resp.Truncated || resp.NativeSymbol != "POL" || len(resp.Counterparties) != 1 || resp.Counterparties[0].NativeTransfers != 3 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	content := formatCounterpartiesResponse(resp)
+	if !strings.Contains(content, "3 transfers (0 token, 3 native), 3 POL") || !strings.Contains(content, "Warning: the transfer history is longer") {
+		t.Fatalf("unexpected output:\n%s", content)
+	}
+}
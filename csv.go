@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// FormatCSV renders wallet balances as CSV, one row per holding.
+const FormatCSV = "csv"
+
+var csvHeader = []string{"contract", "name", "symbol", "balance", "usd_value"}
+
+// EncodeCSV writes the wallet's balances to w as RFC 4180 CSV with the columns
+// contract, name, symbol, balance and usd_value. The native balance comes
+// first with an empty contract, followed by the wrapped native summary when
+// present and then the tokens. usd_value is empty for unpriced holdings.
+// Cells that a spreadsheet would run as a formula, such as a spam token named
+// "=HYPERLINK(...)", are escaped with a leading single quote.
+func EncodeCSV(w io.Writer, resp *WalletResponse) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	if resp.NativeBalance != "" {
+		symbol := firstNonEmpty(resp.NativeSymbol, defaultChain.NativeSymbol)
+		if err := cw.Write(csvRow("", symbol, symbol, resp.NativeBalance, "")); err != nil {
+			return err
+		}
+	}
+	rows := resp.Tokens
+	if resp.WrappedNative != nil {
+		rows = append([]TokenBalance{*resp.WrappedNative}, rows...)
+	}
+	for _, token := range rows {
+		if err := cw.Write(csvRow(token.Address, token.Name, token.Symbol, token.Balance, token.USDValue)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	return nil
+}
+
+// csvRow escapes every cell with csvCell.
+func csvRow(cells ...string) []string {
+	for i, cell := range cells {
+		cells[i] = csvCell(cell)
+	}
+	return cells
+}
+
+// csvCell prefixes a cell starting with =, +, -, @, a tab or a carriage
+// return with a single quote, so that spreadsheets show it as text instead of
+// evaluating it. Numbers, such as a negative balance, are left as they are:
+// they cannot be formulas.
+func csvCell(cell string) string {
+	if cell == "" || !strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return cell
+	}
+	if _, ok := new(big.Rat).SetString(cell); ok && !strings.ContainsAny(cell, "/eE") {
+		return cell
+	}
+	return "'" + cell
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEncodeCSV(t *testing.T) {
+	resp := &WalletResponse{
+		Address:       testWalletA,
+		NativeSymbol:  "ETH",
+		NativeBalance: "1.5",
+		Tokens: []TokenBalance{
+			{Address: "0xc0ffee0000000000000000000000000000000000", Name: `Coffee, "Premium"`, Symbol: "CAF", Balance: "12.5", USDValue: "3.10"},
+			{Address: "0xdecaf00000000000000000000000000000000000", Name: "Decaf", Symbol: "DCF", Balance: "7"},
+		},
+	}
+
+	var buf strings.Builder
+	if err := EncodeCSV(&buf, resp); err != nil {
+		t.Fatalf("EncodeCSV returned error: %v", err)
+	}
+	want := "contract,name,symbol,balance,usd_value\n" +
+		",ETH,ETH,1.5,\n" +
+		"0xc0ffee0000000000000000000000000000000000,\"Coffee, \"\"Premium\"\"\",CAF,12.5,3.10\n" +
+		"0xdecaf00000000000000000000000000000000000,Decaf,DCF,7,\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected csv:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestEncodeCSVEscapesFormulas(t *testing.T) {
+	resp := &WalletResponse{
+		Address: testWalletA,
+		Tokens: []TokenBalance{
+			{Address: "0xbad0000000000000000000000000000000000000", Name: `=HYPERLINK("http://evil.example","Claim")`, Symbol: "@SUM(A1)", Balance: "-5", USDValue: "-1.50"},
+			{Address: "0xbad1000000000000000000000000000000000000", Name: "+cmd|' /C calc'!A0", Symbol: "-2+3", Balance: "1"},
+			{Address: "0xbad2000000000000000000000000000000000000", Name: "\t=1+1", Symbol: "\r=1", Balance: "1"},
+		},
+	}
+
+	var buf strings.Builder
+	if err := EncodeCSV(&buf, resp); err != nil {
+		t.Fatalf("EncodeCSV returned error: %v", err)
+	}
+	want := "contract,name,symbol,balance,usd_value\n" +
+		"0xbad0000000000000000000000000000000000000,\"'=HYPERLINK(\"\"http://evil.example\"\",\"\"Claim\"\")\",'@SUM(A1),-5,-1.50\n" +
+		"0xbad1000000000000000000000000000000000000,'+cmd|' /C calc'!A0,'-2+3,1,\n" +
+		"0xbad2000000000000000000000000000000000000,'\t=1+1,\"'\r=1\",1,\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected csv:\n%q\nwant:\n%q", buf.String(), want)
+	}
+}
+
+func TestWalletRouteCSV(t *testing.T) {
+	tracker := newTestTracker(t, withNativeBalance("1000000000000000000", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+	}))
+	router := setupRoutes(tracker, tracker)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wallet/"+testWalletA+"?format=csv", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("expected a csv response, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if want := "contract,name,symbol,balance,usd_value\n,ETH,ETH,1,\n"; rec.Body.String() != want {
+		t.Fatalf("unexpected body:\n%s", rec.Body.String())
+	}
+
+	for _, path := range []string{"/wallet/" + testWalletA + "?format=xml", "/wallet/" + testWalletA + "?format=csv&chains=1,137"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", path, rec.Code)
+		}
+	}
+}
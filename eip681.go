@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const paymentURIScheme = "ethereum:"
+
+var ErrInvalidPaymentURI = errors.New("invalid EIP-681 payment URI")
+
+// PaymentURI is the part of an EIP-681 URI
+// (ethereum:[pay-]<target>[@<chain id>][/<function>][?<params>]) that
+// identifies a wallet. Address is a 0x address or an ENS name; ChainID is zero
+// when the URI does not name a chain.
+type PaymentURI struct {
+	Address string
+	ChainID int64
+}
+
+func isPaymentURI(raw string) bool {
+	return len(raw) >= len(paymentURIScheme) && strings.EqualFold(raw[:len(paymentURIScheme)], paymentURIScheme)
+}
+
+// ParsePaymentURI extracts the wallet from an EIP-681 URI. For ERC-20
+// transfer requests (/transfer?address=<recipient>&uint256=<amount>) the
+// target is the token contract, so the recipient is returned instead.
+func ParsePaymentURI(raw string) (PaymentURI, error) {
+	raw = strings.TrimSpace(raw)
+	if !isPaymentURI(raw) {
+		return PaymentURI{}, fmt.Errorf("%w: missing %q scheme", ErrInvalidPaymentURI, paymentURIScheme)
+	}
+	rest := strings.TrimPrefix(raw[len(paymentURIScheme):], "pay-")
+
+	rest, query, _ := strings.Cut(rest, "?")
+	rest, function, _ := strings.Cut(rest, "/")
+	target, chain, hasChain := strings.Cut(rest, "@")
+
+	var uri PaymentURI
+	if hasChain {
+		id, err := strconv.ParseInt(chain, 10, 64)
+		if err != nil || id <= 0 {
+			return PaymentURI{}, fmt.Errorf("%w: bad chain id %q", ErrInvalidPaymentURI, chain)
+		}
+		uri.ChainID = id
+	}
+
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return PaymentURI{}, fmt.Errorf("%w: %v", ErrInvalidPaymentURI, err)
+	}
+	if function == "transfer" && params.Get("address") != "" {
+		target = params.Get("address")
+	}
+
+	switch {
+	case target == "":
+		return PaymentURI{}, fmt.Errorf("%w: missing target address", ErrInvalidPaymentURI)
+	case isENSName(target):
+		uri.Address = normalizeENSName(target)
+	case ValidateAddress(target) == nil:
+		uri.Address = target
+	default:
+		return PaymentURI{}, fmt.Errorf("%w: target %q is neither an address nor an ENS name", ErrInvalidPaymentURI, target)
+	}
+	return uri, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParsePaymentURI(t *testing.T) {
+	const usdc = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+
+	valid := []struct {
+		raw  string
+		want PaymentURI
+	}{
+		{"ethereum:" + testWalletA, PaymentURI{Address: testWalletA}},
+		{"ethereum:pay-" + testWalletA + "@137", PaymentURI{Address: testWalletA, ChainID: 137}},
+		{"ETHEREUM:" + testWalletA + "?value=1e18", PaymentURI{Address: testWalletA}},
+		{"ethereum:" + usdc + "@1/transfer?address=" + testWalletB + "&uint256=1000000", PaymentURI{Address: testWalletB, ChainID: 1}},
+		{"ethereum:Vitalik.eth", PaymentURI{Address: "vitalik.eth"}},
+		{"ethereum:pay-vitalik.eth@10", PaymentURI{Address: "vitalik.eth", ChainID: 10}},
+	}
+	for _, tc := range valid {
+		got, err := ParsePaymentURI(tc.raw)
+		if err != nil {
+			t.Errorf("ParsePaymentURI(%q) returned error: %v", tc.raw, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParsePaymentURI(%q) = %+v, want %+v", tc.raw, got, tc.want)
+		}
+	}
+
+	invalid := []string{
+		testWalletA,
+		"ethereum:",
+		"ethereum:@1",
+		"ethereum:0x1234",
+		"ethereum:" + testWalletA + "@polygon",
+		"ethereum:" + testWalletA + "@-5",
+		"ethereum:" + testWalletA + "?value=%zz",
+	}
+	for _, raw := range invalid {
+		if _, err := ParsePaymentURI(raw); !errors.Is(err, ErrInvalidPaymentURI) {
+			t.Errorf("ParsePaymentURI(%q): expected ErrInvalidPaymentURI, got %v", raw, err)
+		}
+	}
+}
+
+func TestWalletArgPaymentURI(t *testing.T) {
+	tracker, err := NewWalletTracker("test-key")
+	if err != nil {
+		t.Fatalf("Failed to create wallet tracker: %v", err)
+	}
+
+	if got, err := tracker.walletArg("wallet_address", "ethereum:"+testWalletA+"@1"); err != nil || got != testWalletA {
+		t.Fatalf("expected %s, got %q (err %v)", testWalletA, got, err)
+	}
+	if _, err := tracker.walletArg("wallet_address", "ethereum:"+testWalletA+"@137"); err == nil {
+		t.Fatal("expected a non-default chain to be rejected by a mainnet-only tool")
+	}
+	if _, err := tracker.walletArg("wallet_address", "ethereum:vitalik.eth"); err == nil {
+		t.Fatal("expected an ENS target to be rejected")
+	}
+
+	address, chain, explicit, err := tracker.walletChainArg("wallet_address", "ethereum:"+testWalletA+"@137")
+	if err != nil || address != testWalletA || chain.Name != "polygon" || !explicit {
+		t.Fatalf("expected %s on polygon, got %q on %q (err %v)", testWalletA, address, chain.Name, err)
+	}
+	if _, _, explicit, err := tracker.walletChainArg("wallet_address", "ethereum:"+testWalletA); err != nil || explicit {
+		t.Fatalf("expected a URI without @chainid not to name a chain, got explicit=%v (err %v)", explicit, err)
+	}
+	if _, _, _, err := tracker.walletChainArg("wallet_address", "ethereum:"+testWalletA+"@999"); !errors.Is(err, ErrUnsupportedChain) {
+		t.Fatalf("expected ErrUnsupportedChain, got %v", err)
+	}
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	ensRegistryAddress     = "0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e"
+	ensResolverSelector    = "0178b8bf" // resolver(bytes32)
+	ensAddrSelector        = "3b3b57de" // addr(bytes32)
+	ensNameSelector        = "691f3431" // name(bytes32)
+	ensReverseSuffix       = ".addr.reverse"
+	defaultENSConcurrency  = 4
+	zeroAddressHexChars    = "0000000000000000000000000000000000000000"
+	ethCallResultHexLength = 64
+)
+
+var ErrENSNameNotFound = errors.New("ens name does not resolve")
+
+// ENSResolver resolves ENS names such as "vitalik.eth" to hex addresses.
+type ENSResolver interface {
+	Resolve(ctx context.Context, name string) (string, error)
+}
+
+// ENSReverseResolver is implemented by ENSResolvers that can also look up
+// the primary name an address has set in its ENS reverse record.
+type ENSReverseResolver interface {
+	LookupAddress(ctx context.Context, address string) (string, error)
+}
+
+var errENSReverseUnsupported = errors.New("the ens resolver does not support reverse lookups")
+
+// rpcENSResolver resolves names against the ENS registry on Ethereum mainnet
+// through a JSON-RPC endpoint.
+type rpcENSResolver struct {
+	rpc *rpcClient
+}
+
+func (r *rpcENSResolver) Resolve(ctx context.Context, name string) (string, error) {
+	node := ensNamehash(name)
+
+	resolver, err := r.callAddress(ctx, ensRegistryAddress, ensResolverSelector, node)
+	if err != nil {
+		return "", fmt.Errorf("looking up ens resolver: %w", err)
+	}
+	if resolver == "" {
+		return "", fmt.Errorf("%w: %s", ErrENSNameNotFound, name)
+	}
+
+	address, err := r.callAddress(ctx, resolver, ensAddrSelector, node)
+	if err != nil {
+		return "", fmt.Errorf("resolving ens address: %w", err)
+	}
+	if address == "" {
+		return "", fmt.Errorf("%w: %s", ErrENSNameNotFound, name)
+	}
+	return address, nil
+}
+
+// LookupAddress reads the name in the address's reverse record. The record
+// is set by the address's owner and not verified; LookupENSName checks it.
+func (r *rpcENSResolver) LookupAddress(ctx context.Context, address string) (string, error) {
+	node := ensNamehash(ensReverseName(address))
+
+	resolver, err := r.callAddress(ctx, ensRegistryAddress, ensResolverSelector, node)
+	if err != nil {
+		return "", fmt.Errorf("looking up ens reverse resolver: %w", err)
+	}
+	if resolver == "" {
+		return "", fmt.Errorf("%w: no reverse record for %s", ErrENSNameNotFound, address)
+	}
+
+	name, err := r.callString(ctx, resolver, ensNameSelector, node)
+	if err != nil {
+		return "", fmt.Errorf("reading ens reverse record: %w", err)
+	}
+	if name == "" {
+		return "", fmt.Errorf("%w: no reverse record for %s", ErrENSNameNotFound, address)
+	}
+	return name, nil
+}
+
+// callString performs eth_call with selector+node and decodes a single
+// ABI-encoded string return value.
+func (r *rpcENSResolver) callString(ctx context.Context, to, selector string, node []byte) (string, error) {
+	call := map[string]string{
+		"to":   to,
+		"data": "0x" + selector + hex.EncodeToString(node),
+	}
+
+	var result string
+	if err := r.rpc.call(ctx, "eth_call", []any{call, "latest"}, &result); err != nil {
+		return "", err
+	}
+
+	data, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil || len(data) < 64 {
+		return "", fmt.Errorf("unexpected eth_call result %q", result)
+	}
+	offset := new(big.Int).SetBytes(data[:32])
+	if !offset.IsInt64() || offset.Int64() > int64(len(data)-32) {
+		return "", fmt.Errorf("unexpected eth_call result %q", result)
+	}
+	start := int(offset.Int64()) + 32
+	length := new(big.Int).SetBytes(data[start-32 : start])
+	if !length.IsInt64() || length.Int64() > int64(len(data)-start) {
+		return "", fmt.Errorf("unexpected eth_call result %q", result)
+	}
+	return string(data[start : start+int(length.Int64())]), nil
+}
+
+// ensReverseName is the name of address's reverse record, e.g.
+// d8da6bf26964af9d7eed9e03e53415d37aa96045.addr.reverse.
+func ensReverseName(address string) string {
+	return strings.ToLower(strings.TrimPrefix(address, "0x")) + ensReverseSuffix
+}
+
+// callAddress performs eth_call with selector+node and decodes a single
+// address return value, yielding "" for the zero address.
+func (r *rpcENSResolver) callAddress(ctx context.Context, to, selector string, node []byte) (string, error) {
+	call := map[string]string{
+		"to":   to,
+		"data": "0x" + selector + hex.EncodeToString(node),
+	}
+
+	var result string
+	if err := r.rpc.call(ctx, "eth_call", []any{call, "latest"}, &result); err != nil {
+		return "", err
+	}
+
+	word := strings.TrimPrefix(result, "0x")
+	if len(word) < ethCallResultHexLength {
+		return "", fmt.Errorf("unexpected eth_call result %q", result)
+	}
+	addr := word[ethCallResultHexLength-40 : ethCallResultHexLength]
+	if addr == zeroAddressHexChars {
+		return "", nil
+	}
+	return "0x" + addr, nil
+}
+
+// ensNamehash implements the EIP-137 namehash algorithm.
+func ensNamehash(name string) []byte {
+	node := make([]byte, 32)
+	name = normalizeENSName(name)
+	if name == "" {
+		return node
+	}
+
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		labelHash := keccak256([]byte(labels[i]))
+		node = keccak256(node, labelHash)
+	}
+	return node
+}
+
+// normalizeENSName lowercases and trims the name. Full UTS-46 normalization is
+// not performed, so names with non-ASCII characters may not resolve.
+func normalizeENSName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func isENSName(input string) bool {
+	name := normalizeENSName(input)
+	return strings.Contains(name, ".") && !strings.HasPrefix(name, "0x")
+}
+
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// ResolveENS resolves a single ENS name, consulting the tracker's cache first.
+// Names that do not resolve are cached too, for a shorter time. Lookups that
+// reach the resolver are bounded tracker-wide by the ENS concurrency limit.
+func (t *WalletTracker) ResolveENS(ctx context.Context, name string) (string, error) {
+	if t.ens == nil {
+		return "", ErrRPCNotConfigured
+	}
+
+	key := normalizeENSName(name)
+	if entry, ok := t.ensCache.get(key); ok {
+		if entry.notFound {
+			return "", fmt.Errorf("%w: %s", ErrENSNameNotFound, key)
+		}
+		return entry.address, nil
+	}
+
+	select {
+	case t.ensSem <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	address, err := t.ens.Resolve(ctx, key)
+	<-t.ensSem
+
+	switch {
+	case errors.Is(err, ErrENSNameNotFound):
+		t.ensCache.put(key, "", true)
+		return "", err
+	case err != nil:
+		return "", err
+	}
+	t.ensCache.put(key, address, false)
+	return address, nil
+}
+
+// LookupENSName returns the primary ENS name of address. Anyone can put any
+// name in their reverse record, so a name only counts when it resolves back
+// to address; otherwise, or without a record, it returns ErrENSNameNotFound.
+// Results are cached like forward lookups, under the reverse record's name.
+func (t *WalletTracker) LookupENSName(ctx context.Context, address string) (string, error) {
+	if t.ens == nil {
+		return "", ErrRPCNotConfigured
+	}
+	reverser, ok := t.ens.(ENSReverseResolver)
+	if !ok {
+		return "", errENSReverseUnsupported
+	}
+
+	key := ensReverseName(address)
+	if entry, ok := t.ensCache.get(key); ok {
+		if entry.notFound {
+			return "", fmt.Errorf("%w: no primary name for %s", ErrENSNameNotFound, address)
+		}
+		return entry.address, nil
+	}
+
+	select {
+	case t.ensSem <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	name, err := reverser.LookupAddress(ctx, address)
+	<-t.ensSem
+
+	if err == nil {
+		resolved, resolveErr := t.ResolveENS(ctx, name)
+		switch {
+		case resolveErr == nil && strings.EqualFold(resolved, address):
+		case resolveErr == nil || errors.Is(resolveErr, ErrENSNameNotFound):
+			err = fmt.Errorf("%w: %s claims %s, which does not resolve back to it", ErrENSNameNotFound, address, name)
+		default:
+			return "", resolveErr
+		}
+	}
+
+	switch {
+	case errors.Is(err, ErrENSNameNotFound):
+		t.ensCache.put(key, "", true)
+		return "", err
+	case err != nil:
+		return "", err
+	}
+	t.ensCache.put(key, name, false)
+	return name, nil
+}
+
+type ENSResult struct {
+	Name    string `json:"name"`
+	Address string `json:"address,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ResolveENSNames resolves many names concurrently. Names are deduplicated
+// case-insensitively and each failure is reported on its own entry.
+func (t *WalletTracker) ResolveENSNames(ctx context.Context, names []string) ([]ENSResult, error) {
+	if len(names) > t.maxBatchSize {
+		return nil, fmt.Errorf("%w in ens names: %d (max %d)", ErrBatchTooLarge, len(names), t.maxBatchSize)
+	}
+
+	seen := make(map[string]bool, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		key := normalizeENSName(name)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, key)
+	}
+
+	results := make([]ENSResult, len(unique))
+	sem := make(chan struct{}, cap(t.ensSem))
+	var wg sync.WaitGroup
+
+	for i, name := range unique {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i].Name = name
+			address, err := t.ResolveENS(ctx, name)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Address = address
+		}(i, name)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+type ENSResolveBatchRequest struct {
+	Names []string `json:"names" description:"ENS names to resolve, e.g. [\"vitalik.eth\", \"nick.eth\"]"`
+}
+
+func registerENSResolveBatch(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("ens_resolve_batch", "Resolve several ENS names to Ethereum addresses in one call", trackCall(ctx, tracker, func(ctx context.Context, req ENSResolveBatchRequest) (*mcp_golang.ToolResponse, error) {
+		if err := tracker.batchArg("names", req.Names); err != nil {
+			return nil, err
+		}
+
+		results, err := tracker.ResolveENSNames(ctx, req.Names)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatENSResults(results)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatENSResults(results []ENSResult) string {
+	if len(results) == 0 {
+		return "No ENS names provided."
+	}
+
+	var builder strings.Builder
+	builder.WriteString("ENS Names:\n")
+	for _, r := range results {
+		if r.Error != "" {
+			builder.WriteString(fmt.Sprintf("- %s: error: %s\n", r.Name, r.Error))
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("- %s: %s\n", r.Name, r.Address))
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
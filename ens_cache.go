@@ -0,0 +1,93 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultENSCacheSize   = 1000
+	defaultENSCacheTTL    = time.Hour
+	defaultENSNegativeTTL = 5 * time.Minute
+)
+
+type ensCacheEntry struct {
+	address string
+	// notFound records a name that did not resolve. Such entries use the
+	// shorter negative TTL so newly registered names show up quickly.
+	notFound bool
+	expires  time.Time
+}
+
+// ensCache is a bounded TTL cache of ENS lookups keyed by normalized name.
+// When full, expired entries are dropped first, then the one closest to
+// expiry.
+type ensCache struct {
+	mu          sync.Mutex
+	entries     map[string]ensCacheEntry
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+}
+
+func newENSCache(size int, ttl, negativeTTL time.Duration) *ensCache {
+	return &ensCache{
+		entries:     make(map[string]ensCacheEntry),
+		size:        size,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		now:         time.Now,
+	}
+}
+
+func (c *ensCache) get(name string) (ensCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[name]
+	if !ok {
+		return ensCacheEntry{}, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, name)
+		return ensCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *ensCache) put(name, address string, notFound bool) {
+	ttl := c.ttl
+	if notFound {
+		ttl = c.negativeTTL
+	}
+	if ttl <= 0 || c.size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[name]; !ok && len(c.entries) >= c.size {
+		c.evictLocked()
+	}
+	c.entries[name] = ensCacheEntry{address: address, notFound: notFound, expires: c.now().Add(ttl)}
+}
+
+func (c *ensCache) evictLocked() {
+	now := c.now()
+	var oldest string
+	var oldestExpiry time.Time
+	for name, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, name)
+			continue
+		}
+		if oldest == "" || entry.expires.Before(oldestExpiry) {
+			oldest, oldestExpiry = name, entry.expires
+		}
+	}
+	if len(c.entries) >= c.size && oldest != "" {
+		delete(c.entries, oldest)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestENSNamehash(t *testing.T) {
+	tests := map[string]string{
+		"":        "0000000000000000000000000000000000000000000000000000000000000000",
+		"eth":     "93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae",
+		"foo.eth": "de9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f",
+		"Foo.ETH": "de9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f",
+	}
+	for name, want := range tests {
+		if got := hex.EncodeToString(ensNamehash(name)); got != want {
+			t.Errorf("namehash(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+type fakeENSResolver struct {
+	names map[string]string
+	calls atomic.Int32
+}
+
+func (f *fakeENSResolver) Resolve(ctx context.Context, name string) (string, error) {
+	f.calls.Add(1)
+	if addr, ok := f.names[name]; ok {
+		return addr, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrENSNameNotFound, name)
+}
+
+func TestResolveENSNamesDeduplicatesAndCaches(t *testing.T) {
+	resolver := &fakeENSResolver{names: map[string]string{
+		"vitalik.eth": "0xd8da6bf26964af9d7eed9e03e53415d37aa96045",
+	}}
+	tracker, err := NewWalletTracker("key", WithENSResolver(resolver))
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+
+	results, err := tracker.ResolveENSNames(context.Background(), []string{"vitalik.eth", "VITALIK.eth", "missing.eth"})
+	if err != nil {
+		t.Fatalf("ResolveENSNames returned error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 deduplicated results, got %d: %+v", len(results), results)
+	}
+	if results[0].Address != "0xd8da6bf26964af9d7eed9e03e53415d37aa96045" {
+		t.Fatalf("unexpected result for vitalik.eth: %+v", results[0])
+	}
+	if results[1].Error == "" {
+		t.Fatalf("expected error for missing.eth, got %+v", results[1])
+	}
+
+	if _, err := tracker.ResolveENSNames(context.Background(), []string{"vitalik.eth"}); err != nil {
+		t.Fatalf("ResolveENSNames returned error: %v", err)
+	}
+	if got := resolver.calls.Load(); got != 2 {
+		t.Fatalf("expected cached second lookup (2 resolver calls), got %d", got)
+	}
+}
+
+func TestResolveENSCachesNegativeResultsBriefly(t *testing.T) {
+	resolver := &fakeENSResolver{names: map[string]string{}}
+	tracker, err := NewWalletTracker("key", WithENSResolver(resolver), WithENSCache(10, time.Hour, time.Minute))
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	tracker.ensCache.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := tracker.ResolveENS(context.Background(), "missing.eth"); !errors.Is(err, ErrENSNameNotFound) {
+			t.Fatalf("expected ErrENSNameNotFound, got %v", err)
+		}
+	}
+	if got := resolver.calls.Load(); got != 1 {
+		t.Fatalf("expected the negative result to be cached, got %d resolver calls", got)
+	}
+
+	resolver.names["missing.eth"] = testWalletA
+	now = now.Add(time.Minute)
+	if address, err := tracker.ResolveENS(context.Background(), "missing.eth"); err != nil || address != testWalletA {
+		t.Fatalf("expected the name to resolve after the negative TTL, got %q (err %v)", address, err)
+	}
+}
+
+func TestENSCacheEvictsWhenFull(t *testing.T) {
+	cache := newENSCache(2, time.Hour, time.Minute)
+	now := time.Unix(1700000000, 0)
+	cache.now = func() time.Time { return now }
+
+	cache.put("a.eth", testWalletA, false)
+	now = now.Add(time.Second)
+	cache.put("b.eth", testWalletB, false)
+	cache.put("c.eth", testWalletA, false)
+
+	if _, ok := cache.get("a.eth"); ok {
+		t.Fatal("expected the entry closest to expiry to be evicted")
+	}
+	for _, name := range []string{"b.eth", "c.eth"} {
+		if _, ok := cache.get(name); !ok {
+			t.Fatalf("expected %s to be cached", name)
+		}
+	}
+}
+
+type blockingENSResolver struct {
+	inFlight, peak atomic.Int32
+	release        chan struct{}
+}
+
+func (b *blockingENSResolver) Resolve(ctx context.Context, name string) (string, error) {
+	n := b.inFlight.Add(1)
+	defer b.inFlight.Add(-1)
+	for {
+		peak := b.peak.Load()
+		if n <= peak || b.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	<-b.release
+	return testWalletA, nil
+}
+
+func TestResolveENSBoundsConcurrency(t *testing.T) {
+	resolver := &blockingENSResolver{release: make(chan struct{})}
+	tracker, err := NewWalletTracker("key", WithENSResolver(resolver), WithENSConcurrency(2))
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tracker.ResolveENSNames(context.Background(), []string{"a.eth", "b.eth", "c.eth", "d.eth", "e.eth"})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(resolver.release)
+	<-done
+
+	if peak := resolver.peak.Load(); peak > 2 {
+		t.Fatalf("expected at most 2 concurrent lookups, saw %d", peak)
+	}
+}
+
+func TestGetWalletTokensResolvesENSName(t *testing.T) {
+	var queried []string
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		queried = append(queried, r.URL.Query().Get("address"))
+		fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+	}))
+	resolver := &fakeENSResolver{names: map[string]string{"vitalik.eth": testWalletA}}
+	WithENSResolver(resolver)(tracker)
+
+	for i := 0; i < 2; i++ {
+		resp, err := tracker.GetWalletTokens(context.Background(), " Vitalik.eth")
+		if err != nil {
+			t.Fatalf("GetWalletTokens returned error: %v", err)
+		}
+		if resp.Address != testWalletA || resp.ENSName != "vitalik.eth" {
+			t.Fatalf("expected the resolved address alongside the name, got %+v", resp)
+		}
+	}
+	if n := resolver.calls.Load(); n != 1 {
+		t.Fatalf("expected the second lookup to be cached, got %d resolver calls", n)
+	}
+	if len(queried) == 0 || queried[0] != testWalletA {
+		t.Fatalf("expected Etherscan to be queried with the resolved address, got %v", queried)
+	}
+
+	if _, err := tracker.GetWalletTokens(context.Background(), "missing.eth"); !errors.Is(err, ErrENSNameNotFound) {
+		t.Fatalf("expected ErrENSNameNotFound, got %v", err)
+	}
+}
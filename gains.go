@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// TokenGains is a best-effort estimate of the USD gains on one token: every
+// incoming transfer is an acquisition and every outgoing transfer a disposal,
+// both at the market price of the time, and disposals are matched against
+// acquisitions first in, first out. Gas fees are ignored. USD amounts only
+// cover transfers with a known price.
+type TokenGains struct {
+	Address  string `json:"address"`
+	Contract string `json:"contract"`
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	// Acquisitions and Disposals count the incoming and outgoing
+	// transfers; transfers to the wallet itself are neither.
+	Acquisitions int `json:"acquisitions"`
+	Disposals    int `json:"disposals"`
+	// Holding is the amount left from the acquisitions, with CostBasisUSD
+	// its cost and CurrentValueUSD its value now, both over the priced part.
+	Holding         string `json:"holding"`
+	CostBasisUSD    string `json:"cost_basis_usd"`
+	CurrentValueUSD string `json:"current_value_usd,omitempty"`
+	// RealizedUSD is the gain, negative for a loss, on disposals, and
+	// UnrealizedUSD the gain on the holding at the current price; it is
+	// empty when the token has no current price.
+	RealizedUSD   string `json:"realized_usd"`
+	UnrealizedUSD string `json:"unrealized_usd,omitempty"`
+	// UnpricedTransfers counts transfers without a price at their time,
+	// such as those older than the price history, which the USD amounts
+	// leave out.
+	UnpricedTransfers int `json:"unpriced_transfers,omitempty"`
+	// Unmatched is the amount disposed of beyond what the transfers brought
+	// in, e.g. tokens minted to the wallet without a Transfer event.
+	Unmatched string `json:"unmatched,omitempty"`
+	// Truncated is set when the transfer history exceeded the page cap, so
+	// only its earliest transfers were matched.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// gainsLot is an acquisition not yet fully disposed of.
+type gainsLot struct {
+	raw *big.Int
+	// cost is the USD price per whole token, nil when unknown.
+	cost *big.Rat
+}
+
+// GetTokenGains estimates the realized and unrealized USD gains on one token
+// held by the wallet on the configured chain, as described on TokenGains.
+// Historical prices come from the tracker's HistoricalPriceProvider, so it
+// fails with ErrPricingDisabled or ErrHistoricalPricingUnsupported without
+// one. Prices are looked up once for the whole history, but only reach back
+// as far as the provider serves them, 365 days for CoinGecko.
+func (t *WalletTracker) GetTokenGains(ctx context.Context, walletAddress, contractAddress string) (*TokenGains, error) {
+	if err := ValidateAddress(walletAddress); err != nil {
+		return nil, err
+	}
+	if err := ValidateAddress(contractAddress); err != nil {
+		return nil, fmt.Errorf("contract address: %w", err)
+	}
+	history, _, err := t.historicalPrices()
+	if err != nil {
+		return nil, err
+	}
+
+	params := accountListParams("tokentx", walletAddress)
+	params.Set("contractaddress", contractAddress)
+	txs, truncated, err := fetchForward(ctx, t, t.chainID, params, "startblock", 0, t.txPageSize, tokenTransaction.blockNumber)
+	if err != nil && !errors.Is(err, ErrNoTransactions) {
+		return nil, err
+	}
+
+	gains := &TokenGains{Address: walletAddress, Contract: contractAddress, Truncated: truncated}
+	if len(txs) == 0 {
+		gains.Holding, gains.CostBasisUSD, gains.RealizedUSD = "0", "0.00", "0.00"
+		return gains, nil
+	}
+	gains.Name = txs[0].displayName()
+	gains.Symbol = txs[0].displaySymbol()
+	decimals := txs[0].decimals(t.decimalOverrides)
+
+	now := time.Now()
+	from := txs[0].timestamp()
+	if oldest := now.Add(-maxPriceHistory); from.Before(oldest) {
+		from = oldest
+	}
+	prices, err := history.TokenPriceHistory(ctx, t.chain(), contractAddress, from.Add(-historicalPriceWindow), now)
+	if err != nil {
+		return nil, fmt.Errorf("looking up the price history of %s: %w", contractAddress, err)
+	}
+	priceOf := func(ts time.Time) *big.Rat {
+		price, ok := priceAt(prices, ts)
+		if !ok || price.Time.Sub(ts).Abs() > historicalPriceWindow {
+			return nil
+		}
+		return price.USD
+	}
+
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	tokens := func(raw *big.Int) *big.Rat {
+		return new(big.Rat).Quo(new(big.Rat).SetInt(raw), scale)
+	}
+
+	wallet := strings.ToLower(walletAddress)
+	var lots []*gainsLot
+	realized, unmatched := new(big.Rat), new(big.Int)
+	for _, tx := range txs {
+		qty := tx.quantity()
+		if qty == nil || qty.Sign() == 0 {
+			continue
+		}
+		dir := transferDirection(wallet, strings.ToLower(tx.From), strings.ToLower(tx.To))
+		if dir != DirectionIn && dir != DirectionOut {
+			continue
+		}
+		price := priceOf(tx.timestamp())
+		if price == nil {
+			gains.UnpricedTransfers++
+		}
+
+		if dir == DirectionIn {
+			gains.Acquisitions++
+			lots = append(lots, &gainsLot{raw: new(big.Int).Set(qty), cost: price})
+			continue
+		}
+		gains.Disposals++
+		remaining := new(big.Int).Set(qty)
+		for remaining.Sign() > 0 && len(lots) > 0 {
+			lot := lots[0]
+			take := lot.raw
+			if remaining.Cmp(take) < 0 {
+				take = remaining
+			}
+			if price != nil && lot.cost != nil {
+				gain := new(big.Rat).Sub(price, lot.cost)
+				realized.Add(realized, gain.Mul(gain, tokens(take)))
+			}
+			taken := new(big.Int).Set(take)
+			lot.raw.Sub(lot.raw, taken)
+			remaining.Sub(remaining, taken)
+			if lot.raw.Sign() == 0 {
+				lots = lots[1:]
+			}
+		}
+		unmatched.Add(unmatched, remaining)
+	}
+
+	current, err := t.prices.TokenPrices(ctx, t.chain(), []string{contractAddress})
+	if err != nil {
+		return nil, fmt.Errorf("looking up the current price of %s: %w", contractAddress, err)
+	}
+	currentPrice := current[strings.ToLower(contractAddress)]
+
+	holding, costBasis, value, unrealized := new(big.Int), new(big.Rat), new(big.Rat), new(big.Rat)
+	for _, lot := range lots {
+		holding.Add(holding, lot.raw)
+		if lot.cost == nil {
+			continue
+		}
+		amount := tokens(lot.raw)
+		costBasis.Add(costBasis, new(big.Rat).Mul(amount, lot.cost))
+		if currentPrice != nil {
+			value.Add(value, new(big.Rat).Mul(amount, currentPrice))
+			gain := new(big.Rat).Sub(currentPrice, lot.cost)
+			unrealized.Add(unrealized, gain.Mul(gain, amount))
+		}
+	}
+	gains.Holding = formatTokenBalance(holding, decimals)
+	gains.CostBasisUSD = costBasis.FloatString(usdDecimals)
+	gains.RealizedUSD = realized.FloatString(usdDecimals)
+	if currentPrice != nil {
+		gains.CurrentValueUSD = value.FloatString(usdDecimals)
+		gains.UnrealizedUSD = unrealized.FloatString(usdDecimals)
+	}
+	if unmatched.Sign() > 0 {
+		gains.Unmatched = formatTokenBalance(unmatched, decimals)
+	}
+	return gains, nil
+}
+
+type TokenGainsRequest struct {
+	WalletAddress   string `json:"wallet_address" description:"The cryptocurrency wallet address to analyze"`
+	ContractAddress string `json:"contract_address" description:"The ERC-20 token contract address"`
+}
+
+func registerTokenGains(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("token_gains", "Estimate the realized and unrealized USD gains on one token of a wallet, FIFO at historical prices (approximate, e.g. to prepare taxes)", trackCall(ctx, tracker, func(ctx context.Context, req TokenGainsRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+		contract, err := tracker.walletArg("contract_address", req.ContractAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		gains, err := tracker.GetTokenGains(ctx, wallet, contract)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatTokenGains(gains)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatTokenGains(g *TokenGains) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallet Address: %s\n", g.Address))
+	builder.WriteString(fmt.Sprintf("Token: %s\n", tokenLabel(TokenBalance{Address: g.Contract, Name: g.Name, Symbol: g.Symbol}, LabelContract)))
+	if g.Acquisitions+g.Disposals == 0 {
+		builder.WriteString("No transfers of this token found.")
+		return builder.String()
+	}
+	builder.WriteString(fmt.Sprintf("Transfers: %d in, %d out\n", g.Acquisitions, g.Disposals))
+	builder.WriteString(fmt.Sprintf("Realized gain: $%s\n", g.RealizedUSD))
+	builder.WriteString(fmt.Sprintf("Holding: %s, cost basis $%s\n", g.Holding, g.CostBasisUSD))
+	if g.UnrealizedUSD != "" {
+		builder.WriteString(fmt.Sprintf("Unrealized gain: $%s (current value $%s)\n", g.UnrealizedUSD, g.CurrentValueUSD))
+	} else {
+		builder.WriteString("Unrealized gain: unknown, the token has no current price\n")
+	}
+	if g.UnpricedTransfers > 0 {
+		builder.WriteString(fmt.Sprintf("%d transfer(s) had no price at the time and are left out of the USD amounts.\n", g.UnpricedTransfers))
+	}
+	if g.Unmatched != "" {
+		builder.WriteString(fmt.Sprintf("%s more went out than came in through transfers; that part has no cost basis.\n", g.Unmatched))
+	}
+	if g.Truncated {
+		builder.WriteString("Warning: the transfer history is longer than the server fetches; only the earliest transfers were matched.\n")
+	}
+	builder.WriteString("Note: an approximate FIFO estimate in USD. Every incoming transfer counts as a purchase and every outgoing one as a sale at the market price of the time; gas fees are ignored. Not tax advice.")
+	return builder.String()
+}
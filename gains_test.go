@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetTokenGains(t *testing.T) {
+	const token = "0xc0ffee0000000000000000000000000000000000"
+	const other = "0x3333333333333333333333333333333333333333"
+	day := func(n int) time.Time {
+		return time.Now().Add(-time.Duration(n) * 24 * time.Hour).Truncate(time.Hour).UTC()
+	}
+	transfer := func(block int, ts time.Time, value, from, to string) string {
+		return fmt.Sprintf(`{"blockNumber":"%d","timeStamp":"%d","hash":"0x%d","contractAddress":"%s","tokenName":"Coffee","tokenSymbol":"CAF","tokenDecimal":"2","value":"%s","from":"%s","to":"%s"}`,
+			block, ts.Unix(), block, token, value, from, to)
+	}
+
+	var asked string
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		asked = r.URL.Query().Get("contractaddress")
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[%s]}`, strings.Join([]string{
+			// Older than the price history, so it has no cost basis.
+			transfer(1, day(400), "100", other, testWalletA),
+			transfer(2, day(100), "1000", other, testWalletA),
+			transfer(3, day(50), "500", other, testWalletA),
+			transfer(4, day(30), "700", testWalletA, testWalletA),
+			transfer(5, day(20), "1200", testWalletA, other),
+		}, ","))
+	})
+
+	if _, err := tracker.GetTokenGains(context.Background(), testWalletA, token); !errors.Is(err, ErrPricingDisabled) {
+		t.Fatalf("expected ErrPricingDisabled without pricing, got %v", err)
+	}
+
+	provider := &fakeHistoricalPrices{
+		fakePriceProvider: fakePriceProvider{prices: map[string]string{token: "4"}},
+		history: map[string][]HistoricalPrice{token: {
+			{USD: big.NewRat(1, 1), Time: day(100)},
+			{USD: big.NewRat(2, 1), Time: day(50)},
+			{USD: big.NewRat(3, 1), Time: day(20)},
+		}},
+	}
+	tracker.prices = newCachedPrices(provider, time.Minute)
+
+	gains, err := tracker.GetTokenGains(context.Background(), testWalletA, token)
+	if err != nil {
+		t.Fatalf("GetTokenGains returned error: %v", err)
+	}
+	if asked != token {
+		t.Fatalf("expected the transfers to be filtered by contract, got %q", asked)
+	}
+	// The sale of 12 takes the unpriced 1, then 10 bought at $1 and 1 bought
+	// at $2, all sold at $3; 4 bought at $2 remain, now worth $4 each.
+	want := TokenGains{
+		Address: testWalletA, Contract: token, Name: "Coffee", Symbol: "CAF",
+		Acquisitions: 3, Disposals: 1,
+		Holding: "4", CostBasisUSD: "8.00", CurrentValueUSD: "16.00",
+		RealizedUSD: "21.00", UnrealizedUSD: "8.00",
+		UnpricedTransfers: 1,
+	}
+	if *gains != want {
+		t.Fatalf("unexpected gains:\n got %+v\nwant %+v", *gains, want)
+	}
+	var from, to int64
+	if len(provider.ranges) != 1 {
+		t.Fatalf("expected one price history lookup, got %v", provider.ranges)
+	}
+	fmt.Sscanf(provider.ranges[0], token+" %d-%d", &from, &to)
+	if start := time.Now().Add(-maxPriceHistory - historicalPriceWindow); time.Unix(from, 0).Sub(start).Abs() > time.Minute {
+		t.Fatalf("expected the price history to start a year and a day back, got %v", provider.ranges)
+	}
+
+	content := formatTokenGains(gains)
+	for _, line := range []string{"Realized gain: $21.00", "Holding: 4, cost basis $8.00", "Unrealized gain: $8.00 (current value $16.00)", "approximate FIFO estimate"} {
+		if !strings.Contains(content, line) {
+			t.Fatalf("expected %q in:\n%s", line, content)
+		}
+	}
+}
+
+func TestGetTokenGainsUnmatchedDisposal(t *testing.T) {
+	const token = "0xc0ffee0000000000000000000000000000000000"
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[{"blockNumber":"1","timeStamp":"%d","contractAddress":"%s","tokenName":"Coffee","tokenSymbol":"CAF","tokenDecimal":"0","value":"5","from":"%s","to":"0x3333333333333333333333333333333333333333"}]}`,
+			time.Now().Add(-time.Hour).Unix(), token, testWalletA)
+	})
+	tracker.prices = &fakeHistoricalPrices{}
+
+	gains, err := tracker.GetTokenGains(context.Background(), testWalletA, token)
+	if err != nil {
+		t.Fatalf("GetTokenGains returned error: %v", err)
+	}
+	if gains.Unmatched != "5" || gains.Holding != "0" || gains.RealizedUSD != "0.00" || gains.UnrealizedUSD != "" {
+		t.Fatalf("expected an unmatched disposal without a current price, got %+v", gains)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultReadyTTL is how long a readiness result is reused, so frequent
+	// probes from a load balancer do not each cost an Etherscan call.
+	defaultReadyTTL = 5 * time.Second
+	// readyCheckTimeout bounds the Etherscan call behind a readiness probe,
+	// well under the probe timeouts orchestrators use by default.
+	readyCheckTimeout = 2 * time.Second
+)
+
+// readinessCheck reports whether Etherscan is reachable, caching the outcome,
+// failures included, for ttl.
+type readinessCheck struct {
+	check  func(ctx context.Context) error
+	ttl    time.Duration
+	now    func() time.Time
+	logger *slog.Logger
+
+	mu        sync.Mutex
+	err       error
+	checkedAt time.Time
+}
+
+func newReadinessCheck(tracker *WalletTracker, ttl time.Duration) *readinessCheck {
+	provider := &etherscanBlockHeight{tracker: tracker, chainID: tracker.chainID}
+	return &readinessCheck{
+		check: func(ctx context.Context) error {
+			_, err := provider.BlockHeight(ctx)
+			return err
+		},
+		ttl:    ttl,
+		now:    time.Now,
+		logger: tracker.logger,
+	}
+}
+
+func (c *readinessCheck) Ready(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.checkedAt.IsZero() && c.now().Sub(c.checkedAt) < c.ttl {
+		return c.err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readyCheckTimeout)
+	defer cancel()
+	c.err = c.check(ctx)
+	c.checkedAt = c.now()
+	return c.err
+}
+
+// ValidateAPIKey checks the Etherscan API key with one cheap authenticated
+// call, eth_blockNumber through the proxy module, without retrying. It returns
+// nil when the key works, an error wrapping ErrInvalidAPIKey when Etherscan
+// rejects it, and one wrapping ErrRateLimited when the key was accepted but is
+// over its rate limit; any other error means Etherscan could not be asked.
+func (t *WalletTracker) ValidateAPIKey(ctx context.Context) error {
+	params := url.Values{}
+	params.Set("module", "proxy")
+	params.Set("action", "eth_blockNumber")
+
+	apiResp, err := t.queryEtherscanOnce(ctx, t.chainID, params)
+	if err != nil {
+		return err
+	}
+	return apiResp.statusErr()
+}
+
+// healthzHandler reports that the process is up and serving requests.
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("ok\n"))
+}
+
+// readyzHandler reports whether the server can currently reach Etherscan,
+// answering 503 while it cannot.
+func readyzHandler(ready *readinessCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := ready.Ready(r.Context()); err != nil {
+			ready.logger.Warn("Readiness check failed", "error", err)
+			http.Error(w, "Etherscan unreachable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("ok\n"))
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthz(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("liveness must not call Etherscan, got %s", r.URL.RawQuery)
+	})
+
+	rec := httptest.NewRecorder()
+	setupRoutes(tracker, tracker).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+}
+
+func TestReadyzCachesResult(t *testing.T) {
+	calls := 0
+	healthy := true
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if !healthy {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":83,"result":"0x10"}`)
+	})
+
+	ready := newReadinessCheck(tracker, defaultReadyTTL)
+	now := time.Unix(1700000000, 0)
+	ready.now = func() time.Time { return now }
+	handler := readyzHandler(ready)
+
+	probe := func() int {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec.Code
+	}
+
+	if code := probe(); code != http.StatusOK {
+		t.Fatalf("expected 200 while Etherscan answers, got %d", code)
+	}
+	healthy = false
+	if code := probe(); code != http.StatusOK || calls != 1 {
+		t.Fatalf("expected the cached result within the TTL, got %d after %d calls", code, calls)
+	}
+
+	now = now.Add(defaultReadyTTL)
+	if code := probe(); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 once Etherscan fails, got %d", code)
+	}
+	if code := probe(); code != http.StatusServiceUnavailable || calls != 2 {
+		t.Fatalf("expected the failure to be cached too, got %d after %d calls", code, calls)
+	}
+}
+
+func TestValidateAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    error
+	}{
+		{"valid", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("module") != "proxy" || r.URL.Query().Get("action") != "eth_blockNumber" || r.URL.Query().Get("apikey") != "test-key" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"jsonrpc":"2.0","id":83,"result":"0x1234"}`)
+		}, nil},
+		{"invalid", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"status":"0","message":"NOTOK","result":"Invalid API Key"}`)
+		}, ErrInvalidAPIKey},
+		{"rate limited", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		}, ErrRateLimited},
+	}
+
+	for _, tt := range tests {
+		var calls atomic.Int32
+		tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			tt.handler(w, r)
+		})
+		if err := tracker.ValidateAPIKey(context.Background()); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+		if n := calls.Load(); n != 1 {
+			t.Errorf("%s: expected a single call, got %d", tt.name, n)
+		}
+	}
+}
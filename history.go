@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// GetWalletTokensAtBlock returns the wallet's token balances as of block, by
+// netting only transfers up to and including it. The native balance is
+// included when a JSON-RPC endpoint is configured, since Etherscan only
+// serves historical balances to API Pro keys. A block at or beyond the head of
+// the queried chain is treated as the latest state; when the head cannot be
+// read, block is queried as given.
+func (t *WalletTracker) GetWalletTokensAtBlock(ctx context.Context, walletAddress string, block uint64, opts ...QueryOption) (*WalletResponse, error) {
+	q := queryOptions{chain: t.chain()}
+	for _, opt := range opts {
+		opt(&q)
+	}
+	if head, err := t.chainHead(ctx, q.chain.ID); err == nil && block >= head {
+		return t.GetWalletTokens(ctx, walletAddress, opts...)
+	}
+	return t.GetWalletTokens(ctx, walletAddress, append(opts, func(o *queryOptions) {
+		o.endBlock = block
+	})...)
+}
+
+type WalletTokensAtBlockRequest struct {
+	WalletAddress string `json:"wallet_address" description:"The cryptocurrency wallet address to inspect"`
+	Block         uint64 `json:"block" description:"The block number to report balances at"`
+}
+
+func registerWalletTokensAtBlock(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_tokens_at_block", "Show a wallet's token balances as of a given block, e.g. for accounting snapshots", trackCall(ctx, tracker, func(ctx context.Context, req WalletTokensAtBlockRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+		if req.Block == 0 {
+			return nil, fmt.Errorf("%w: block", ErrMissingArgument)
+		}
+
+		resp, err := tracker.GetWalletTokensAtBlock(ctx, wallet, req.Block)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatWalletResponse(resp, formatOptions{Labels: LabelDefault})
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
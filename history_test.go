@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetWalletTokensAtBlock(t *testing.T) {
+	var endBlocks []string
+	tracker := newTestTracker(t, withNativeBalance("1000000000000000000", func(w http.ResponseWriter, r *http.Request) {
+		endBlock := r.URL.Query().Get("endblock")
+		endBlocks = append(endBlocks, endBlock)
+
+		value := "5"
+		if endBlock == "500" {
+			value = "2"
+		}
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[{"blockNumber":"400","contractAddress":"0xc0ffee0000000000000000000000000000000000","tokenName":"Test","tokenSymbol":"TST","tokenDecimal":"0","value":"%s","from":"0x3333333333333333333333333333333333333333","to":"%s"}]}`, value, testWalletA)
+	}))
+	tracker.blockHeight = &fakeBlockHeight{height: 999}
+
+	resp, err := tracker.GetWalletTokensAtBlock(context.Background(), testWalletA, 500)
+	if err != nil {
+		t.Fatalf("GetWalletTokensAtBlock returned error: %v", err)
+	}
+	if endBlocks[0] != "500" {
+		t.Fatalf("expected endblock=500, got %q", endBlocks[0])
+	}
+	if resp.Block != 500 || len(resp.Tokens) != 1 || resp.Tokens[0].Balance != "2" {
+		t.Fatalf("unexpected historical response: %+v", resp)
+	}
+	if resp.NativeBalance != "" {
+		t.Fatalf("expected no native balance without a JSON-RPC endpoint, got %q", resp.NativeBalance)
+	}
+
+	future, err := tracker.GetWalletTokensAtBlock(context.Background(), testWalletA, 1_000_000)
+	if err != nil {
+		t.Fatalf("GetWalletTokensAtBlock returned error: %v", err)
+	}
+	if future.Block != 0 || future.NativeBalance != "1" || future.Tokens[0].Balance != "5" {
+		t.Fatalf("expected a future block to report the latest state, got %+v", future)
+	}
+	if last := endBlocks[len(endBlocks)-1]; last != "999999999" {
+		t.Fatalf("expected the latest query for a future block, got endblock=%q", last)
+	}
+}
+
+func TestGetWalletTokensInBlockRange(t *testing.T) {
+	var queries []string
+	tracker := newTestTracker(t, withNativeBalance("1000000000000000000", func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("startblock")+"-"+r.URL.Query().Get("endblock"))
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+			{"blockNumber":"150","contractAddress":"0xc0ffee0000000000000000000000000000000000","tokenName":"Test","tokenSymbol":"TST","tokenDecimal":"0","value":"2","from":"0x3333333333333333333333333333333333333333","to":"%[1]s"},
+			{"blockNumber":"180","contractAddress":"0xc0ffee0000000000000000000000000000000000","tokenName":"Test","tokenSymbol":"TST","tokenDecimal":"0","value":"7","from":"%[1]s","to":"0x3333333333333333333333333333333333333333"}]}`, testWalletA)
+	}))
+	tracker.prices = &fakePriceProvider{prices: map[string]string{"0xc0ffee0000000000000000000000000000000000": "1"}}
+
+	resp, err := tracker.GetWalletTokens(context.Background(), testWalletA, InBlockRange(100, 200))
+	if err != nil {
+		t.Fatalf("GetWalletTokens returned error: %v", err)
+	}
+	if len(queries) != 1 || queries[0] != "100-200" {
+		t.Fatalf("expected the transfer query to be limited to blocks 100-200, got %v", queries)
+	}
+	if resp.StartBlock != 100 || resp.Block != 200 || len(resp.Tokens) != 1 || resp.Tokens[0].Balance != "-5" {
+		t.Fatalf("expected a net outflow over the range, got %+v", resp)
+	}
+	if resp.NativeBalance != "" || resp.Tokens[0].USDValue != "" || resp.TotalUSD != "" {
+		t.Fatalf("expected no holdings data for a range, got %+v", resp)
+	}
+	if text := formatWalletResponse(resp, formatOptions{}); !strings.Contains(text, "Net changes from block 100 to 200") {
+		t.Fatalf("expected the range in the output, got:\n%s", text)
+	}
+
+	if _, err := tracker.GetWalletTokens(context.Background(), testWalletA, InBlockRange(300, 200)); !errors.Is(err, ErrInvalidBlockRange) {
+		t.Fatalf("expected ErrInvalidBlockRange, got %v", err)
+	}
+	if len(queries) != 1 {
+		t.Fatalf("expected an invalid range to fail before querying Etherscan, got %v", queries)
+	}
+
+	full, err := tracker.GetWalletTokens(context.Background(), testWalletA, InBlockRange(0, 0))
+	if err != nil || full.StartBlock != 0 || full.NativeBalance != "1" || queries[1] != "0-999999999" {
+		t.Fatalf("expected an empty range to query the full history, got %+v, %v (queries %v)", full, err, queries)
+	}
+}
+
+func TestGetWalletTokensAtBlockUsesTheQueriedChainsHead(t *testing.T) {
+	// The RPC endpoint serves mainnet, whose head is far below polygon's.
+	rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x10"}`)
+	}))
+	t.Cleanup(rpc.Close)
+
+	var endBlocks []string
+	etherscan := httptest.NewServer(withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("action") == "eth_blockNumber" {
+			if q.Get("chainid") != "137" {
+				t.Errorf("expected polygon's head to be read, got chainid=%s", q.Get("chainid"))
+			}
+			fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x3e7"}`)
+			return
+		}
+		endBlocks = append(endBlocks, q.Get("endblock"))
+		fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+	}))
+	t.Cleanup(etherscan.Close)
+
+	polygon, _ := LookupChain("polygon")
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		qopt []QueryOption
+	}{
+		{"polygon tracker", []Option{WithChainID(polygon.ID)}, nil},
+		{"mainnet tracker querying polygon", nil, []QueryOption{OnChain(polygon)}},
+	} {
+		endBlocks = nil
+		tracker, err := NewWalletTracker("key", append(tc.opts, WithBaseURL(etherscan.URL), WithRateLimit(0), WithRPCURL(rpc.URL), WithRPCRetries(0))...)
+		if err != nil {
+			t.Fatalf("%s: NewWalletTracker returned error: %v", tc.name, err)
+		}
+		resp, err := tracker.GetWalletTokensAtBlock(context.Background(), testWalletA, 500, tc.qopt...)
+		if err != nil {
+			t.Fatalf("%s: GetWalletTokensAtBlock returned error: %v", tc.name, err)
+		}
+		if resp.Block != 500 || len(endBlocks) == 0 || endBlocks[0] != "500" {
+			t.Fatalf("%s: expected balances at block 500, got block %d with endblocks %v", tc.name, resp.Block, endBlocks)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// InternalTransaction is a native-currency transfer made by contract code
+// (a call, create or selfdestruct inside another transaction), which appears in
+// neither the normal nor the token transfer list.
+type InternalTransaction struct {
+	Hash        string    `json:"hash"`
+	BlockNumber string    `json:"block_number"`
+	Timestamp   time.Time `json:"timestamp"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Direction   string    `json:"direction"`
+	Value       string    `json:"value"`
+	Type        string    `json:"type,omitempty"`
+	// Failed marks a reverted call; its value never moved.
+	Failed bool `json:"failed,omitempty"`
+}
+
+type InternalTransactionsResponse struct {
+	Address      string                `json:"address"`
+	NativeSymbol string                `json:"native_symbol"`
+	Transactions []InternalTransaction `json:"transactions"`
+	// Truncated is set when the server stopped paging before finding limit
+	// matching transactions; older matches may then be missing.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type internalTransaction struct {
+	Hash            string `json:"hash"`
+	BlockNumber     string `json:"blockNumber"`
+	TimeStamp       string `json:"timeStamp"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	ContractAddress string `json:"contractAddress"`
+	Value           string `json:"value"`
+	Type            string `json:"type"`
+	IsError         string `json:"isError"`
+}
+
+// recipient is the receiving address; contract creations report it as the new
+// contract instead of in to.
+func (tx internalTransaction) recipient() string {
+	return firstNonEmpty(tx.To, tx.ContractAddress)
+}
+
+func (tx internalTransaction) timestamp() time.Time {
+	return parseUnixTimestamp(tx.TimeStamp)
+}
+
+// GetInternalTransactions returns the wallet's most recent internal
+// transactions on the configured chain, newest first, filtered by q like
+// GetTokenTransfers.
+func (t *WalletTracker) GetInternalTransactions(ctx context.Context, walletAddress string, q TransferQuery) (*InternalTransactionsResponse, error) {
+	if err := ValidateAddress(walletAddress); err != nil {
+		return nil, err
+	}
+	direction, err := normalizeDirection(q.Direction)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.checkTimeRange(); err != nil {
+		return nil, err
+	}
+
+	resp := &InternalTransactionsResponse{
+		Address:      walletAddress,
+		NativeSymbol: t.chain().NativeSymbol,
+		Transactions: []InternalTransaction{},
+	}
+
+	limit := transferLimit(q.Limit)
+	params := accountListParams("txlistinternal", walletAddress)
+	t.boundBlocks(ctx, t.chainID, q, params)
+
+	resp.Truncated, err = fetchNewestFirst(ctx, t, t.chainID, params, limit, func(txs []internalTransaction) bool {
+		txs = withinTimeRange(txs, q, internalTransaction.timestamp)
+		resp.Transactions = append(resp.Transactions, filterInternalTransactions(walletAddress, txs, direction, limit-len(resp.Transactions))...)
+		return len(resp.Transactions) >= limit
+	})
+	if err != nil && !errors.Is(err, ErrNoTransactions) {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// filterInternalTransactions keeps up to limit of txs (newest first) that
+// match direction, in order.
+func filterInternalTransactions(walletAddress string, txs []internalTransaction, direction string, limit int) []InternalTransaction {
+	wallet := strings.ToLower(walletAddress)
+	result := make([]InternalTransaction, 0, max(0, min(limit, len(txs))))
+
+	for _, tx := range txs {
+		if len(result) >= limit {
+			break
+		}
+		dir := transferDirection(wallet, strings.ToLower(tx.From), strings.ToLower(tx.recipient()))
+		if dir == "" {
+			continue
+		}
+		if direction != "" && dir != direction && dir != DirectionSelf {
+			continue
+		}
+
+		value := "0"
+		if wei, ok := new(big.Int).SetString(strings.TrimSpace(tx.Value), 10); ok {
+			value = formatTokenBalance(wei, nativeDecimals)
+		}
+		result = append(result, InternalTransaction{
+			Hash:        tx.Hash,
+			BlockNumber: tx.BlockNumber,
+			Timestamp:   tx.timestamp(),
+			From:        tx.From,
+			To:          tx.recipient(),
+			Direction:   dir,
+			Value:       value,
+			Type:        tx.Type,
+			Failed:      tx.IsError == "1",
+		})
+	}
+	return result
+}
+
+type InternalTransactionsRequest struct {
+	WalletAddress string `json:"wallet_address" description:"The cryptocurrency wallet address to inspect"`
+	Direction     string `json:"direction,omitempty" description:"Filter by direction: in, out or all (default all)"`
+	Limit         int    `json:"limit,omitempty" description:"Maximum number of transactions to return, newest first (default 50, max 1000)"`
+	StartTime     string `json:"start_time,omitempty" description:"Only transactions at or after this time, RFC 3339 or unix seconds"`
+	EndTime       string `json:"end_time,omitempty" description:"Only transactions at or before this time, RFC 3339 or unix seconds"`
+}
+
+func registerInternalTransactions(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_internal_transactions", "List native-currency transfers made to or from a wallet by contract calls (internal transactions)", trackCall(ctx, tracker, func(ctx context.Context, req InternalTransactionsRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		since, until, err := parseTimeRange(req.StartTime, req.EndTime)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := tracker.GetInternalTransactions(ctx, wallet, TransferQuery{
+			Direction: req.Direction,
+			Limit:     req.Limit,
+			Since:     since,
+			Until:     until,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatInternalTransactionsResponse(resp)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatInternalTransactionsResponse(resp *InternalTransactionsResponse) string {
+	unit := " " + resp.NativeSymbol
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallet Address: %s\n", resp.Address))
+	if len(resp.Transactions) == 0 {
+		builder.WriteString("No internal transactions found.\n")
+	} else {
+		builder.WriteString("Internal transactions:\n")
+	}
+	for _, tx := range resp.Transactions {
+		var action string
+		switch tx.Direction {
+		case DirectionIn:
+			action = fmt.Sprintf("received %s%s from %s", tx.Value, unit, tx.From)
+		case DirectionOut:
+			action = fmt.Sprintf("sent %s%s to %s", tx.Value, unit, tx.To)
+		default:
+			action = fmt.Sprintf("self-transfer of %s%s", tx.Value, unit)
+		}
+		if tx.Failed {
+			action += ", failed"
+		}
+		builder.WriteString(fmt.Sprintf("- %s: %s (tx %s)\n", tx.Timestamp.Format(time.RFC3339), action, tx.Hash))
+	}
+	if resp.Truncated {
+		builder.WriteString("Warning: the internal transaction history is longer than the server pages through; older transactions may be missing.\n")
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGetInternalTransactions(t *testing.T) {
+	other := "0x3333333333333333333333333333333333333333"
+	created := "0x4444444444444444444444444444444444444444"
+
+	// Newest first, as Etherscan returns them with sort=desc.
+	history := []string{
+		fmt.Sprintf(`{"hash":"0x04","blockNumber":"4","timeStamp":"1700000300","from":"%s","to":"%s","value":"7","type":"call","isError":"0"}`, other, created),
+		fmt.Sprintf(`{"hash":"0x03","blockNumber":"3","timeStamp":"1700000200","from":"%s","to":"%s","value":"250000000000000000","type":"call","isError":"1"}`, other, testWalletA),
+		fmt.Sprintf(`{"hash":"0x02","blockNumber":"2","timeStamp":"1700000100","from":"%s","to":"","contractAddress":"%s","value":"0","type":"create","isError":"0"}`, testWalletA, created),
+		fmt.Sprintf(`{"hash":"0x01","blockNumber":"1","timeStamp":"1700000000","from":"%s","to":"%s","value":"1500000000000000000","type":"call","isError":"0"}`, other, testWalletA),
+	}
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if got := q.Get("action"); got != "txlistinternal" {
+			t.Errorf("expected action=txlistinternal, got %q", got)
+		}
+		if q.Get("sort") != "desc" {
+			t.Errorf("expected the newest transactions first, got sort=%q", q.Get("sort"))
+		}
+		page, _ := strconv.Atoi(q.Get("page"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		start, end := min((page-1)*offset, len(history)), min(page*offset, len(history))
+		if start == end {
+			fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[%s]}`, strings.Join(history[start:end], ","))
+	})
+
+	resp, err := tracker.GetInternalTransactions(context.Background(), testWalletA, TransferQuery{})
+	if err != nil {
+		t.Fatalf("GetInternalTransactions returned error: %v", err)
+	}
+	if len(resp.Transactions) != 3 || resp.NativeSymbol != "ETH" {
+		t.Fatalf("expected the wallet's 3 internal transactions, got %+v", resp)
+	}
+	if got := resp.Transactions[0]; got.Hash != "0x03" || got.Direction != DirectionIn || got.Value != "0.25" || !got.Failed {
+		t.Fatalf("expected the failed call first, got %+v", got)
+	}
+	if got := resp.Transactions[1]; got.To != created || got.Direction != DirectionOut || got.Type != "create" {
+		t.Fatalf("expected the creation to name the new contract, got %+v", got)
+	}
+
+	in, err := tracker.GetInternalTransactions(context.Background(), testWalletA, TransferQuery{Direction: "in", Limit: 1})
+	if err != nil || len(in.Transactions) != 1 || in.Transactions[0].Hash != "0x03" {
+		t.Fatalf("expected the newest incoming transaction, got %+v, %v", in, err)
+	}
+
+	text := formatInternalTransactionsResponse(resp)
+	if !strings.Contains(text, "received 1.5 ETH from "+other+" (tx 0x01)") || !strings.Contains(text, "received 0.25 ETH from "+other+", failed (tx 0x03)") {
+		t.Fatalf("unexpected output:\n%s", text)
+	}
+
+	// The newest transaction does not involve the wallet, and page 2 is
+	// beyond the page cap.
+	tracker.maxTxPages = 1
+	capped, err := tracker.GetInternalTransactions(context.Background(), testWalletA, TransferQuery{Limit: 1})
+	if err != nil || len(capped.Transactions) != 0 || !capped.Truncated {
+		t.Fatalf("expected a truncated empty listing, got %+v, %v", capped, err)
+	}
+	if text := formatInternalTransactionsResponse(capped); !strings.Contains(text, "Warning: the internal transaction history is longer") {
+		t.Fatalf("expected a truncation warning, got:\n%s", text)
+	}
+}
+
+func TestGetInternalTransactionsEmpty(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+	})
+
+	resp, err := tracker.GetInternalTransactions(context.Background(), testWalletA, TransferQuery{})
+	if err != nil || len(resp.Transactions) != 0 {
+		t.Fatalf("expected an empty list, got %+v, %v", resp, err)
+	}
+	if text := formatInternalTransactionsResponse(resp); !strings.HasSuffix(text, "No internal transactions found.") {
+		t.Fatalf("unexpected output: %q", text)
+	}
+}
@@ -2,82 +2,554 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport"
 	"github.com/metoro-io/mcp-golang/transport/stdio"
 )
 
 func main() {
 	log.Println("Starting MCP Server...")
 
-	apiKey, ok := os.LookupEnv("ETHERSCAN_API_KEY")
-	if !ok || apiKey == "" {
-		log.Fatal("ETHERSCAN_API_KEY environment variable is required")
+	configPath := flag.String("config", "", "path to a JSON config file; environment variables override it")
+	validate := flag.Bool("validate", false, "check the Etherscan API key and exit")
+	transportName := flag.String("transport", "", "MCP transport, stdio or http; overrides MCP_TRANSPORT")
+	flag.Parse()
+
+	cfg, err := LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := cfg.LoadFromEnvironment(); err != nil {
+		log.Fatalf("Invalid environment: %v", err)
+	}
+	if *transportName != "" {
+		cfg.MCPTransport = *transportName
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if *validate && cfg.APIKey == "" {
+		log.Fatal("-validate needs an Etherscan API key")
+	}
+
+	opts := cfg.Options()
+	if cfg.LogLevel != "" || cfg.LogFormat != "" {
+		logger, err := newLogger(cfg.LogLevel, cfg.LogFormat)
+		if err != nil {
+			log.Fatalf("Invalid logging configuration: %v", err)
+		}
+		// Also route the standard log package, used during startup and
+		// shutdown, through the configured handler.
+		slog.SetDefault(logger)
+		opts = append(opts, WithLogger(logger))
 	}
 
-	walletTracker, err := NewWalletTracker(apiKey)
+	metrics := NewPrometheusMetrics()
+	opts = append(opts, WithMetricsHandler(metrics), WithRetryMetrics(metrics))
+
+	// In mock mode the key may be missing: only the tools backed by the
+	// fixtures are registered, so no tool call reaches Etherscan.
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		apiKey = "mock"
+	}
+	walletTracker, err := NewWalletTracker(apiKey, opts...)
 	if err != nil {
 		log.Fatalf("Failed to initialize wallet tracker: %v", err)
 	}
+	if *validate {
+		os.Exit(validateAPIKey(walletTracker))
+	}
+	var wallets WalletService = walletTracker
+	if cfg.Fixtures != "" {
+		fixtures, err := LoadWalletFixtures(cfg.Fixtures)
+		if err != nil {
+			log.Fatalf("Failed to load wallet fixtures: %v", err)
+		}
+		wallets = NewMockWalletTracker(fixtures)
+		log.Printf("Mock mode: wallet_tracker serves %d wallet fixtures from %s", len(fixtures), cfg.Fixtures)
+	} else {
+		// The tracker caches results itself; the decorator only collapses
+		// concurrent lookups of a hot wallet into one.
+		wallets = NewCachingWalletService(wallets, 0, 0)
+	}
+	wallets = NewMeteredWalletService(wallets, metrics)
+
+	signalled, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Tool calls derive from rootCtx, which outlives the signal so in-flight
+	// calls can finish; it is cancelled once the grace period is over.
+	rootCtx, cancelCalls := context.WithCancel(context.Background())
+	defer cancelCalls()
+
+	// The HTTP API runs alongside the MCP server when an address is given.
+	var httpServer *http.Server
+	if cfg.ListenAddr != "" {
+		if httpServer, err = startServer(walletTracker, wallets, cfg.ListenAddr); err != nil {
+			log.Fatalf("Failed to start HTTP server: %v", err)
+		}
+	}
 
-	// Start the HTTP server
-	//startServer(walletTracker)
+	// The monitor posts wallet changes to the webhook until the server stops.
+	if cfg.WebhookURL != "" && len(cfg.MonitorWallets) > 0 {
+		monitor, err := NewMonitor(wallets, cfg.WebhookURL, WithPollInterval(time.Duration(cfg.MonitorInterval)), WithMonitorLogger(walletTracker.logger))
+		if err != nil {
+			log.Fatalf("Failed to initialize wallet monitor: %v", err)
+		}
+		for _, wallet := range cfg.MonitorWallets {
+			if err := monitor.Watch(wallet); err != nil {
+				log.Fatalf("Failed to watch wallet: %v", err)
+			}
+		}
+		go monitor.Run(rootCtx)
+		log.Printf("Monitoring %d wallet(s) every %s", len(cfg.MonitorWallets), time.Duration(cfg.MonitorInterval))
+	}
 
-	// Initialize MCP server with stdio transport
-	server := mcp_golang.NewServer(stdio.NewStdioServerTransport())
+	// Initialize the MCP server. With stdio, the transport does not report when
+	// stdin reaches EOF, so watch for it ourselves to know when the client has
+	// gone away. Over HTTP, clients come and go, and only a signal stops the
+	// server. Either way, wallet:// resource reads are answered in front of
+	// the server.
+	var (
+		clientGone    <-chan struct{}
+		mcpHTTPServer *http.Server
+		mcpTransport  transport.Transport
+	)
+	if cfg.MCPTransport == TransportHTTP {
+		httpTransport := newMCPHTTPTransport()
+		if mcpHTTPServer, err = startMCPHTTPServer(httpTransport, cfg.MCPAddr, walletTracker.logger); err != nil {
+			log.Fatalf("Failed to start MCP HTTP server: %v", err)
+		}
+		mcpTransport = httpTransport
+	} else {
+		stdin := newEOFNotifyReader(os.Stdin)
+		clientGone = stdin.Done()
+		mcpTransport = stdio.NewStdioServerTransportWithIO(stdin, os.Stdout)
+	}
+	server := mcp_golang.NewServer(newWalletResourceTransport(rootCtx, mcpTransport, walletTracker, wallets))
 
 	// Register tools, prompts, and resources here...
-	if err := registerWalletTracker(server, walletTracker); err != nil {
+	if err := registerWalletTracker(rootCtx, server, walletTracker, wallets); err != nil {
 		log.Fatalf("Failed to register wallet tracker tool: %v", err)
 	}
+	if err := registerWalletResource(server); err != nil {
+		log.Fatalf("Failed to register wallet resource: %v", err)
+	}
+	// Mock mode only has fixtures for the wallets service, so the tools that
+	// query Etherscan or the RPC endpoint directly are left out.
+	if cfg.Fixtures == "" {
+		if err := registerEtherscanTools(rootCtx, server, walletTracker); err != nil {
+			log.Fatalf("Failed to register tools: %v", err)
+		}
+	}
 
-	// Start the server
+	// Start the server. Serve only wires up the transport and returns; requests
+	// are handled in the background until the stdio client closes stdin or the
+	// process is signalled.
 	log.Println("MCP Server is now running and waiting for requests...")
 	if err := server.Serve(); err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
-	select {}
+
+	select {
+	case <-clientGone:
+		log.Println("Client closed stdin, shutting down")
+	case <-signalled.Done():
+		log.Println("Received shutdown signal, shutting down")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownGracePeriod)
+	defer cancel()
+	if err := walletTracker.Drain(shutdownCtx); err != nil {
+		log.Printf("Tool calls still running after %s, cancelling them", shutdownGracePeriod)
+	}
+	if httpServer != nil {
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("HTTP server did not shut down cleanly: %v", err)
+		}
+	}
+	if mcpHTTPServer != nil {
+		if err := mcpHTTPServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("MCP HTTP server did not shut down cleanly: %v", err)
+		}
+	}
+}
+
+// registerEtherscanTools registers the tools that look data up through
+// tracker itself rather than a WalletService.
+func registerEtherscanTools(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	tools := []struct {
+		name     string
+		register func(context.Context, *mcp_golang.Server, *WalletTracker) error
+	}{
+		{"counterparties tool", registerCounterparties},
+		{"token transfers tool", registerTokenTransfers},
+		{"ENS batch resolve tool", registerENSResolveBatch},
+		{"balances-for tool", registerBalancesFor},
+		{"pending transactions tool", registerPending},
+		{"wallet age tool", registerWalletAge},
+		{"wallet changes tool", registerWalletChanges},
+		{"NFT history tool", registerNFTHistory},
+		{"server config tool", registerServerConfig},
+		{"ping tool", registerPing},
+		{"wallet NFTs tool", registerWalletNFTs},
+		{"wallet tokens at block tool", registerWalletTokensAtBlock},
+		{"wallet value at tool", registerWalletValueAt},
+		{"token gains tool", registerTokenGains},
+		{"wallets tracker tool", registerWalletsTracker},
+		{"token balance tool", registerTokenBalance},
+		{"internal transactions tool", registerInternalTransactions},
+		{"wallet summary tool", registerWalletSummary},
+		{"wallet concentration tool", registerWalletConcentration},
+		{"approvals tool", registerApprovals},
+		{"wallet risk tool", registerWalletRisk},
+		{"transaction token flows tool", registerTxTokenFlows},
+	}
+	for _, tool := range tools {
+		if err := tool.register(ctx, server, tracker); err != nil {
+			return fmt.Errorf("registering %s: %w", tool.name, err)
+		}
+	}
+	return nil
+}
+
+// validateAPIKey runs the -validate check and returns the exit code: 0 when
+// the key works, even if it is currently rate limited, and 1 otherwise.
+func validateAPIKey(tracker *WalletTracker) int {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultHTTPTimeout)
+	defer cancel()
+
+	err := tracker.ValidateAPIKey(ctx)
+	switch {
+	case err == nil:
+		log.Println("Etherscan API key is valid")
+		return 0
+	case errors.Is(err, ErrRateLimited):
+		log.Printf("Etherscan API key is valid but currently rate limited: %v", err)
+		return 0
+	case errors.Is(err, ErrInvalidAPIKey):
+		log.Printf("Etherscan API key is invalid: %v", err)
+		return 1
+	default:
+		log.Printf("Could not validate the Etherscan API key: %v", err)
+		return 1
+	}
+}
+
+// newLogger builds a logger writing to stderr, since stdout carries the MCP
+// protocol. level is debug, info (the default), warn or error; format is text
+// (the default) or json.
+func newLogger(level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if level != "" {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("LOG_LEVEL: %w", err)
+		}
+	}
+	handlerOpts := &slog.HandlerOptions{Level: lvl}
+
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, handlerOpts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, handlerOpts)), nil
+	default:
+		return nil, fmt.Errorf("LOG_FORMAT: unsupported format %q (want text or json)", format)
+	}
+}
+
+// eofNotifyReader wraps the server's input and closes Done once it returns
+// io.EOF or any other read error, i.e. once no further requests can arrive.
+type eofNotifyReader struct {
+	r    io.Reader
+	done chan struct{}
+	once sync.Once
+}
+
+func newEOFNotifyReader(r io.Reader) *eofNotifyReader {
+	return &eofNotifyReader{r: r, done: make(chan struct{})}
+}
+
+func (e *eofNotifyReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil {
+		e.once.Do(func() { close(e.done) })
+	}
+	return n, err
+}
+
+func (e *eofNotifyReader) Done() <-chan struct{} {
+	return e.done
 }
 
 type WalletTrackerRequest struct {
-	WalletAddress string `json:"wallet_address" description:"The cryptocurrency wallet address to track"`
+	WalletAddress string `json:"wallet_address" description:"The cryptocurrency wallet address or ENS name (e.g. vitalik.eth) to track, or an EIP-681 URI (ethereum:0x...@137) to track it on another chain"`
+	Labels        string `json:"labels,omitempty" description:"How tokens are labelled: default (name, symbol in parentheses), contract (always include the contract address) or symbol (prefer the symbol)"`
+	Chain         string `json:"chain,omitempty" description:"The chain to query, by name (ethereum, polygon, bsc, arbitrum, ...) or chain ID; defaults to the server's configured chain"`
+	WrappedNative bool   `json:"wrapped_native,omitempty" description:"Report the wrapped native token (e.g. WETH) separately as spendable balance"`
+	IncludeSpam   bool   `json:"include_spam,omitempty" description:"Show tokens that look like spam airdrops (URLs or emoji in the name, or blocklisted), which are hidden by default"`
+	Format        string `json:"format,omitempty" description:"Output format: text (default, human-readable), json (the full structured response) or csv (contract, name, symbol, balance, usd_value rows for spreadsheets)"`
+	SortBy        string `json:"sort_by,omitempty" description:"Order tokens by name (default), balance or value (USD value; unpriced tokens last)"`
+	SortDesc      bool   `json:"sort_desc,omitempty" description:"Sort in descending order, e.g. with sort_by=value to list the largest holdings first"`
+	MinBalance    string `json:"min_balance,omitempty" description:"Hide tokens whose balance is below this amount (a decimal such as 0.01), e.g. dust and spam airdrops"`
+	StartBlock    uint64 `json:"start_block,omitempty" description:"Only net transfers from this block on; balances then show the wallet's net change over the range instead of its holdings"`
+	EndBlock      uint64 `json:"end_block,omitempty" description:"Only net transfers up to and including this block; defaults to the latest block"`
 }
 
-func registerWalletTracker(server *mcp_golang.Server, tracker *WalletTracker) error {
+// registerWalletTracker registers the wallet_tracker tool and the
+// analyze_portfolio prompt, which look balances up with wallets; tracker
+// parses the arguments and bounds the calls.
+func registerWalletTracker(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker, wallets WalletService) error {
+	if err := registerPortfolioPrompt(ctx, server, tracker, wallets); err != nil {
+		return err
+	}
+
 	// Register "wallet tracker" tool
-	return server.RegisterTool("wallet_tracker", "Track the balance of a cryptocurrency wallet", func(req WalletTrackerRequest) (*mcp_golang.ToolResponse, error) {
-		walletResp, err := tracker.GetWalletTokens(context.Background(), req.WalletAddress)
+	return server.RegisterTool("wallet_tracker", "Track the balance of a cryptocurrency wallet", trackCall(ctx, tracker, func(ctx context.Context, req WalletTrackerRequest) (*mcp_golang.ToolResponse, error) {
+		policy, err := parseLabelPolicy(req.Labels)
+		if err != nil {
+			return nil, err
+		}
+		format, err := parseOutputFormat(req.Format)
 		if err != nil {
 			return nil, err
 		}
 
-		content := formatWalletResponse(walletResp)
+		wallet, chain, explicit, err := tracker.walletChainArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+		if chain, err = tracker.chainArg("chain", req.Chain, chain, explicit); err != nil {
+			return nil, err
+		}
+
+		minBalance, err := parseMinBalance(req.MinBalance)
+		if err != nil {
+			return nil, fmt.Errorf("min_balance: %w", err)
+		}
+
+		sortBy, err := parseSortBy(req.SortBy)
+		if err != nil {
+			return nil, fmt.Errorf("sort_by: %w", err)
+		}
+
+		if err := validateBlockRange(req.StartBlock, req.EndBlock); err != nil {
+			return nil, err
+		}
+
+		opts := []QueryOption{OnChain(chain), WithMinBalance(minBalance), SortTokens(sortBy, req.SortDesc)}
+		if req.StartBlock != 0 || req.EndBlock != 0 {
+			opts = append(opts, InBlockRange(req.StartBlock, req.EndBlock))
+		}
+		if req.WrappedNative {
+			opts = append(opts, WithWrappedNativeSummary())
+		}
+		if req.IncludeSpam {
+			opts = append(opts, IncludeSpam())
+		}
+
+		walletResp, err := wallets.GetWalletTokens(ctx, wallet, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		if format == FormatJSON {
+			encoded, err := json.MarshalIndent(walletResp, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("encoding wallet response: %w", err)
+			}
+			return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(string(encoded))), nil
+		}
+		if format == FormatCSV {
+			var buf strings.Builder
+			if err := EncodeCSV(&buf, walletResp); err != nil {
+				return nil, fmt.Errorf("encoding wallet response: %w", err)
+			}
+			return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(buf.String())), nil
+		}
+
+		content := formatWalletResponse(walletResp, formatOptions{Labels: policy})
 		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
-	})
+	}))
 }
 
-func formatWalletResponse(resp *WalletResponse) string {
-	if len(resp.Tokens) == 0 {
-		return fmt.Sprintf("Wallet Address: %s\nNo token balances found.", resp.Address)
+// Output formats of the wallet_tracker tool.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+func parseOutputFormat(raw string) (string, error) {
+	switch format := strings.ToLower(strings.TrimSpace(raw)); format {
+	case "":
+		return FormatText, nil
+	case FormatText, FormatJSON, FormatCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown format %q: expected text, json or csv", raw)
+	}
+}
+
+// Token label policies for the text output.
+const (
+	LabelDefault  = "default"
+	LabelContract = "contract"
+	LabelSymbol   = "symbol"
+)
+
+type formatOptions struct {
+	Labels string
+	// TrailingNewline ends the output with a newline instead of trimming it,
+	// so that several outputs can be concatenated directly.
+	TrailingNewline bool
+}
+
+func parseLabelPolicy(raw string) (string, error) {
+	switch policy := strings.ToLower(strings.TrimSpace(raw)); policy {
+	case "":
+		return LabelDefault, nil
+	case LabelDefault, LabelContract, LabelSymbol:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown label policy %q: expected default, contract or symbol", raw)
+	}
+}
+
+func formatWalletResponse(resp *WalletResponse, opts formatOptions) string {
+	header := fmt.Sprintf("Wallet Address: %s\n", resp.Address)
+	if resp.ENSName != "" {
+		header = fmt.Sprintf("Wallet Address: %s (%s)\n", resp.Address, resp.ENSName)
+	}
+	switch {
+	case resp.StartBlock != 0 && resp.Block != 0:
+		header += fmt.Sprintf("Net changes from block %d to %d\n", resp.StartBlock, resp.Block)
+	case resp.StartBlock != 0:
+		header += fmt.Sprintf("Net changes since block %d\n", resp.StartBlock)
+	case resp.Block != 0:
+		header += fmt.Sprintf("As of block: %d\n", resp.Block)
+	}
+	if resp.NativeBalance != "" {
+		header += fmt.Sprintf("%s: %s\n", firstNonEmpty(resp.NativeSymbol, defaultChain.NativeSymbol), resp.NativeBalance)
+	}
+	if resp.WrappedNative != nil {
+		header += fmt.Sprintf("Wrapped native (%s): %s%s\n", firstNonEmpty(resp.WrappedNative.Symbol, resp.WrappedNative.Address), resp.WrappedNative.Balance, usdSuffix(*resp.WrappedNative))
 	}
 
 	var builder strings.Builder
-	builder.WriteString(fmt.Sprintf("Wallet Address: %s\nTokens:\n", resp.Address))
-	for _, token := range resp.Tokens {
-		name := token.Name
-		if name == "" {
-			name = token.Address
+	builder.WriteString(header)
+	if len(resp.Tokens) == 0 {
+		builder.WriteString("No token balances found.")
+	} else {
+		builder.WriteString("Tokens:\n")
+		for _, token := range resp.Tokens {
+			builder.WriteString(fmt.Sprintf("- %s: %s%s%s%s\n", tokenLabel(token, opts.Labels), token.Balance, approximateSuffix(token), skippedSuffix(token), usdSuffix(token)))
 		}
+		if resp.TotalUSD != "" {
+			builder.WriteString(fmt.Sprintf("Total value of priced tokens: $%s\n", resp.TotalUSD))
+		}
+	}
+
+	builder.WriteString(skippedTransactionsNote(resp))
+	builder.WriteString(truncatedNote(resp))
+	builder.WriteString(approximateNote(resp))
+	builder.WriteString(hiddenSpamNote(resp))
+
+	out := strings.TrimRight(builder.String(), "\n")
+	if opts.TrailingNewline {
+		out += "\n"
+	}
+	return out
+}
+
+func approximateSuffix(token TokenBalance) string {
+	if !token.Approximate {
+		return ""
+	}
+	return " (approximate)"
+}
+
+func skippedSuffix(token TokenBalance) string {
+	if token.SkippedTransfers == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (incomplete: %d transfer(s) skipped)", token.SkippedTransfers)
+}
+
+func usdSuffix(token TokenBalance) string {
+	if token.USDValue == "" {
+		return ""
+	}
+	if token.PortfolioPct != "" {
+		return fmt.Sprintf(" ($%s, %s%%)", token.USDValue, token.PortfolioPct)
+	}
+	return fmt.Sprintf(" ($%s)", token.USDValue)
+}
+
+// tokenLabel renders a token's display name according to policy. The default
+// shows the name (or contract when unnamed) followed by the symbol when known.
+func tokenLabel(token TokenBalance, policy string) string {
+	switch policy {
+	case LabelContract:
+		label := firstNonEmpty(token.Name, token.Symbol)
+		if label == "" {
+			return token.Address
+		}
+		if token.Name != "" && token.Symbol != "" {
+			return fmt.Sprintf("%s (%s, %s)", token.Name, token.Symbol, token.Address)
+		}
+		return fmt.Sprintf("%s (%s)", label, token.Address)
+	case LabelSymbol:
+		return firstNonEmpty(token.Symbol, token.Name, token.Address)
+	default:
+		name := firstNonEmpty(token.Name, token.Address)
 		if token.Symbol != "" {
-			builder.WriteString(fmt.Sprintf("- %s (%s): %s\n", name, token.Symbol, token.Balance))
-			continue
+			return fmt.Sprintf("%s (%s)", name, token.Symbol)
 		}
-		builder.WriteString(fmt.Sprintf("- %s: %s\n", name, token.Balance))
+		return name
 	}
+}
 
-	return strings.TrimRight(builder.String(), "\n")
+func skippedTransactionsNote(resp *WalletResponse) string {
+	if resp.SkippedTransactions == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nNote: %d transaction(s) with malformed quantities were skipped; balances may be incomplete.", resp.SkippedTransactions)
+}
+
+func hiddenSpamNote(resp *WalletResponse) string {
+	if resp.HiddenSpam == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nNote: %d suspected spam token(s) hidden; set include_spam to show them.", resp.HiddenSpam)
+}
+
+func approximateNote(resp *WalletResponse) string {
+	for _, token := range resp.Tokens {
+		if token.Approximate {
+			return "\nNote: approximate balances are summed from transfers, which misses rebases and transfer fees; configure ETH_RPC_URL to read them on-chain."
+		}
+	}
+	return ""
+}
+
+func truncatedNote(resp *WalletResponse) string {
+	if !resp.Truncated {
+		return ""
+	}
+	return "\nWarning: the transfer history is longer than the server fetches; balances only reflect the earliest transfers."
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport/stdio"
+)
+
+func TestTokenLabelPolicies(t *testing.T) {
+	const contract = "0xc0ffee0000000000000000000000000000000000"
+
+	tests := []struct {
+		name   string
+		token  TokenBalance
+		policy string
+		want   string
+	}{
+		{"default name and symbol", TokenBalance{Address: contract, Name: "Tether USD", Symbol: "USDT"}, LabelDefault, "Tether USD (USDT)"},
+		{"default name only", TokenBalance{Address: contract, Name: "Tether USD"}, LabelDefault, "Tether USD"},
+		{"default symbol only", TokenBalance{Address: contract, Symbol: "USDT"}, LabelDefault, contract + " (USDT)"},
+		{"default neither", TokenBalance{Address: contract}, LabelDefault, contract},
+
+		{"contract name and symbol", TokenBalance{Address: contract, Name: "Tether USD", Symbol: "USDT"}, LabelContract, "Tether USD (USDT, " + contract + ")"},
+		{"contract name only", TokenBalance{Address: contract, Name: "Tether USD"}, LabelContract, "Tether USD (" + contract + ")"},
+		{"contract symbol only", TokenBalance{Address: contract, Symbol: "USDT"}, LabelContract, "USDT (" + contract + ")"},
+		{"contract neither", TokenBalance{Address: contract}, LabelContract, contract},
+
+		{"symbol name and symbol", TokenBalance{Address: contract, Name: "Tether USD", Symbol: "USDT"}, LabelSymbol, "USDT"},
+		{"symbol name only", TokenBalance{Address: contract, Name: "Tether USD"}, LabelSymbol, "Tether USD"},
+		{"symbol symbol only", TokenBalance{Address: contract, Symbol: "USDT"}, LabelSymbol, "USDT"},
+		{"symbol neither", TokenBalance{Address: contract}, LabelSymbol, contract},
+	}
+
+	for _, tt := range tests {
+		if got := tokenLabel(tt.token, tt.policy); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseLabelPolicy(t *testing.T) {
+	if got, err := parseLabelPolicy(""); err != nil || got != LabelDefault {
+		t.Fatalf("empty policy: got %q, %v", got, err)
+	}
+	if got, err := parseLabelPolicy("Symbol"); err != nil || got != LabelSymbol {
+		t.Fatalf("Symbol policy: got %q, %v", got, err)
+	}
+	if _, err := parseLabelPolicy("fancy"); err == nil {
+		t.Fatal("expected error for unknown policy")
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	if got, err := parseOutputFormat(""); err != nil || got != FormatText {
+		t.Fatalf("empty format: got %q, %v", got, err)
+	}
+	if got, err := parseOutputFormat(" JSON "); err != nil || got != FormatJSON {
+		t.Fatalf("JSON format: got %q, %v", got, err)
+	}
+	if _, err := parseOutputFormat("yaml"); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	logger, err := newLogger("warn", "json")
+	if err != nil {
+		t.Fatalf("newLogger returned error: %v", err)
+	}
+	if logger.Enabled(context.Background(), slog.LevelInfo) || !logger.Enabled(context.Background(), slog.LevelWarn) {
+		t.Fatalf("expected a warn-level logger")
+	}
+	if logger, err = newLogger("", ""); err != nil || !logger.Enabled(context.Background(), slog.LevelInfo) || logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatalf("expected an info-level default, got err %v", err)
+	}
+
+	if _, err := newLogger("loud", ""); err == nil {
+		t.Fatalf("expected an unknown level to fail")
+	}
+	if _, err := newLogger("", "xml"); err == nil {
+		t.Fatalf("expected an unknown format to fail")
+	}
+}
+
+func TestServerInputEOFSignalsShutdown(t *testing.T) {
+	pr, pw := io.Pipe()
+	input := newEOFNotifyReader(pr)
+
+	server := mcp_golang.NewServer(stdio.NewStdioServerTransportWithIO(input, io.Discard))
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve returned error: %v", err)
+	}
+
+	select {
+	case <-input.Done():
+		t.Fatal("Done closed before the input ended")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	pw.Close()
+	select {
+	case <-input.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Done not closed after the input reached EOF")
+	}
+}
+
+func TestFormatWalletResponseTrailingNewline(t *testing.T) {
+	full := &WalletResponse{
+		Address: testWalletA,
+		Tokens:  []TokenBalance{{Address: "0xc0ffee0000000000000000000000000000000000", Name: "Test", Symbol: "TST", Balance: "1"}},
+	}
+	empty := &WalletResponse{Address: testWalletB, Tokens: []TokenBalance{}}
+
+	for _, resp := range []*WalletResponse{full, empty} {
+		trimmed := formatWalletResponse(resp, formatOptions{Labels: LabelDefault})
+		if strings.HasSuffix(trimmed, "\n") {
+			t.Fatalf("expected trimmed output by default, got %q", trimmed)
+		}
+
+		kept := formatWalletResponse(resp, formatOptions{Labels: LabelDefault, TrailingNewline: true})
+		if kept != trimmed+"\n" {
+			t.Fatalf("expected exactly one trailing newline, got %q", kept)
+		}
+	}
+
+	combined := formatWalletResponse(full, formatOptions{TrailingNewline: true}) + formatWalletResponse(empty, formatOptions{TrailingNewline: true})
+	if !strings.Contains(combined, "- Test (TST): 1\nWallet Address: "+testWalletB) {
+		t.Fatalf("expected outputs to concatenate cleanly, got %q", combined)
+	}
+}
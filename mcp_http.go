@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+const (
+	// MCP transports, selected with MCP_TRANSPORT or -transport.
+	TransportStdio = "stdio"
+	TransportHTTP  = "http"
+
+	defaultMCPAddr = ":8081"
+	mcpHTTPPath    = "/mcp"
+	// maxMCPMessageBytes bounds one JSON-RPC message; tool arguments are small.
+	maxMCPMessageBytes = 1 << 20
+)
+
+var _ transport.Transport = (*mcpHTTPTransport)(nil)
+
+// mcpHTTPTransport serves MCP over plain HTTP for hosted deployments: each
+// POST carries one JSON-RPC message, and a request is answered in the HTTP
+// response. It keeps no session, so any number of clients can share one
+// server. Requests get server-side IDs, since every client numbers its own
+// from the start. mcp-golang's HTTP transport is not used because it leaves
+// notifications hanging and routes responses by the client's IDs.
+type mcpHTTPTransport struct {
+	mu           sync.Mutex
+	handler      func(ctx context.Context, message *transport.BaseJsonRpcMessage)
+	closeHandler func()
+	errorHandler func(error)
+	nextID       transport.RequestId
+	pending      map[transport.RequestId]chan *transport.BaseJsonRpcMessage
+}
+
+func newMCPHTTPTransport() *mcpHTTPTransport {
+	return &mcpHTTPTransport{pending: make(map[transport.RequestId]chan *transport.BaseJsonRpcMessage)}
+}
+
+// Start does nothing: the transport receives messages through ServeHTTP,
+// which the caller mounts on its own server.
+func (t *mcpHTTPTransport) Start(ctx context.Context) error {
+	return nil
+}
+
+// Send delivers a response to the HTTP request waiting for it. Messages the
+// server starts itself, such as progress notifications, have no request to
+// ride on and are dropped.
+func (t *mcpHTTPTransport) Send(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	var id transport.RequestId
+	switch message.Type {
+	case transport.BaseMessageTypeJSONRPCResponseType:
+		id = message.JsonRpcResponse.Id
+	case transport.BaseMessageTypeJSONRPCErrorType:
+		id = message.JsonRpcError.Id
+	case transport.BaseMessageTypeJSONRPCNotificationType:
+		return nil
+	default:
+		return errors.New("server-initiated requests are not supported over HTTP")
+	}
+
+	t.mu.Lock()
+	waiting, ok := t.pending[id]
+	delete(t.pending, id)
+	t.mu.Unlock()
+	if !ok {
+		// The client went away before the response was ready.
+		return nil
+	}
+	waiting <- message
+	return nil
+}
+
+func (t *mcpHTTPTransport) Close() error {
+	t.mu.Lock()
+	onClose := t.closeHandler
+	t.mu.Unlock()
+	if onClose != nil {
+		onClose()
+	}
+	return nil
+}
+
+func (t *mcpHTTPTransport) SetCloseHandler(handler func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closeHandler = handler
+}
+
+func (t *mcpHTTPTransport) SetErrorHandler(handler func(error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errorHandler = handler
+}
+
+func (t *mcpHTTPTransport) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handler = handler
+}
+
+func (t *mcpHTTPTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "MCP messages must be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMCPMessageBytes+1))
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxMCPMessageBytes {
+		http.Error(w, "Message too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	t.mu.Lock()
+	handler := t.handler
+	t.mu.Unlock()
+	if handler == nil {
+		http.Error(w, "MCP server not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	var request transport.BaseJSONRPCRequest
+	if err := json.Unmarshal(body, &request); err == nil {
+		t.serveRequest(w, r, handler, request)
+		return
+	}
+
+	// Anything else is one-way: notifications, and responses to requests
+	// the server never sends over HTTP.
+	var notification transport.BaseJSONRPCNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON-RPC message: %v", err), http.StatusBadRequest)
+		return
+	}
+	handler(r.Context(), transport.NewBaseMessageNotification(&notification))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// serveRequest passes request on under a server-side ID and writes the
+// response under the client's.
+func (t *mcpHTTPTransport) serveRequest(w http.ResponseWriter, r *http.Request, handler func(context.Context, *transport.BaseJsonRpcMessage), request transport.BaseJSONRPCRequest) {
+	clientID := request.Id
+	waiting := make(chan *transport.BaseJsonRpcMessage, 1)
+
+	t.mu.Lock()
+	t.nextID++
+	request.Id = t.nextID
+	t.pending[request.Id] = waiting
+	t.mu.Unlock()
+
+	handler(r.Context(), transport.NewBaseMessageRequest(&request))
+
+	var response *transport.BaseJsonRpcMessage
+	select {
+	case response = <-waiting:
+	case <-r.Context().Done():
+		t.mu.Lock()
+		delete(t.pending, request.Id)
+		t.mu.Unlock()
+		return
+	}
+
+	switch response.Type {
+	case transport.BaseMessageTypeJSONRPCResponseType:
+		response.JsonRpcResponse.Id = clientID
+	case transport.BaseMessageTypeJSONRPCErrorType:
+		response.JsonRpcError.Id = clientID
+	}
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		t.mu.Lock()
+		onError := t.errorHandler
+		t.mu.Unlock()
+		if onError != nil {
+			onError(fmt.Errorf("encoding MCP response: %w", err))
+		}
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(encoded)
+}
+
+// startMCPHTTPServer serves t at /mcp on addr in the background. Like
+// startServer, it binds the listener before returning.
+func startMCPHTTPServer(t *mcpHTTPTransport, addr string, logger *slog.Logger) (*http.Server, error) {
+	if addr == "" {
+		addr = defaultMCPAddr
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(mcpHTTPPath, t)
+	srv := &http.Server{
+		Addr:              listener.Addr().String(),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	logger.Info("MCP server listening", "addr", srv.Addr, "path", mcpHTTPPath)
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("MCP HTTP server error", "error", err)
+		}
+	}()
+	return srv, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+type echoRequest struct {
+	Text string `json:"text"`
+}
+
+func newMCPHTTPTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	transport := newMCPHTTPTransport()
+	server := mcp_golang.NewServer(transport)
+	err := server.RegisterTool("echo", "Echo the text back", func(req echoRequest) (*mcp_golang.ToolResponse, error) {
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(req.Text)), nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterTool returned error: %v", err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve returned error: %v", err)
+	}
+	srv := httptest.NewServer(transport)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func postMCP(t *testing.T, url, body string) (*http.Response, string) {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading response: %v", err)
+	}
+	return resp, string(data)
+}
+
+func TestMCPHTTPTransportAnswersRequests(t *testing.T) {
+	srv := newMCPHTTPTestServer(t)
+
+	resp, body := postMCP(t, srv.URL, `{"jsonrpc":"2.0","id":7,"method":"tools/list","params":{}}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	var listed struct {
+		ID     int `json:"id"`
+		Result struct {
+			Tools []struct {
+				Name string `json:"name"`
+			} `json:"tools"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(body), &listed); err != nil {
+		t.Fatalf("decoding response %s: %v", body, err)
+	}
+	if listed.ID != 7 || len(listed.Result.Tools) != 1 || listed.Result.Tools[0].Name != "echo" {
+		t.Fatalf("unexpected tools/list response: %s", body)
+	}
+
+	resp, body = postMCP(t, srv.URL, `{"jsonrpc":"2.0","id":8,"method":"no/such/method"}`)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `"id":8`) || !strings.Contains(body, `"error"`) {
+		t.Fatalf("expected a JSON-RPC error for id 8, got %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestMCPHTTPTransportSeparatesClients(t *testing.T) {
+	srv := newMCPHTTPTestServer(t)
+
+	// Every client numbers its requests from 1; the responses must still
+	// reach the right caller.
+	texts := []string{"alpha", "bravo", "charlie", "delta"}
+	bodies := make([]string, len(texts))
+	var wg sync.WaitGroup
+	for i, text := range texts {
+		wg.Add(1)
+		go func(i int, text string) {
+			defer wg.Done()
+			_, bodies[i] = postMCP(t, srv.URL, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"text":"`+text+`"}}}`)
+		}(i, text)
+	}
+	wg.Wait()
+
+	for i, text := range texts {
+		if !strings.Contains(bodies[i], `"id":1`) || !strings.Contains(bodies[i], text) {
+			t.Fatalf("client %d got %s, want its own echo of %q", i, bodies[i], text)
+		}
+	}
+}
+
+func TestMCPHTTPTransportNotificationsAndMethods(t *testing.T) {
+	srv := newMCPHTTPTestServer(t)
+
+	resp, _ := postMCP(t, srv.URL, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202 for a notification, got %d", resp.StatusCode)
+	}
+
+	resp, _ = postMCP(t, srv.URL, `not json`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid JSON, got %d", resp.StatusCode)
+	}
+
+	get, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	get.Body.Close()
+	if get.StatusCode != http.StatusMethodNotAllowed || get.Header.Get("Allow") != http.MethodPost {
+		t.Fatalf("expected 405 with Allow: POST, got %d %q", get.StatusCode, get.Header.Get("Allow"))
+	}
+}
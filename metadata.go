@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TokenMetadata is what describes an ERC-20 token besides its address.
+// HasDecimals tells a token with zero decimals apart from one whose decimals
+// are not known.
+type TokenMetadata struct {
+	Name        string
+	Symbol      string
+	Decimals    int
+	HasDecimals bool
+}
+
+// complete reports whether the metadata names the token and knows its
+// decimals.
+func (m TokenMetadata) complete() bool {
+	return (m.Name != "" || m.Symbol != "") && m.HasDecimals
+}
+
+// merge fills the fields m lacks from other; fields m already has win.
+func (m TokenMetadata) merge(other TokenMetadata) TokenMetadata {
+	m.Name = firstNonEmpty(m.Name, other.Name)
+	m.Symbol = firstNonEmpty(m.Symbol, other.Symbol)
+	if !m.HasDecimals && other.HasDecimals {
+		m.Decimals, m.HasDecimals = other.Decimals, true
+	}
+	return m
+}
+
+// displayName is the token's name, else its symbol, else its contract.
+func (m TokenMetadata) displayName(contract string) string {
+	return firstNonEmpty(m.Name, m.Symbol, contract)
+}
+
+// metadata is the token metadata carried by a transfer.
+func (t tokenTransaction) metadata() TokenMetadata {
+	meta := TokenMetadata{
+		Name:   firstNonEmpty(t.TokenName, t.TokenNameAlt),
+		Symbol: t.displaySymbol(),
+	}
+	if raw := firstNonEmpty(t.TokenDecimal, t.TokenDecimalAlt); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil {
+			meta.Decimals, meta.HasDecimals = parsed, true
+		}
+	}
+	return meta
+}
+
+// TokenMetadataResolver looks token metadata up from a source other than the
+// transfer history, such as the token contract itself.
+type TokenMetadataResolver interface {
+	ResolveTokenMetadata(ctx context.Context, chainID int64, contract string) (TokenMetadata, error)
+}
+
+// TokenMetadataCache remembers token metadata per chain and contract. The
+// first value seen for each field is kept, so a token is labelled the same
+// way across transfers and lookups even when Etherscan reports it
+// inconsistently. Metadata does not change, so entries never expire.
+type TokenMetadataCache struct {
+	resolver TokenMetadataResolver
+
+	mu      sync.Mutex
+	entries map[string]TokenMetadata
+	// resolved holds the tokens the resolver has answered for, so tokens it
+	// cannot complete either are not looked up again.
+	resolved map[string]bool
+}
+
+// NewTokenMetadataCache returns an empty cache. A nil resolver limits it to
+// the metadata found in transfers.
+func NewTokenMetadataCache(resolver TokenMetadataResolver) *TokenMetadataCache {
+	return &TokenMetadataCache{
+		resolver: resolver,
+		entries:  make(map[string]TokenMetadata),
+		resolved: make(map[string]bool),
+	}
+}
+
+func tokenMetadataKey(chainID int64, contract string) string {
+	return fmt.Sprintf("%d:%s", chainID, strings.ToLower(contract))
+}
+
+// Get returns the cached metadata of contract.
+func (c *TokenMetadataCache) Get(chainID int64, contract string) (TokenMetadata, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	meta, ok := c.entries[tokenMetadataKey(chainID, contract)]
+	return meta, ok
+}
+
+// Remember merges observed into the cached metadata of contract and returns
+// the result.
+func (c *TokenMetadataCache) Remember(chainID int64, contract string, observed TokenMetadata) TokenMetadata {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := tokenMetadataKey(chainID, contract)
+	meta := c.entries[key].merge(observed)
+	c.entries[key] = meta
+	return meta
+}
+
+// Resolve completes the cached metadata of contract with the resolver, if
+// there is one and the cache lacks a name or decimals. The resolver is asked
+// once per token; only failed lookups are retried on a later call.
+func (c *TokenMetadataCache) Resolve(ctx context.Context, chainID int64, contract string) (TokenMetadata, error) {
+	key := tokenMetadataKey(chainID, contract)
+	c.mu.Lock()
+	meta, done := c.entries[key], c.resolved[key]
+	c.mu.Unlock()
+	if meta.complete() || done || c.resolver == nil {
+		return meta, nil
+	}
+
+	resolved, err := c.resolver.ResolveTokenMetadata(ctx, chainID, contract)
+	if err != nil {
+		return meta, err
+	}
+	c.mu.Lock()
+	c.resolved[key] = true
+	c.mu.Unlock()
+	return c.Remember(chainID, contract, resolved), nil
+}
+
+// rpcDecimalsResolver reads decimals the transfer history lacks from the
+// token contract's decimals() over JSON-RPC. The endpoint serves the default
+// chain only; tokens on other chains are left as they are.
+type rpcDecimalsResolver struct {
+	rpc *rpcClient
+}
+
+func (r *rpcDecimalsResolver) ResolveTokenMetadata(ctx context.Context, chainID int64, contract string) (TokenMetadata, error) {
+	if chainID != defaultChain.ID {
+		return TokenMetadata{}, nil
+	}
+	decimals, err := r.rpc.decimals(ctx, contract)
+	if err != nil {
+		return TokenMetadata{}, err
+	}
+	return TokenMetadata{Decimals: decimals, HasDecimals: true}, nil
+}
+
+// decimals reads contract.decimals(). Contracts without the method answer an
+// empty result, which is an error rather than zero decimals.
+func (c *rpcClient) decimals(ctx context.Context, contract string) (int, error) {
+	call := map[string]string{
+		"to":   contract,
+		"data": "0x" + erc20DecimalsSelector,
+	}
+
+	var result string
+	if err := c.call(ctx, "eth_call", []any{call, "latest"}, &result); err != nil {
+		return 0, err
+	}
+	if result == "" || result == "0x" {
+		return 0, fmt.Errorf("%s has no decimals()", contract)
+	}
+	value, err := parseHexBig(result)
+	if err != nil {
+		return 0, err
+	}
+	// decimals() returns a uint8.
+	if !value.IsInt64() || value.Int64() > 255 {
+		return 0, fmt.Errorf("decimals() of %s out of range: %s", contract, value)
+	}
+	return int(value.Int64()), nil
+}
+
+// resolveIncompleteMetadata fills the cache for tokens whose transfers do not
+// fully describe them, before the transfers are summarized. Failures are
+// logged and leave the token as the transfers describe it.
+func (t *WalletTracker) resolveIncompleteMetadata(ctx context.Context, chainID int64, txs []tokenTransaction) {
+	if t.tokenMetadata.resolver == nil {
+		return
+	}
+
+	seen := make(map[string]bool)
+	var contracts []string
+	for _, tx := range txs {
+		if isNativePseudoContract(tx.ContractAddress) {
+			continue
+		}
+		t.tokenMetadata.Remember(chainID, tx.ContractAddress, tx.metadata())
+		if key := strings.ToLower(tx.ContractAddress); !seen[key] {
+			seen[key] = true
+			contracts = append(contracts, tx.ContractAddress)
+		}
+	}
+
+	for _, contract := range contracts {
+		if _, err := t.tokenMetadata.Resolve(ctx, chainID, contract); err != nil {
+			t.logger.Warn("Resolving token metadata failed", "contract", contract, "error", err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type fakeMetadataResolver struct {
+	calls atomic.Int32
+	meta  map[string]TokenMetadata
+}
+
+func (f *fakeMetadataResolver) ResolveTokenMetadata(ctx context.Context, chainID int64, contract string) (TokenMetadata, error) {
+	f.calls.Add(1)
+	meta, ok := f.meta[contract]
+	if !ok {
+		return TokenMetadata{}, errors.New("unknown token")
+	}
+	return meta, nil
+}
+
+func TestTokenMetadataCacheKeepsFirstValues(t *testing.T) {
+	cache := NewTokenMetadataCache(nil)
+	contract := "0xC0FFEE0000000000000000000000000000000000"
+
+	cache.Remember(1, contract, TokenMetadata{Symbol: "TST"})
+	got := cache.Remember(1, "0xc0ffee0000000000000000000000000000000000", TokenMetadata{Name: "Test", Symbol: "OTHER", Decimals: 6, HasDecimals: true})
+	if got != (TokenMetadata{Name: "Test", Symbol: "TST", Decimals: 6, HasDecimals: true}) {
+		t.Fatalf("expected missing fields filled and known ones kept, got %+v", got)
+	}
+	if _, ok := cache.Get(137, contract); ok {
+		t.Fatal("expected entries to be per chain")
+	}
+	if meta, err := cache.Resolve(context.Background(), 1, contract); err != nil || meta != got {
+		t.Fatalf("expected the cached entry without a resolver, got %+v, %v", meta, err)
+	}
+}
+
+func TestSummarizeTokenBalancesUsesMetadataCache(t *testing.T) {
+	wallet := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	other := "0x1111111111111111111111111111111111111111"
+	contract := "0xc0ffee0000000000000000000000000000000000"
+
+	cache := NewTokenMetadataCache(nil)
+	opts := summaryOptions{metadata: cache, chainID: 1}
+
+	// The first transfer lacks decimals, which a later one supplies.
+	first := []tokenTransaction{
+		{ContractAddress: contract, TokenName: "Test", TokenSymbol: "TST", TokenQuantity: "1500", From: other, To: wallet},
+		{ContractAddress: contract, TokenName: "Test v2", TokenSymbol: "TST2", TokenDecimal: "3", TokenQuantity: "500", From: other, To: wallet},
+	}
+	tokens, _ := summarizeTokenBalances(wallet, first, opts)
+	if len(tokens) != 1 || tokens[0].Name != "Test" || tokens[0].Symbol != "TST" || tokens[0].Balance != "2" {
+		t.Fatalf("expected the first name and the known decimals, got %+v", tokens)
+	}
+
+	// A later lookup labels the token the same way, whatever it reports now.
+	later := []tokenTransaction{
+		{ContractAddress: contract, TokenName: "Renamed", TokenSymbol: "RNM", TokenDecimal: "0", TokenQuantity: "1000", From: other, To: wallet},
+	}
+	tokens, _ = summarizeTokenBalances(wallet, later, opts)
+	if len(tokens) != 1 || tokens[0].Name != "Test" || tokens[0].Decimals != 3 || tokens[0].Balance != "1" {
+		t.Fatalf("expected the cached metadata to be reused, got %+v", tokens)
+	}
+}
+
+func TestTokenMetadataResolverEnrichesIncompleteTokens(t *testing.T) {
+	contract := "0xc0ffee0000000000000000000000000000000000"
+	other := "0x3333333333333333333333333333333333333333"
+
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+			{"blockNumber":"1","contractAddress":"%[1]s","value":"2500000","from":"%[2]s","to":"%[3]s"}
+		]}`, contract, other, testWalletA)
+	}))
+	resolver := &fakeMetadataResolver{meta: map[string]TokenMetadata{
+		contract: {Name: "Old Token", Symbol: "OLD", Decimals: 6, HasDecimals: true},
+	}}
+	tracker.tokenMetadata = NewTokenMetadataCache(resolver)
+
+	for i := 0; i < 2; i++ {
+		resp, err := tracker.GetWalletTokens(context.Background(), testWalletA)
+		if err != nil {
+			t.Fatalf("GetWalletTokens returned error: %v", err)
+		}
+		if len(resp.Tokens) != 1 || resp.Tokens[0].Symbol != "OLD" || resp.Tokens[0].Balance != "2.5" {
+			t.Fatalf("expected resolved metadata, got %+v", resp.Tokens)
+		}
+	}
+	if n := resolver.calls.Load(); n != 1 {
+		t.Fatalf("expected one resolver call, got %d", n)
+	}
+}
+
+func TestRPCDecimalsResolver(t *testing.T) {
+	contract := "0xc0ffee0000000000000000000000000000000000"
+
+	var calls atomic.Int32
+	tracker := newRPCTestTracker(t, func(method string, params []json.RawMessage) (string, *rpcError) {
+		calls.Add(1)
+		if method != "eth_call" || !strings.Contains(string(params[0]), "0x313ce567") {
+			t.Errorf("unexpected call %s %s", method, params)
+		}
+		if strings.Contains(string(params[0]), contract) {
+			return `"0x0000000000000000000000000000000000000000000000000000000000000012"`, nil
+		}
+		return `"0x"`, nil
+	})
+
+	cache := tracker.tokenMetadata
+	cache.Remember(defaultChain.ID, contract, TokenMetadata{Symbol: "TST"})
+	for i := 0; i < 2; i++ {
+		meta, err := cache.Resolve(context.Background(), defaultChain.ID, contract)
+		if err != nil || !meta.HasDecimals || meta.Decimals != 18 || meta.Symbol != "TST" {
+			t.Fatalf("expected on-chain decimals, got %+v, %v", meta, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected the still-nameless token to be looked up once, got %d calls", n)
+	}
+
+	if _, err := cache.Resolve(context.Background(), defaultChain.ID, "0x4444444444444444444444444444444444444444"); err == nil {
+		t.Fatal("expected an error for a contract without decimals()")
+	}
+	if meta, err := cache.Resolve(context.Background(), 137, contract); err != nil || meta.HasDecimals {
+		t.Fatalf("expected other chains to be left alone, got %+v, %v", meta, err)
+	}
+}
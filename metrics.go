@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcomes of a wallet lookup, as recorded by MeteredWalletService.
+const (
+	OutcomeSuccess        = "success"
+	OutcomeInvalidAddress = "invalid_address"
+	OutcomeNoTransactions = "no_transactions"
+	OutcomeUpstreamError  = "upstream_error"
+	OutcomeCancelled      = "cancelled"
+)
+
+// Reasons an Etherscan attempt failed, as recorded by queryEtherscan. Only
+// rate-limited attempts are retried.
+const (
+	RetryRateLimited         = "rate_limited"
+	RetryUpstreamUnavailable = "upstream_unavailable"
+	RetryOther               = "other"
+)
+
+// RetrySink receives one observation per failed Etherscan attempt.
+// Implementations must be safe for concurrent use.
+type RetrySink interface {
+	ObserveRetry(reason string)
+}
+
+// MetricsSink receives one observation per wallet lookup. Implementations must
+// be safe for concurrent use.
+type MetricsSink interface {
+	ObserveWalletLookup(outcome string, latency time.Duration)
+}
+
+var _ WalletService = (*MeteredWalletService)(nil)
+
+// MeteredWalletService is a WalletService decorator that records the latency
+// and outcome of every GetWalletTokens call in a MetricsSink. Results and
+// errors are passed through unchanged, so errors.Is keeps working on them.
+type MeteredWalletService struct {
+	next WalletService
+	sink MetricsSink
+	now  func() time.Time
+}
+
+// NewMeteredWalletService wraps next, recording its lookups in sink.
+func NewMeteredWalletService(next WalletService, sink MetricsSink) *MeteredWalletService {
+	return &MeteredWalletService{next: next, sink: sink, now: time.Now}
+}
+
+func (m *MeteredWalletService) GetWalletTokens(ctx context.Context, walletAddress string, opts ...QueryOption) (*WalletResponse, error) {
+	start := m.now()
+	resp, err := m.next.GetWalletTokens(ctx, walletAddress, opts...)
+	m.sink.ObserveWalletLookup(lookupOutcome(err), m.now().Sub(start))
+	return resp, err
+}
+
+// lookupOutcome classifies a GetWalletTokens error. Unresolvable ENS names
+// count as invalid addresses, since retrying cannot help either.
+func lookupOutcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeSuccess
+	case errors.Is(err, ErrInvalidWalletAddress), errors.Is(err, ErrENSNameNotFound):
+		return OutcomeInvalidAddress
+	case errors.Is(err, ErrNoTransactions):
+		return OutcomeNoTransactions
+	case isContextError(err):
+		return OutcomeCancelled
+	default:
+		return OutcomeUpstreamError
+	}
+}
+
+// retryReason classifies an error from a failed Etherscan attempt.
+func retryReason(err error) string {
+	switch {
+	case errors.Is(err, ErrRateLimited):
+		return RetryRateLimited
+	case errors.Is(err, ErrUpstreamUnavailable):
+		return RetryUpstreamUnavailable
+	default:
+		return RetryOther
+	}
+}
+
+// InMemoryMetrics is a MetricsSink keeping a count and total latency per
+// outcome, e.g. for tests.
+type InMemoryMetrics struct {
+	mu      sync.Mutex
+	counts  map[string]int
+	latency map[string]time.Duration
+}
+
+// NewInMemoryMetrics returns an empty InMemoryMetrics.
+func NewInMemoryMetrics() *InMemoryMetrics {
+	return &InMemoryMetrics{counts: make(map[string]int), latency: make(map[string]time.Duration)}
+}
+
+func (m *InMemoryMetrics) ObserveWalletLookup(outcome string, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[outcome]++
+	m.latency[outcome] += latency
+}
+
+// Count reports how many lookups ended with outcome.
+func (m *InMemoryMetrics) Count(outcome string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[outcome]
+}
+
+// Latency reports the total time spent in lookups that ended with outcome.
+func (m *InMemoryMetrics) Latency(outcome string) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.latency[outcome]
+}
+
+// lookupLatencyBuckets are the upper bounds, in seconds, of the lookup latency
+// histogram: Prometheus' defaults, which suit calls to a remote API.
+var lookupLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type latencyHistogram struct {
+	buckets []uint64 // cumulative counts per lookupLatencyBuckets bound
+	count   uint64
+	sum     float64
+}
+
+// PrometheusMetrics is a MetricsSink that serves its observations in the
+// Prometheus text exposition format, as the histogram
+// wallet_lookup_duration_seconds labelled by outcome; its _count series
+// counts lookups per outcome. As a RetrySink it also counts failed Etherscan
+// attempts in etherscan_failed_attempts_total, labelled by reason. Mount it on
+// a /metrics route to scrape it.
+type PrometheusMetrics struct {
+	mu         sync.Mutex
+	histograms map[string]*latencyHistogram
+	retries    map[string]uint64
+}
+
+// NewPrometheusMetrics returns an empty PrometheusMetrics.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{histograms: make(map[string]*latencyHistogram), retries: make(map[string]uint64)}
+}
+
+func (p *PrometheusMetrics) ObserveRetry(reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.retries[reason]++
+}
+
+func (p *PrometheusMetrics) ObserveWalletLookup(outcome string, latency time.Duration) {
+	seconds := latency.Seconds()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h, ok := p.histograms[outcome]
+	if !ok {
+		h = &latencyHistogram{buckets: make([]uint64, len(lookupLatencyBuckets))}
+		p.histograms[outcome] = h
+	}
+	for i, bound := range lookupLatencyBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf strings.Builder
+	buf.WriteString("# HELP wallet_lookup_duration_seconds Latency of wallet token lookups by outcome.\n")
+	buf.WriteString("# TYPE wallet_lookup_duration_seconds histogram\n")
+
+	p.mu.Lock()
+	outcomes := make([]string, 0, len(p.histograms))
+	for outcome := range p.histograms {
+		outcomes = append(outcomes, outcome)
+	}
+	sort.Strings(outcomes)
+	for _, outcome := range outcomes {
+		h := p.histograms[outcome]
+		for i, bound := range lookupLatencyBuckets {
+			fmt.Fprintf(&buf, "wallet_lookup_duration_seconds_bucket{outcome=%q,le=%q} %d\n", outcome, strconv.FormatFloat(bound, 'g', -1, 64), h.buckets[i])
+		}
+		fmt.Fprintf(&buf, "wallet_lookup_duration_seconds_bucket{outcome=%q,le=\"+Inf\"} %d\n", outcome, h.count)
+		fmt.Fprintf(&buf, "wallet_lookup_duration_seconds_sum{outcome=%q} %s\n", outcome, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&buf, "wallet_lookup_duration_seconds_count{outcome=%q} %d\n", outcome, h.count)
+	}
+
+	// Every reason is listed, even at zero, so rate() works from the first
+	// failure on.
+	buf.WriteString("# HELP etherscan_failed_attempts_total Failed Etherscan attempts by reason; rate_limited ones are retried.\n")
+	buf.WriteString("# TYPE etherscan_failed_attempts_total counter\n")
+	for _, reason := range []string{RetryOther, RetryRateLimited, RetryUpstreamUnavailable} {
+		fmt.Fprintf(&buf, "etherscan_failed_attempts_total{reason=%q} %d\n", reason, p.retries[reason])
+	}
+	p.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(buf.String()))
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// erroringWalletService fails every lookup with err, or succeeds when err is
+// nil.
+type erroringWalletService struct {
+	err error
+}
+
+func (s erroringWalletService) GetWalletTokens(ctx context.Context, walletAddress string, opts ...QueryOption) (*WalletResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &WalletResponse{Address: walletAddress, Tokens: []TokenBalance{}}, nil
+}
+
+func TestMeteredWalletServiceOutcomes(t *testing.T) {
+	upstream := fmt.Errorf("fetching token transactions: %w", ErrUpstreamUnavailable)
+	tests := []struct {
+		err     error
+		outcome string
+	}{
+		{nil, OutcomeSuccess},
+		{fmt.Errorf("%w: %q", ErrInvalidChecksum, testWalletA), OutcomeInvalidAddress},
+		{ErrENSNameNotFound, OutcomeInvalidAddress},
+		{ErrNoTransactions, OutcomeNoTransactions},
+		{upstream, OutcomeUpstreamError},
+		{context.DeadlineExceeded, OutcomeCancelled},
+	}
+
+	for _, tt := range tests {
+		metrics := NewInMemoryMetrics()
+		service := NewMeteredWalletService(erroringWalletService{err: tt.err}, metrics)
+		now := time.Now()
+		service.now = func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		}
+
+		_, err := service.GetWalletTokens(context.Background(), testWalletA)
+		if err != tt.err {
+			t.Fatalf("expected the error to pass through unchanged, got %v", err)
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Fatalf("expected errors.Is to match %v", tt.err)
+		}
+		if metrics.Count(tt.outcome) != 1 || metrics.Latency(tt.outcome) != time.Second {
+			t.Fatalf("%v: expected one %s lookup taking 1s, got %d taking %s", tt.err, tt.outcome, metrics.Count(tt.outcome), metrics.Latency(tt.outcome))
+		}
+	}
+}
+
+func TestPrometheusMetricsEndpoint(t *testing.T) {
+	metrics := NewPrometheusMetrics()
+	metrics.ObserveWalletLookup(OutcomeSuccess, 30*time.Millisecond)
+	metrics.ObserveWalletLookup(OutcomeSuccess, 2*time.Second)
+	metrics.ObserveWalletLookup(OutcomeUpstreamError, time.Millisecond)
+
+	tracker := newTestTracker(t, withNativeBalance("0", nil))
+	WithMetricsHandler(metrics)(tracker)
+	rec := httptest.NewRecorder()
+	setupRoutes(tracker, tracker).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected response %d %v", rec.Code, rec.Header())
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE wallet_lookup_duration_seconds histogram\n",
+		`wallet_lookup_duration_seconds_bucket{outcome="success",le="0.025"} 0` + "\n",
+		`wallet_lookup_duration_seconds_bucket{outcome="success",le="0.05"} 1` + "\n",
+		`wallet_lookup_duration_seconds_bucket{outcome="success",le="+Inf"} 2` + "\n",
+		`wallet_lookup_duration_seconds_sum{outcome="success"} 2.03` + "\n",
+		`wallet_lookup_duration_seconds_count{outcome="upstream_error"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in\n%s", want, body)
+		}
+	}
+}
+
+func TestPrometheusMetricsCountsFailedEtherscanAttempts(t *testing.T) {
+	var calls atomic.Int32
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		case 2:
+			fmt.Fprint(w, `{"status":"0","message":"NOTOK","result":"Max calls per sec rate limit reached (5/sec)"}`)
+		case 3:
+			http.Error(w, "Bad Gateway", http.StatusBadGateway)
+		default:
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		}
+	})
+	metrics := NewPrometheusMetrics()
+	WithEtherscanRetries(3, time.Millisecond)(tracker)
+	WithRetryMetrics(metrics)(tracker)
+	WithMetricsHandler(metrics)(tracker)
+
+	// Two rate-limited attempts are retried; the 502 is not.
+	if _, _, err := tracker.fetchTokenTransactions(context.Background(), defaultChain.ID, testWalletA, 0, 0); !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Fatalf("expected ErrUpstreamUnavailable, got %v", err)
+	}
+	if _, _, err := tracker.fetchTokenTransactions(context.Background(), defaultChain.ID, testWalletA, 0, 0); err == nil {
+		t.Fatal("expected the 403 to fail")
+	}
+	if n := calls.Load(); n != 4 {
+		t.Fatalf("expected 4 attempts, got %d", n)
+	}
+
+	rec := httptest.NewRecorder()
+	setupRoutes(tracker, tracker).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE etherscan_failed_attempts_total counter\n",
+		`etherscan_failed_attempts_total{reason="rate_limited"} 2` + "\n",
+		`etherscan_failed_attempts_total{reason="upstream_unavailable"} 1` + "\n",
+		`etherscan_failed_attempts_total{reason="other"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var _ WalletService = (*MockWalletTracker)(nil)
+
+// MockWalletTracker is a WalletService that answers from fixed responses
+// instead of the network, so the server can run without an Etherscan key.
+type MockWalletTracker struct {
+	fixtures map[string]*WalletResponse
+}
+
+// NewMockWalletTracker returns a WalletService serving fixtures, keyed by
+// wallet address or ENS name in any case. Wallets without a fixture have no
+// tokens.
+func NewMockWalletTracker(fixtures map[string]*WalletResponse) *MockWalletTracker {
+	m := &MockWalletTracker{fixtures: make(map[string]*WalletResponse, len(fixtures))}
+	for key, resp := range fixtures {
+		if resp != nil {
+			m.fixtures[strings.ToLower(strings.TrimSpace(key))] = resp.clone()
+		}
+	}
+	return m
+}
+
+// GetWalletTokens returns a copy of the wallet's fixture. Only the sort
+// options apply; the chain, block and filtering options are ignored, so a
+// fixture is returned as written.
+func (m *MockWalletTracker) GetWalletTokens(ctx context.Context, walletAddress string, opts ...QueryOption) (*WalletResponse, error) {
+	var q queryOptions
+	for _, opt := range opts {
+		opt(&q)
+	}
+
+	walletAddress = strings.TrimSpace(walletAddress)
+	if !isENSName(walletAddress) {
+		if err := ValidateAddress(walletAddress); err != nil {
+			return nil, fmt.Errorf("%w: %q", err, walletAddress)
+		}
+	}
+
+	fixture, ok := m.fixtures[strings.ToLower(walletAddress)]
+	if !ok {
+		return &WalletResponse{Address: walletAddress, Tokens: []TokenBalance{}}, nil
+	}
+	resp := fixture.clone()
+	if resp.Address == "" {
+		resp.Address = walletAddress
+	}
+	if resp.Tokens == nil {
+		resp.Tokens = []TokenBalance{}
+	}
+	return q.sorted(resp), nil
+}
+
+// LoadWalletFixtures reads NewMockWalletTracker fixtures from a JSON file
+// mapping each wallet to a response in the wallet_tracker JSON format.
+func LoadWalletFixtures(path string) (map[string]*WalletResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures map[string]*WalletResponse
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("parsing wallet fixtures %s: %w", path, err)
+	}
+	return fixtures, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMockWalletTracker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	err := os.WriteFile(path, []byte(`{
+		"`+testWalletA+`": {"address": "`+testWalletA+`", "tokens": [
+			{"address": "0xc0ffee0000000000000000000000000000000000", "name": "Alpha", "symbol": "ALP", "balance": "1", "raw_balance": "1"},
+			{"address": "0xbeef000000000000000000000000000000000000", "name": "Beta", "symbol": "BET", "balance": "5", "raw_balance": "5"}
+		]}
+	}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures, err := LoadWalletFixtures(path)
+	if err != nil {
+		t.Fatalf("LoadWalletFixtures returned error: %v", err)
+	}
+	mock := NewMockWalletTracker(fixtures)
+	ctx := context.Background()
+
+	resp, err := mock.GetWalletTokens(ctx, "0x"+testWalletA[2:], SortTokens(SortByBalance, true))
+	if err != nil {
+		t.Fatalf("GetWalletTokens returned error: %v", err)
+	}
+	if len(resp.Tokens) != 2 || resp.Tokens[0].Symbol != "BET" {
+		t.Fatalf("expected the sorted fixture, got %+v", resp.Tokens)
+	}
+	resp.Tokens[0].Balance = "changed"
+
+	again, _ := mock.GetWalletTokens(ctx, testWalletA)
+	if again.Tokens[0].Symbol != "ALP" || again.Tokens[1].Balance != "5" {
+		t.Fatalf("expected callers not to share the fixture, got %+v", again.Tokens)
+	}
+
+	empty, err := mock.GetWalletTokens(ctx, testWalletB)
+	if err != nil || empty.Address != testWalletB || empty.Tokens == nil || len(empty.Tokens) != 0 {
+		t.Fatalf("expected an empty response for a wallet without a fixture, got %+v, %v", empty, err)
+	}
+	if _, err := mock.GetWalletTokens(ctx, "0x123"); !errors.Is(err, ErrInvalidWalletAddress) {
+		t.Fatalf("expected ErrInvalidWalletAddress, got %v", err)
+	}
+}
+
+func TestHTTPAPIUsesWalletService(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected etherscan request %s", r.URL.RawQuery)
+	})
+	wallets := NewMockWalletTracker(map[string]*WalletResponse{
+		testWalletA: {Address: testWalletA, Tokens: []TokenBalance{{Address: "0xc0ffee0000000000000000000000000000000000", Name: "Test", Symbol: "TST", Balance: "3"}}},
+	})
+	router := setupRoutes(tracker, wallets)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wallet/"+testWalletA, nil))
+	var resp WalletResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected the fixture, got status %d: %v", rec.Code, err)
+	}
+	if len(resp.Tokens) != 1 || resp.Tokens[0].Balance != "3" {
+		t.Fatalf("unexpected tokens %+v", resp.Tokens)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wallet/"+testWalletA+"?chains=1,137", nil))
+	var multi MultiChainResponse
+	if err := json.NewDecoder(rec.Body).Decode(&multi); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected a multi-chain response, got status %d: %v", rec.Code, err)
+	}
+	if multi.Address != testWalletA || len(multi.Chains) != 2 || len(multi.Chains[1].Tokens) != 1 {
+		t.Fatalf("unexpected multi-chain response %+v", multi)
+	}
+}
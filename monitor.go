@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMonitorInterval     = time.Minute
+	defaultWebhookTimeout      = 10 * time.Second
+	defaultWebhookAttempts     = 5
+	defaultWebhookRetryBackoff = time.Second
+)
+
+// WebhookEvent is the body the monitor POSTs to the webhook. ID identifies
+// the delivery: retries of the same delivery carry the same ID, so receivers
+// can drop duplicates.
+type WebhookEvent struct {
+	ID         string      `json:"id"`
+	Event      string      `json:"event"`
+	ObservedAt time.Time   `json:"observed_at"`
+	Diff       *WalletDiff `json:"diff"`
+}
+
+// webhookEventBalancesChanged is the Event of a WebhookEvent.
+const webhookEventBalancesChanged = "wallet.balances_changed"
+
+// MonitorOption configures optional Monitor behaviour at construction time.
+type MonitorOption func(*Monitor)
+
+// WithPollInterval sets how often the monitor polls each wallet. Values below
+// one are ignored. Defaults to a minute.
+func WithPollInterval(d time.Duration) MonitorOption {
+	return func(m *Monitor) {
+		if d > 0 {
+			m.interval = d
+		}
+	}
+}
+
+// WithWebhookRetries sets how many times a delivery is attempted before the
+// monitor gives up until the next poll, and the backoff before the second
+// attempt, which doubles for every attempt after it. Values below one are
+// ignored. Defaults to 5 attempts starting at a second.
+func WithWebhookRetries(attempts int, backoff time.Duration) MonitorOption {
+	return func(m *Monitor) {
+		if attempts > 0 {
+			m.attempts = attempts
+		}
+		if backoff > 0 {
+			m.backoff = backoff
+		}
+	}
+}
+
+// WithWebhookClient sets the HTTP client used to deliver webhooks.
+func WithWebhookClient(client *http.Client) MonitorOption {
+	return func(m *Monitor) {
+		if client != nil {
+			m.client = client
+		}
+	}
+}
+
+// WithMonitorLogger sets the logger for polling and delivery failures.
+func WithMonitorLogger(logger *slog.Logger) MonitorOption {
+	return func(m *Monitor) {
+		if logger != nil {
+			m.logger = logger
+		}
+	}
+}
+
+// Monitor polls watched wallets and POSTs a WebhookEvent to a webhook
+// whenever their balances change between polls.
+//
+// Delivery is at least once: a wallet's snapshot only moves forward once the
+// webhook has accepted the diff with a 2xx status. When every attempt fails,
+// the next poll diffs against the same snapshot again, so the change is sent
+// again, together with anything that changed since.
+type Monitor struct {
+	wallets    WalletService
+	webhookURL string
+	interval   time.Duration
+	attempts   int
+	backoff    time.Duration
+	client     *http.Client
+	logger     *slog.Logger
+
+	mu      sync.Mutex
+	watched map[string]*monitoredWallet
+}
+
+type monitoredWallet struct {
+	address  string
+	opts     []QueryOption
+	snapshot *WalletResponse
+}
+
+// NewMonitor returns a monitor that looks wallets up with wallets and reports
+// their changes to webhookURL, an absolute http(s) URL.
+func NewMonitor(wallets WalletService, webhookURL string, opts ...MonitorOption) (*Monitor, error) {
+	if err := validateWebhookURL(webhookURL); err != nil {
+		return nil, fmt.Errorf("webhook URL: %w", err)
+	}
+	m := &Monitor{
+		wallets:    wallets,
+		webhookURL: webhookURL,
+		interval:   defaultMonitorInterval,
+		attempts:   defaultWebhookAttempts,
+		backoff:    defaultWebhookRetryBackoff,
+		client:     &http.Client{Timeout: defaultWebhookTimeout},
+		logger:     slog.Default(),
+		watched:    make(map[string]*monitoredWallet),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m, nil
+}
+
+// validateWebhookURL accepts absolute http(s) URLs.
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute http(s) URL", raw)
+	}
+	return nil
+}
+
+// Watch starts monitoring wallet, a 0x address or an ENS name, queried with
+// opts. Its first poll only records a snapshot; later polls report changes
+// against it. Watching a wallet again replaces its options and snapshot.
+func (m *Monitor) Watch(wallet string, opts ...QueryOption) error {
+	wallet = strings.TrimSpace(wallet)
+	if isENSName(wallet) {
+		wallet = normalizeENSName(wallet)
+	} else if err := ValidateAddress(wallet); err != nil {
+		return fmt.Errorf("%w: %q", err, wallet)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.watched[strings.ToLower(wallet)] = &monitoredWallet{address: wallet, opts: opts}
+	return nil
+}
+
+// Unwatch stops monitoring wallet.
+func (m *Monitor) Unwatch(wallet string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.watched, strings.ToLower(strings.TrimSpace(wallet)))
+}
+
+// Run polls every watched wallet right away and then once per interval until
+// ctx is cancelled, which it returns.
+func (m *Monitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll checks each watched wallet once, in turn.
+func (m *Monitor) poll(ctx context.Context) {
+	m.mu.Lock()
+	watched := make([]*monitoredWallet, 0, len(m.watched))
+	for _, w := range m.watched {
+		watched = append(watched, w)
+	}
+	m.mu.Unlock()
+
+	for _, w := range watched {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := m.check(ctx, w); err != nil {
+			m.logger.Warn("monitor poll failed", "wallet", w.address, "error", err)
+		}
+	}
+}
+
+// check looks w up, and delivers its diff against the last snapshot when
+// anything changed. The snapshot is only replaced once the diff is delivered.
+func (m *Monitor) check(ctx context.Context, w *monitoredWallet) error {
+	current, err := m.wallets.GetWalletTokens(ctx, w.address, w.opts...)
+	if err != nil {
+		return err
+	}
+	if w.snapshot == nil {
+		w.snapshot = current
+		return nil
+	}
+
+	diff := DiffWallets(w.snapshot, current)
+	if diff.Empty() {
+		return nil
+	}
+	observedAt := time.Now().UTC()
+	event := WebhookEvent{
+		ID:         fmt.Sprintf("%s-%d", strings.ToLower(current.Address), observedAt.UnixNano()),
+		Event:      webhookEventBalancesChanged,
+		ObservedAt: observedAt,
+		Diff:       &diff,
+	}
+	if err := m.deliver(ctx, event); err != nil {
+		return fmt.Errorf("delivering webhook %s: %w", event.ID, err)
+	}
+	w.snapshot = current
+	return nil
+}
+
+// deliver POSTs event to the webhook, retrying with backoff until it answers
+// with a 2xx status, the attempts run out or ctx is cancelled.
+func (m *Monitor) deliver(ctx context.Context, event WebhookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding webhook event: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= m.attempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(retryDelay(m.backoff, attempt-1)):
+			}
+		}
+		if lastErr = m.post(ctx, event.ID, body); lastErr == nil {
+			return nil
+		}
+		m.logger.Debug("webhook delivery failed", "id", event.ID, "attempt", attempt, "error", lastErr)
+	}
+	return fmt.Errorf("giving up after %d attempt(s): %w", m.attempts, lastErr)
+}
+
+func (m *Monitor) post(ctx context.Context, id string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", id)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type webhookRecorder struct {
+	mu       sync.Mutex
+	failures int
+	events   []WebhookEvent
+}
+
+func (r *webhookRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var event WebhookEvent
+	if err := json.NewDecoder(req.Body).Decode(&event); err != nil || req.Header.Get("X-Webhook-ID") != event.ID {
+		http.Error(w, "bad event", http.StatusBadRequest)
+		return
+	}
+	r.events = append(r.events, event)
+	if r.failures > 0 {
+		r.failures--
+		http.Error(w, "try later", http.StatusServiceUnavailable)
+	}
+}
+
+func (r *webhookRecorder) received() []WebhookEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]WebhookEvent(nil), r.events...)
+}
+
+func monitorFixture(balance string) *MockWalletTracker {
+	return NewMockWalletTracker(map[string]*WalletResponse{
+		testWalletA: {Address: testWalletA, Tokens: []TokenBalance{{Address: "0xc0ffee0000000000000000000000000000000000", Name: "Test", Symbol: "TST", Balance: balance}}},
+	})
+}
+
+func TestMonitorDeliversChangesWithRetries(t *testing.T) {
+	recorder := &webhookRecorder{failures: 2}
+	srv := httptest.NewServer(recorder)
+	t.Cleanup(srv.Close)
+
+	monitor, err := NewMonitor(monitorFixture("1"), srv.URL, WithWebhookRetries(3, time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewMonitor returned error: %v", err)
+	}
+	if err := monitor.Watch(testWalletA); err != nil {
+		t.Fatalf("Watch returned error: %v", err)
+	}
+
+	ctx := context.Background()
+	monitor.poll(ctx)
+	if events := recorder.received(); len(events) != 0 {
+		t.Fatalf("expected the first poll to only take a snapshot, got %+v", events)
+	}
+
+	monitor.wallets = monitorFixture("4")
+	monitor.poll(ctx)
+	events := recorder.received()
+	if len(events) != 3 {
+		t.Fatalf("expected two failed attempts and a delivery, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.ID != events[0].ID {
+			t.Fatalf("expected retries to share the delivery ID, got %q and %q", events[0].ID, event.ID)
+		}
+	}
+	diff := events[2].Diff
+	if events[2].Event != webhookEventBalancesChanged || len(diff.Changed) != 1 || diff.Changed[0].Previous != "1" || diff.Changed[0].Current != "4" {
+		t.Fatalf("unexpected event %+v", events[2])
+	}
+
+	monitor.poll(ctx)
+	if got := len(recorder.received()); got != 3 {
+		t.Fatalf("expected no delivery without changes, got %d events", got)
+	}
+}
+
+func TestMonitorRedeliversAfterFailedDelivery(t *testing.T) {
+	recorder := &webhookRecorder{failures: 2}
+	srv := httptest.NewServer(recorder)
+	t.Cleanup(srv.Close)
+
+	monitor, err := NewMonitor(monitorFixture("1"), srv.URL, WithWebhookRetries(2, time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewMonitor returned error: %v", err)
+	}
+	if err := monitor.Watch(testWalletA); err != nil {
+		t.Fatalf("Watch returned error: %v", err)
+	}
+
+	ctx := context.Background()
+	monitor.poll(ctx)
+	monitor.wallets = monitorFixture("4")
+	monitor.poll(ctx)
+	if got := len(recorder.received()); got != 2 {
+		t.Fatalf("expected both attempts to fail, got %d events", got)
+	}
+
+	// The snapshot did not move, so the next poll sends the change again.
+	monitor.poll(ctx)
+	events := recorder.received()
+	if len(events) != 3 {
+		t.Fatalf("expected the change to be redelivered, got %d events", len(events))
+	}
+	if diff := events[2].Diff; len(diff.Changed) != 1 || diff.Changed[0].Previous != "1" {
+		t.Fatalf("expected the redelivery to diff against the first snapshot, got %+v", diff)
+	}
+}
+
+func TestNewMonitorRejectsInvalidInput(t *testing.T) {
+	if _, err := NewMonitor(monitorFixture("1"), "hooks.example.com"); err == nil {
+		t.Fatal("expected a relative webhook URL to fail")
+	}
+	monitor, err := NewMonitor(monitorFixture("1"), "https://hooks.example.com")
+	if err != nil {
+		t.Fatalf("NewMonitor returned error: %v", err)
+	}
+	if err := monitor.Watch("0x123"); err == nil {
+		t.Fatal("expected an invalid address to fail")
+	}
+}
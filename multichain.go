@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ChainTokens is one chain's share of a multi-chain portfolio. Error is set,
+// and Tokens empty, when that chain could not be fetched.
+type ChainTokens struct {
+	Chain               Chain          `json:"chain"`
+	NativeBalance       string         `json:"native_balance,omitempty"`
+	NativeRawBalance    string         `json:"native_raw_balance,omitempty"`
+	NativeDecimals      int            `json:"native_decimals,omitempty"`
+	Tokens              []TokenBalance `json:"tokens"`
+	SkippedTransactions int            `json:"skipped_transactions,omitempty"`
+	Truncated           bool           `json:"truncated,omitempty"`
+	Error               string         `json:"error,omitempty"`
+}
+
+type MultiChainResponse struct {
+	Address string        `json:"address"`
+	Chains  []ChainTokens `json:"chains"`
+}
+
+// GetMultiChainTokens fetches the wallet's token balances on each chain
+// concurrently. A failing chain is annotated with its error rather than
+// failing the whole portfolio; results keep the order of chains. opts apply
+// to every chain.
+func (t *WalletTracker) GetMultiChainTokens(ctx context.Context, walletAddress string, chains []Chain, opts ...QueryOption) (*MultiChainResponse, error) {
+	walletAddress, _, err := t.resolveWallet(ctx, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	return multiChainTokens(ctx, t, walletAddress, chains, opts...)
+}
+
+// multiChainTokens is GetMultiChainTokens over any WalletService. Errors about
+// the wallet itself, such as an ENS name that does not resolve, fail the whole
+// portfolio since every chain would report them.
+func multiChainTokens(ctx context.Context, wallets WalletService, walletAddress string, chains []Chain, opts ...QueryOption) (*MultiChainResponse, error) {
+	resp := &MultiChainResponse{
+		Address: walletAddress,
+		Chains:  make([]ChainTokens, len(chains)),
+	}
+	// Each chain reports the address it looked up, which differs from
+	// walletAddress when that is an ENS name.
+	addresses := make([]string, len(chains))
+	errs := make([]error, len(chains))
+	sem := make(chan struct{}, defaultBatchConcurrency)
+
+	var wg sync.WaitGroup
+	for i, chain := range chains {
+		wg.Add(1)
+		go func(i int, chain Chain) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := ChainTokens{Chain: chain, Tokens: []TokenBalance{}}
+			// Capping opts' capacity makes append copy rather than share
+			// its backing array between goroutines.
+			wallet, err := wallets.GetWalletTokens(ctx, walletAddress, append(opts[:len(opts):len(opts)], OnChain(chain))...)
+			if err != nil {
+				result.Error = err.Error()
+				errs[i] = err
+			} else {
+				addresses[i] = wallet.Address
+				result.NativeBalance = wallet.NativeBalance
+				result.NativeRawBalance = wallet.NativeRawBalance
+				result.NativeDecimals = wallet.NativeDecimals
+				result.Tokens = wallet.Tokens
+				result.SkippedTransactions = wallet.SkippedTransactions
+				result.Truncated = wallet.Truncated
+			}
+			resp.Chains[i] = result
+		}(i, chain)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if errors.Is(err, ErrInvalidWalletAddress) || errors.Is(err, ErrENSNameNotFound) {
+			return nil, err
+		}
+		if addresses[i] != "" {
+			resp.Address = addresses[i]
+		}
+	}
+	return resp, ctx.Err()
+}
+
+// parseChainList resolves a comma-separated list of chain names or IDs,
+// dropping duplicates.
+func parseChainList(raw string) ([]Chain, error) {
+	var chains []Chain
+	seen := make(map[int64]bool)
+	for _, part := range strings.Split(raw, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		chain, err := LookupChain(part)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[chain.ID] {
+			seen[chain.ID] = true
+			chains = append(chains, chain)
+		}
+	}
+	if len(chains) == 0 {
+		return nil, fmt.Errorf("%w: empty chain list", ErrUnsupportedChain)
+	}
+	return chains, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWalletRouteMultipleChains(t *testing.T) {
+	tracker := newTestTracker(t, withNativeBalance("2500000000000000000", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("chainid") {
+		case "1":
+			fmt.Fprintf(w, `{"status":"1","message":"OK","result":[{"contractAddress":"0xc0ffee0000000000000000000000000000000000","tokenName":"Test","tokenSymbol":"TST","tokenDecimal":"0","value":"3","from":"0x3333333333333333333333333333333333333333","to":"%s"}]}`, testWalletA)
+		case "137":
+			fmt.Fprint(w, `{"status":"0","message":"NOTOK","result":"Max rate limit reached"}`)
+		default:
+			fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+		}
+	}))
+	WithEtherscanRetries(0, 0)(tracker)
+	router := setupRoutes(tracker, tracker)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wallet/"+testWalletA+"?chains=1,polygon,137,arbitrum", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp MultiChainResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(resp.Chains) != 3 {
+		t.Fatalf("expected 3 deduplicated chains, got %+v", resp.Chains)
+	}
+	if got := resp.Chains[0]; got.Chain.ID != 1 || got.NativeBalance != "2.5" || len(got.Tokens) != 1 || got.Tokens[0].Balance != "3" {
+		t.Fatalf("unexpected ethereum entry: %+v", got)
+	}
+	if got := resp.Chains[1]; got.Chain.ID != 137 || got.Error == "" || len(got.Tokens) != 0 {
+		t.Fatalf("expected polygon to carry its error, got %+v", got)
+	}
+	if got := resp.Chains[2]; got.Chain.ID != 42161 || got.Error != "" {
+		t.Fatalf("unexpected arbitrum entry: %+v", got)
+	}
+
+	for _, path := range []string{
+		"/wallet/" + testWalletA + "?chains=1,dogechain",
+		"/wallet/" + testWalletA + "?chains=",
+		"/wallet/polygon/" + testWalletA + "?chains=1",
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status 400, got %d", path, rec.Code)
+		}
+	}
+}
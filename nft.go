@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+type NFTTransfer struct {
+	Hash         string    `json:"hash"`
+	BlockNumber  string    `json:"block_number"`
+	Timestamp    time.Time `json:"timestamp"`
+	Contract     string    `json:"contract"`
+	Collection   string    `json:"collection"`
+	Symbol       string    `json:"symbol"`
+	TokenID      string    `json:"token_id"`
+	Direction    string    `json:"direction"`
+	Counterparty string    `json:"counterparty"`
+}
+
+type NFTHistoryResponse struct {
+	Address   string        `json:"address"`
+	Transfers []NFTTransfer `json:"transfers"`
+	// Truncated is set when the server stopped paging before finding limit
+	// matching transfers; older matches may then be missing.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// nftTransaction is an ERC-721 transfer as returned by the tokennfttx action.
+type nftTransaction struct {
+	Hash            string `json:"hash"`
+	BlockNumber     string `json:"blockNumber"`
+	TimeStamp       string `json:"timeStamp"`
+	ContractAddress string `json:"contractAddress"`
+	TokenID         string `json:"tokenID"`
+	TokenName       string `json:"tokenName"`
+	TokenSymbol     string `json:"tokenSymbol"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+}
+
+func (tx nftTransaction) blockNumber() (uint64, error) {
+	return parseBlockNumber(tx.BlockNumber)
+}
+
+func (tx nftTransaction) timestamp() time.Time {
+	return parseUnixTimestamp(tx.TimeStamp)
+}
+
+// GetNFTHistory returns the wallet's ERC-721 transfer events, newest first,
+// with the collection name and symbol carried on each transfer.
+func (t *WalletTracker) GetNFTHistory(ctx context.Context, walletAddress string, q TransferQuery) (*NFTHistoryResponse, error) {
+	if err := ValidateAddress(walletAddress); err != nil {
+		return nil, err
+	}
+	direction, err := normalizeDirection(q.Direction)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.checkTimeRange(); err != nil {
+		return nil, err
+	}
+
+	resp := &NFTHistoryResponse{
+		Address:   walletAddress,
+		Transfers: []NFTTransfer{},
+	}
+
+	limit := transferLimit(q.Limit)
+	params := accountListParams("tokennfttx", walletAddress)
+	t.boundBlocks(ctx, t.chainID, q, params)
+
+	resp.Truncated, err = fetchNewestFirst(ctx, t, t.chainID, params, limit, func(txs []nftTransaction) bool {
+		txs = withinTimeRange(txs, q, nftTransaction.timestamp)
+		resp.Transfers = append(resp.Transfers, filterNFTTransfers(walletAddress, txs, direction, limit-len(resp.Transfers))...)
+		return len(resp.Transfers) >= limit
+	})
+	if err != nil && !errors.Is(err, ErrNoTransactions) {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// filterNFTTransfers mirrors filterTokenTransfers: it keeps up to limit of
+// txs (newest first) that match direction, in order.
+func filterNFTTransfers(walletAddress string, txs []nftTransaction, direction string, limit int) []NFTTransfer {
+	wallet := strings.ToLower(walletAddress)
+	result := make([]NFTTransfer, 0, max(0, min(limit, len(txs))))
+
+	for _, tx := range txs {
+		if len(result) >= limit {
+			break
+		}
+		dir := transferDirection(wallet, strings.ToLower(tx.From), strings.ToLower(tx.To))
+		if dir == "" {
+			continue
+		}
+		if direction != "" && dir != direction && dir != DirectionSelf {
+			continue
+		}
+
+		counterparty := tx.From
+		if dir == DirectionOut {
+			counterparty = tx.To
+		}
+		result = append(result, NFTTransfer{
+			Hash:         tx.Hash,
+			BlockNumber:  tx.BlockNumber,
+			Timestamp:    tx.timestamp(),
+			Contract:     tx.ContractAddress,
+			Collection:   tx.TokenName,
+			Symbol:       tx.TokenSymbol,
+			TokenID:      tx.TokenID,
+			Direction:    dir,
+			Counterparty: counterparty,
+		})
+	}
+	return result
+}
+
+// NFTHolding lists the token IDs of one collection that a wallet currently
+// holds.
+type NFTHolding struct {
+	Contract   string   `json:"contract"`
+	Collection string   `json:"collection"`
+	Symbol     string   `json:"symbol"`
+	TokenIDs   []string `json:"token_ids"`
+}
+
+type NFTHoldingsResponse struct {
+	Address  string       `json:"address"`
+	Holdings []NFTHolding `json:"holdings"`
+	// Truncated is set when the NFT transfer history is longer than the page
+	// cap allows; later transfers are then missing, so NFTs sold since may
+	// still be listed and NFTs bought since may not.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// GetWalletNFTs reconstructs the wallet's current ERC-721 holdings from its
+// transfer history.
+func (t *WalletTracker) GetWalletNFTs(ctx context.Context, walletAddress string) (*NFTHoldingsResponse, error) {
+	if err := ValidateAddress(walletAddress); err != nil {
+		return nil, err
+	}
+
+	resp := &NFTHoldingsResponse{
+		Address:  walletAddress,
+		Holdings: []NFTHolding{},
+	}
+
+	txs, truncated, err := t.fetchNFTTransactions(ctx, t.chainID, walletAddress)
+	if err != nil {
+		if errors.Is(err, ErrNoTransactions) {
+			return resp, nil
+		}
+		return nil, err
+	}
+
+	resp.Holdings = summarizeNFTHoldings(walletAddress, txs)
+	resp.Truncated = truncated
+	return resp, nil
+}
+
+// fetchNFTTransactions returns the wallet's ERC-721 transfers, oldest first,
+// paged by block with fetchForward. The bool reports a truncated history.
+func (t *WalletTracker) fetchNFTTransactions(ctx context.Context, chainID int64, walletAddress string) ([]nftTransaction, bool, error) {
+	return fetchForward(ctx, t, chainID, accountListParams("tokennfttx", walletAddress), "startblock", 0, t.txPageSize, nftTransaction.blockNumber)
+}
+
+// summarizeNFTHoldings is summarizeTokenBalances per token ID: txs are replayed
+// oldest first, so a token that was sent away and later re-acquired ends up
+// held. Collections are ordered by name and token IDs numerically.
+func summarizeNFTHoldings(walletAddress string, txs []nftTransaction) []NFTHolding {
+	wallet := strings.ToLower(walletAddress)
+	collections := make(map[string]*NFTHolding)
+	held := make(map[string]map[string]bool)
+
+	for _, tx := range txs {
+		dir := transferDirection(wallet, strings.ToLower(tx.From), strings.ToLower(tx.To))
+		if dir == "" || dir == DirectionSelf {
+			continue
+		}
+
+		contract := strings.ToLower(tx.ContractAddress)
+		if _, ok := collections[contract]; !ok {
+			collections[contract] = &NFTHolding{Contract: tx.ContractAddress}
+			held[contract] = make(map[string]bool)
+		}
+		collection := collections[contract]
+		collection.Collection = firstNonEmpty(tx.TokenName, collection.Collection)
+		collection.Symbol = firstNonEmpty(tx.TokenSymbol, collection.Symbol)
+
+		if dir == DirectionIn {
+			held[contract][tx.TokenID] = true
+		} else {
+			delete(held[contract], tx.TokenID)
+		}
+	}
+
+	holdings := make([]NFTHolding, 0, len(collections))
+	for contract, collection := range collections {
+		if len(held[contract]) == 0 {
+			continue
+		}
+		for id := range held[contract] {
+			collection.TokenIDs = append(collection.TokenIDs, id)
+		}
+		sort.Slice(collection.TokenIDs, func(i, j int) bool {
+			return compareTokenIDs(collection.TokenIDs[i], collection.TokenIDs[j]) < 0
+		})
+		holdings = append(holdings, *collection)
+	}
+	sort.Slice(holdings, func(i, j int) bool {
+		a, b := strings.ToLower(holdings[i].Collection), strings.ToLower(holdings[j].Collection)
+		if a != b {
+			return a < b
+		}
+		return strings.ToLower(holdings[i].Contract) < strings.ToLower(holdings[j].Contract)
+	})
+	return holdings
+}
+
+// compareTokenIDs orders decimal token IDs numerically, falling back to a
+// string comparison for IDs that do not parse.
+func compareTokenIDs(a, b string) int {
+	x, okA := new(big.Int).SetString(a, 10)
+	y, okB := new(big.Int).SetString(b, 10)
+	if okA && okB {
+		return x.Cmp(y)
+	}
+	return strings.Compare(a, b)
+}
+
+type NFTHistoryRequest struct {
+	WalletAddress string `json:"wallet_address" description:"The cryptocurrency wallet address to inspect"`
+	Direction     string `json:"direction,omitempty" description:"Filter by direction: in, out or all (default all)"`
+	Limit         int    `json:"limit,omitempty" description:"Maximum number of transfers to return (default 50, max 1000)"`
+	StartTime     string `json:"start_time,omitempty" description:"Only transfers at or after this time, RFC 3339 or unix seconds"`
+	EndTime       string `json:"end_time,omitempty" description:"Only transfers at or before this time, RFC 3339 or unix seconds"`
+}
+
+func registerNFTHistory(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_nft_history", "List a wallet's NFT (ERC-721) transfer events with collection names, newest first", trackCall(ctx, tracker, func(ctx context.Context, req NFTHistoryRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		since, until, err := parseTimeRange(req.StartTime, req.EndTime)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := tracker.GetNFTHistory(ctx, wallet, TransferQuery{
+			Direction: req.Direction,
+			Limit:     req.Limit,
+			Since:     since,
+			Until:     until,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatNFTHistoryResponse(resp)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatNFTHistoryResponse(resp *NFTHistoryResponse) string {
+	if len(resp.Transfers) == 0 {
+		return fmt.Sprintf("Wallet Address: %s\nNo NFT transfers found.", resp.Address)
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallet Address: %s\nNFT transfers:\n", resp.Address))
+	for _, tr := range resp.Transfers {
+		collection := firstNonEmpty(tr.Collection, tr.Contract)
+		if tr.Symbol != "" {
+			collection = fmt.Sprintf("%s (%s)", collection, tr.Symbol)
+		}
+		item := fmt.Sprintf("%s #%s", collection, tr.TokenID)
+
+		var action string
+		switch tr.Direction {
+		case DirectionIn:
+			action = fmt.Sprintf("received %s from %s", item, tr.Counterparty)
+		case DirectionOut:
+			action = fmt.Sprintf("sent %s to %s", item, tr.Counterparty)
+		default:
+			action = fmt.Sprintf("self-transfer of %s", item)
+		}
+		builder.WriteString(fmt.Sprintf("- %s: %s (tx %s)\n", tr.Timestamp.Format(time.RFC3339), action, tr.Hash))
+	}
+	if resp.Truncated {
+		builder.WriteString("Warning: the NFT transfer history is longer than the server pages through; older transfers may be missing.\n")
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+type WalletNFTsRequest struct {
+	WalletAddress string `json:"wallet_address" description:"The cryptocurrency wallet address to inspect"`
+}
+
+func registerWalletNFTs(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_nfts", "List the NFTs (ERC-721) a wallet currently holds, grouped by collection", trackCall(ctx, tracker, func(ctx context.Context, req WalletNFTsRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := tracker.GetWalletNFTs(ctx, wallet)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatNFTHoldingsResponse(resp)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatNFTHoldingsResponse(resp *NFTHoldingsResponse) string {
+	if len(resp.Holdings) == 0 && !resp.Truncated {
+		return fmt.Sprintf("Wallet Address: %s\nNo NFTs held.", resp.Address)
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallet Address: %s\nNFTs:\n", resp.Address))
+	for _, holding := range resp.Holdings {
+		collection := firstNonEmpty(holding.Collection, holding.Contract)
+		if holding.Symbol != "" {
+			collection = fmt.Sprintf("%s (%s)", collection, holding.Symbol)
+		}
+		builder.WriteString(fmt.Sprintf("- %s: #%s\n", collection, strings.Join(holding.TokenIDs, ", #")))
+	}
+	if resp.Truncated {
+		builder.WriteString("Warning: the NFT transfer history is longer than the server fetches; holdings only reflect the earliest transfers.\n")
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
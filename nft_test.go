@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGetNFTHistory(t *testing.T) {
+	wallet := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	buyer := "0x2222222222222222222222222222222222222222"
+
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if got := q.Get("action"); got != "tokennfttx" {
+			t.Errorf("expected action=tokennfttx, got %q", got)
+		}
+		if q.Get("sort") != "desc" || q.Get("page") != "1" || q.Get("offset") != strconv.Itoa(defaultTransferLimit) {
+			t.Errorf("expected the newest page of %d transfers, got %s", defaultTransferLimit, r.URL.RawQuery)
+		}
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+			{"hash":"0x02","timeStamp":"1700000100","contractAddress":"0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d","tokenID":"42","tokenName":"BoredApeYachtClub","tokenSymbol":"BAYC","from":"%[1]s","to":"%[2]s"},
+			{"hash":"0x01","timeStamp":"1700000000","contractAddress":"0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d","tokenID":"42","tokenName":"BoredApeYachtClub","tokenSymbol":"BAYC","from":"0x1111111111111111111111111111111111111111","to":"%[1]s"}
+		]}`, wallet, buyer)
+	})
+
+	resp, err := tracker.GetNFTHistory(context.Background(), wallet, TransferQuery{})
+	if err != nil {
+		t.Fatalf("GetNFTHistory returned error: %v", err)
+	}
+	if len(resp.Transfers) != 2 {
+		t.Fatalf("expected 2 transfers, got %d", len(resp.Transfers))
+	}
+	if got := resp.Transfers[0]; got.Hash != "0x02" || got.Direction != DirectionOut || got.Counterparty != buyer || got.TokenID != "42" || got.Collection != "BoredApeYachtClub" {
+		t.Fatalf("unexpected newest transfer: %+v", got)
+	}
+
+	in, err := tracker.GetNFTHistory(context.Background(), wallet, TransferQuery{Direction: DirectionIn})
+	if err != nil {
+		t.Fatalf("GetNFTHistory returned error: %v", err)
+	}
+	if len(in.Transfers) != 1 || in.Transfers[0].Hash != "0x01" {
+		t.Fatalf("expected only the incoming transfer, got %+v", in.Transfers)
+	}
+
+	text := formatNFTHistoryResponse(resp)
+	if !strings.Contains(text, "sent BoredApeYachtClub (BAYC) #42 to "+buyer) {
+		t.Fatalf("unexpected output:\n%s", text)
+	}
+}
+
+func TestSummarizeNFTHoldings(t *testing.T) {
+	wallet := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	other := "0x2222222222222222222222222222222222222222"
+	bayc := "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d"
+	punks := "0xb47e3cd837ddf8e4c57f05d70ab865de6e193bbb"
+
+	transfer := func(contract, name, id, from, to string) nftTransaction {
+		return nftTransaction{ContractAddress: contract, TokenName: name, TokenSymbol: strings.ToUpper(name[:4]), TokenID: id, From: from, To: to}
+	}
+	txs := []nftTransaction{
+		transfer(bayc, "BoredApeYachtClub", "42", other, wallet),
+		transfer(bayc, "BoredApeYachtClub", "100", other, wallet),
+		transfer(bayc, "BoredApeYachtClub", "9", other, wallet),
+		// 42 is sold and later bought back.
+		transfer(bayc, "BoredApeYachtClub", "42", wallet, other),
+		transfer(bayc, "BoredApeYachtClub", "42", other, wallet),
+		// The only punk is sold, so the collection disappears.
+		transfer(punks, "CryptoPunks", "7", other, wallet),
+		transfer(punks, "CryptoPunks", "7", wallet, other),
+		transfer(bayc, "BoredApeYachtClub", "9", wallet, wallet),
+	}
+
+	holdings := summarizeNFTHoldings(wallet, txs)
+	if len(holdings) != 1 {
+		t.Fatalf("expected only BAYC to be held, got %+v", holdings)
+	}
+	if got := strings.Join(holdings[0].TokenIDs, ","); got != "9,42,100" {
+		t.Fatalf("expected token IDs 9,42,100 in numeric order, got %s", got)
+	}
+
+	text := formatNFTHoldingsResponse(&NFTHoldingsResponse{Address: wallet, Holdings: holdings})
+	if want := "Wallet Address: " + wallet + "\nNFTs:\n- BoredApeYachtClub (BORE): #9, #42, #100"; text != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", text, want)
+	}
+}
+
+func TestGetWalletNFTsPagesThroughHistory(t *testing.T) {
+	bayc := "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d"
+	other := "0x2222222222222222222222222222222222222222"
+	// Token 1 arrives in block 1 and is sold in block 4, past the first
+	// page; token 2 arrives in block 3, which straddles the page boundary.
+	history := []struct{ block, id, from, to string }{
+		{"1", "1", other, testWalletA},
+		{"2", "5", other, testWalletA},
+		{"3", "2", other, testWalletA},
+		{"3", "5", testWalletA, other},
+		{"4", "1", testWalletA, other},
+	}
+	var startBlocks []string
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		start := r.URL.Query().Get("startblock")
+		startBlocks = append(startBlocks, start)
+		from, _ := strconv.Atoi(start)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+		var entries []string
+		for i, tx := range history {
+			if n, _ := strconv.Atoi(tx.block); n >= from && len(entries) < limit {
+				entries = append(entries, fmt.Sprintf(`{"hash":"0x%d","blockNumber":"%s","contractAddress":"%s","tokenID":"%s","tokenName":"BoredApeYachtClub","from":"%s","to":"%s"}`, i, tx.block, bayc, tx.id, tx.from, tx.to))
+			}
+		}
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[%s]}`, strings.Join(entries, ","))
+	})
+	tracker.txPageSize = 3
+
+	resp, err := tracker.GetWalletNFTs(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetWalletNFTs returned error: %v", err)
+	}
+	if resp.Truncated || len(resp.Holdings) != 1 || strings.Join(resp.Holdings[0].TokenIDs, ",") != "2" {
+		t.Fatalf("expected only token 2 to be held, got %+v", resp)
+	}
+	if got := strings.Join(startBlocks, ","); got != "0,3,4" {
+		t.Fatalf("expected start blocks 0,3,4, got %s", got)
+	}
+
+	WithMaxTransferPages(1)(tracker)
+	resp, err = tracker.GetWalletNFTs(context.Background(), testWalletA)
+	if err != nil || !resp.Truncated {
+		t.Fatalf("expected the holdings to be truncated at the page cap, got %+v, %v", resp, err)
+	}
+	if text := formatNFTHoldingsResponse(resp); !strings.Contains(text, "Warning: the NFT transfer history is longer") {
+		t.Fatalf("expected a truncation warning, got:\n%s", text)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// BalanceStrategy selects how GetWalletTokens computes token balances.
+type BalanceStrategy string
+
+const (
+	// BalanceFromTransfers nets the wallet's transfer history. It needs only
+	// Etherscan but misses balance changes that emit no transfer, such as
+	// rebases and transfer fees.
+	BalanceFromTransfers BalanceStrategy = "transfers"
+	// BalanceOnChain still uses the transfer history to find the wallet's
+	// tokens, then reads each balance with an ERC-20 balanceOf call over
+	// JSON-RPC: one eth_call per token.
+	BalanceOnChain BalanceStrategy = "onchain"
+)
+
+const (
+	erc20BalanceOfSelector      = "70a08231"
+	erc20DecimalsSelector       = "313ce567"
+	defaultBalanceOfConcurrency = 4
+)
+
+// ParseBalanceStrategy accepts "transfers" or "onchain", case-insensitively.
+func ParseBalanceStrategy(raw string) (BalanceStrategy, error) {
+	switch s := BalanceStrategy(strings.ToLower(strings.TrimSpace(raw))); s {
+	case BalanceFromTransfers, BalanceOnChain:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown balance strategy %q (want transfers or onchain)", raw)
+}
+
+// balanceOf reads contract.balanceOf(wallet) as of block, or the latest block
+// when block is zero.
+func (c *rpcClient) balanceOf(ctx context.Context, contract, wallet string, block uint64) (*big.Int, error) {
+	call := map[string]string{
+		"to":   contract,
+		"data": "0x" + erc20BalanceOfSelector + strings.Repeat("0", 24) + strings.ToLower(strings.TrimPrefix(wallet, "0x")),
+	}
+	tag := "latest"
+	if block != 0 {
+		tag = fmt.Sprintf("0x%x", block)
+	}
+
+	var result string
+	if err := c.call(ctx, "eth_call", []any{call, tag}, &result); err != nil {
+		return nil, err
+	}
+	return parseHexBig(result)
+}
+
+// onChainBalances replaces the transfer-derived balance of each token with its
+// balanceOf, then drops tokens that turn out empty or below min. Tokens keep
+// their order and metadata.
+func (t *WalletTracker) onChainBalances(ctx context.Context, walletAddress string, tokens []TokenBalance, block uint64, min *big.Rat) ([]TokenBalance, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	balances := make([]*big.Int, len(tokens))
+	sem := make(chan struct{}, defaultBalanceOfConcurrency)
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	for i, token := range tokens {
+		wg.Add(1)
+		go func(i int, contract string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			balance, err := t.rpc.balanceOf(ctx, contract, walletAddress, block)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("reading balance of %s: %w", contract, err)
+					cancel()
+				})
+				return
+			}
+			balances[i] = balance
+		}(i, token.Address)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	result := make([]TokenBalance, 0, len(tokens))
+	for i, token := range tokens {
+		balance := balances[i]
+		if balance.Sign() == 0 || belowMinBalance(balance, token.Decimals, min) {
+			continue
+		}
+		token.Balance = formatTokenBalance(balance, token.Decimals)
+		token.RawBalance = balance.String()
+		result = append(result, token)
+	}
+	return result, nil
+}
+
+// transferSumUnreliable lists Ethereum mainnet tokens whose balances change
+// without matching transfer events, so netting transfers misreports them.
+var transferSumUnreliable = map[string]bool{
+	"0xae7ab96520de3a18e5e111b5eaab095312d7fe84": true, // stETH (rebasing)
+	"0xd46ba6d942050d489dbd938a2c909a5d5039a161": true, // AMPL (rebasing)
+	"0x04906695d6d12cf5459975d7c3c03356e4ccd460": true, // sOHM (rebasing)
+	"0xbcca60bb61934080951369a648fb03df4f96263c": true, // Aave aUSDC (interest-bearing)
+	"0x030ba81f1c18d280636f32af80b9aad02cf0854e": true, // Aave aWETH (interest-bearing)
+	"0x45804880de22913dafe09f4980848ece6ecbaf78": true, // PAXG (fee on transfer)
+}
+
+// checkUnreliableBalances handles tokens in transferSumUnreliable whose
+// balances were summed from transfers: with a JSON-RPC endpoint they are
+// replaced by balanceOf, otherwise, or if that fails, they are marked
+// Approximate. Tokens whose checked balance is empty or below min are dropped.
+func (t *WalletTracker) checkUnreliableBalances(ctx context.Context, walletAddress string, tokens []TokenBalance, chain Chain, block uint64, min *big.Rat) []TokenBalance {
+	if chain.ID != defaultChain.ID {
+		return tokens
+	}
+
+	result := tokens[:0]
+	for _, token := range tokens {
+		if !transferSumUnreliable[strings.ToLower(token.Address)] {
+			result = append(result, token)
+			continue
+		}
+		if t.rpc == nil {
+			token.Approximate = true
+			result = append(result, token)
+			continue
+		}
+
+		balance, err := t.rpc.balanceOf(ctx, token.Address, walletAddress, block)
+		if err != nil {
+			t.logger.Warn("Checking balance on-chain failed", "address", walletAddress, "contract", token.Address, "error", err)
+			token.Approximate = true
+			result = append(result, token)
+			continue
+		}
+		if balance.Sign() == 0 || belowMinBalance(balance, token.Decimals, min) {
+			continue
+		}
+		token.Balance = formatTokenBalance(balance, token.Decimals)
+		token.RawBalance = balance.String()
+		result = append(result, token)
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOnChainBalanceStrategy(t *testing.T) {
+	steth := "0xae7ab96520de3a18e5e111b5eaab095312d7fe84"
+	usdc := "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	other := "0x3333333333333333333333333333333333333333"
+
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	tracker := newRPCTestTracker(t, func(method string, params []json.RawMessage) (string, *rpcError) {
+		var call struct{ To, Data string }
+		if method != "eth_call" || json.Unmarshal(params[0], &call) != nil {
+			t.Errorf("unexpected rpc call %s %s", method, params)
+		}
+		if !strings.HasPrefix(call.Data, "0x70a08231") || !strings.HasSuffix(call.Data, strings.TrimPrefix(testWalletA, "0x")) {
+			t.Errorf("unexpected balanceOf calldata %s", call.Data)
+		}
+		mu.Lock()
+		calls = append(calls, call.To)
+		mu.Unlock()
+		switch strings.ToLower(call.To) {
+		case steth:
+			// Rebased above the 1 stETH received.
+			return `"0x0000000000000000000000000000000000000000000000000e043da617250000"`, nil
+		default:
+			// USDC was sent away since.
+			return `"0x0000000000000000000000000000000000000000000000000000000000000000"`, nil
+		}
+	})
+	etherscan := httptest.NewServer(withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+			{"blockNumber":"1","contractAddress":"%[1]s","tokenName":"Liquid staked Ether 2.0","tokenSymbol":"stETH","tokenDecimal":"18","value":"1000000000000000000","from":"%[3]s","to":"%[4]s"},
+			{"blockNumber":"2","contractAddress":"%[2]s","tokenName":"USD Coin","tokenSymbol":"USDC","tokenDecimal":"6","value":"5000000","from":"%[3]s","to":"%[4]s"},
+			{"blockNumber":"3","contractAddress":"%[2]s","tokenName":"USD Coin","tokenSymbol":"USDC","tokenDecimal":"6","value":"5000000","from":"%[4]s","to":"%[3]s"}
+		]}`, steth, usdc, other, testWalletA)
+	}))
+	t.Cleanup(etherscan.Close)
+	tracker.baseURL = etherscan.URL
+	WithBalanceStrategy(BalanceOnChain)(tracker)
+
+	resp, err := tracker.GetWalletTokens(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetWalletTokens returned error: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected a balanceOf call per token in the history, got %v", calls)
+	}
+	if len(resp.Tokens) != 1 || resp.Tokens[0].Symbol != "stETH" || resp.Tokens[0].Balance != "1.01" {
+		t.Fatalf("expected only the rebased stETH balance, got %+v", resp.Tokens)
+	}
+}
+
+func TestOnChainBalanceStrategyNeedsRPC(t *testing.T) {
+	if _, err := NewWalletTracker("key", WithBalanceStrategy(BalanceOnChain)); !errors.Is(err, ErrRPCNotConfigured) {
+		t.Fatalf("expected ErrRPCNotConfigured, got %v", err)
+	}
+	if _, err := ParseBalanceStrategy("OnChain"); err != nil {
+		t.Fatalf("ParseBalanceStrategy returned error: %v", err)
+	}
+	if _, err := ParseBalanceStrategy("oracle"); err == nil {
+		t.Fatalf("expected an unknown strategy to fail")
+	}
+}
+
+func TestCheckUnreliableBalances(t *testing.T) {
+	steth := "0xae7ab96520de3a18e5e111b5eaab095312d7fe84"
+	tokens := func() []TokenBalance {
+		return []TokenBalance{
+			{Address: "0xAE7AB96520DE3A18E5E111B5EAAB095312D7FE84", Name: "Liquid staked Ether 2.0", Symbol: "stETH", Decimals: 18, Balance: "1", RawBalance: "1000000000000000000"},
+			{Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", Name: "USD Coin", Symbol: "USDC", Decimals: 6, Balance: "5", RawBalance: "5000000"},
+		}
+	}
+
+	tracker, err := NewWalletTracker("key")
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+	flagged := tracker.checkUnreliableBalances(context.Background(), testWalletA, tokens(), defaultChain, 0, nil)
+	if !flagged[0].Approximate || flagged[1].Approximate {
+		t.Fatalf("expected only stETH to be marked approximate without an RPC endpoint, got %+v", flagged)
+	}
+	text := formatWalletResponse(&WalletResponse{Address: testWalletA, Tokens: flagged}, formatOptions{})
+	if !strings.Contains(text, "(stETH): 1 (approximate)") || !strings.Contains(text, "Note: approximate balances") {
+		t.Fatalf("expected the approximate marker and note, got:\n%s", text)
+	}
+
+	polygon, _ := LookupChain("polygon")
+	if other := tracker.checkUnreliableBalances(context.Background(), testWalletA, tokens(), polygon, 0, nil); other[0].Approximate {
+		t.Fatalf("expected the mainnet list not to apply on other chains")
+	}
+
+	rpcTracker := newRPCTestTracker(t, func(method string, params []json.RawMessage) (string, *rpcError) {
+		var call struct{ To string }
+		json.Unmarshal(params[0], &call)
+		if !strings.EqualFold(call.To, steth) {
+			t.Errorf("expected only stETH to be checked, got %s", call.To)
+		}
+		return `"0x0de0b6b3a7640001"`, nil
+	})
+	checked := rpcTracker.checkUnreliableBalances(context.Background(), testWalletA, tokens(), defaultChain, 0, nil)
+	if checked[0].Approximate || checked[0].RawBalance != "1000000000000000001" {
+		t.Fatalf("expected stETH to be corrected on-chain, got %+v", checked[0])
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Option configures optional WalletTracker behaviour at construction time.
+type Option func(*WalletTracker)
+
+// WithMaxConnsPerHost bounds the number of simultaneous connections, and the
+// number of idle connections kept for reuse, to the Etherscan host. Values
+// below one are ignored. Defaults to 10.
+func WithMaxConnsPerHost(n int) Option {
+	return func(t *WalletTracker) {
+		if n > 0 {
+			t.maxConnsPerHost = n
+		}
+	}
+}
+
+// WithMaxBatchSize caps how many items a tool accepts in a single list
+// argument (e.g. ENS names or contract addresses). Requests over the cap are
+// rejected before any upstream call. Values below one are ignored. Defaults
+// to 100.
+func WithMaxBatchSize(n int) Option {
+	return func(t *WalletTracker) {
+		if n > 0 {
+			t.maxBatchSize = n
+		}
+	}
+}
+
+// WithMaxResponseBytes bounds how much of a single Etherscan response body is
+// read. Larger responses fail with ErrResponseTooLarge rather than being
+// buffered, which matters when the base URL points at an untrusted endpoint.
+// Values below one are ignored. Defaults to 50MB.
+func WithMaxResponseBytes(n int64) Option {
+	return func(t *WalletTracker) {
+		if n > 0 {
+			t.maxRespBytes = n
+		}
+	}
+}
+
+// WithMaxTransferPages caps how many pages of token transfers (10,000 records
+// each) are fetched for one wallet. Longer histories are cut off and the
+// response is marked Truncated. Values below one are ignored. Defaults to 10.
+func WithMaxTransferPages(n int) Option {
+	return func(t *WalletTracker) {
+		if n > 0 {
+			t.maxTxPages = n
+		}
+	}
+}
+
+// WithEtherscanRetries sets how many times a rate-limited Etherscan call (HTTP
+// 429 or a "rate limit reached" result) is retried, and the base delay, which
+// doubles with each retry and is jittered. Defaults to 2 retries from 500ms;
+// zero retries disables them. Negative values are ignored.
+func WithEtherscanRetries(n int, baseDelay time.Duration) Option {
+	return func(t *WalletTracker) {
+		if n >= 0 {
+			t.retries = n
+		}
+		if baseDelay >= 0 {
+			t.retryBackoff = baseDelay
+		}
+	}
+}
+
+// WithTokenMetadataResolver sets where token names, symbols and decimals are
+// looked up when a token's transfers lack them, e.g. the token contract. By
+// default only the transfer history is used.
+func WithTokenMetadataResolver(r TokenMetadataResolver) Option {
+	return func(t *WalletTracker) {
+		t.metadataResolver = r
+	}
+}
+
+// WithBaseURL points the tracker at another Etherscan-compatible API, such as
+// a self-hosted Blockscout instance (e.g. "https://eth.blockscout.com/api") or
+// a local mock server. NewWalletTracker fails with ErrInvalidBaseURL unless it
+// is an absolute http(s) URL. Defaults to the Etherscan V2 API.
+func WithBaseURL(baseURL string) Option {
+	return func(t *WalletTracker) {
+		t.baseURL = strings.TrimSpace(baseURL)
+	}
+}
+
+// WithRateLimit caps Etherscan calls at rps per second, shared by all lookups
+// on the tracker, including retries. Defaults to 5, the limit of a free key;
+// zero disables limiting, e.g. for a paid plan. Negative values are ignored.
+func WithRateLimit(rps int) Option {
+	return func(t *WalletTracker) {
+		if rps >= 0 {
+			t.rateLimit = rps
+		}
+	}
+}
+
+// WithChainID sets the chain queried when a request does not name one, by its
+// Etherscan V2 chain ID. Unsupported IDs are ignored. Defaults to 1 (Ethereum
+// mainnet).
+func WithChainID(id int64) Option {
+	return func(t *WalletTracker) {
+		for _, c := range supportedChains {
+			if c.ID == id {
+				t.chainID = id
+				return
+			}
+		}
+	}
+}
+
+// WithRPCURL sets the Ethereum JSON-RPC endpoint used for lookups that
+// Etherscan does not serve. Features that need it report ErrRPCNotConfigured
+// when it is unset.
+func WithRPCURL(url string) Option {
+	return func(t *WalletTracker) {
+		t.rpcURL = strings.TrimSpace(url)
+	}
+}
+
+// WithHTTPTimeout sets the per-request timeout of the Etherscan client. Each
+// page of a paginated history is a separate request. Values below one are
+// ignored. Defaults to 10s.
+func WithHTTPTimeout(d time.Duration) Option {
+	return func(t *WalletTracker) {
+		if d > 0 {
+			t.httpTimeout = d
+		}
+	}
+}
+
+// WithHTTPClient makes Etherscan calls through c, e.g. an instrumented client
+// or one that goes through a proxy. The client is used as is, so
+// WithHTTPTimeout and WithMaxConnsPerHost do not apply to it. A nil client is
+// ignored.
+func WithHTTPClient(c *http.Client) Option {
+	return func(t *WalletTracker) {
+		if c != nil {
+			t.client = c
+			t.customClient = true
+		}
+	}
+}
+
+// WithToolTimeout bounds how long a single MCP tool call may run, across all
+// the upstream requests it makes, before it is cancelled. Values below one are
+// ignored. Defaults to 2m.
+func WithToolTimeout(d time.Duration) Option {
+	return func(t *WalletTracker) {
+		if d > 0 {
+			t.toolTimeout = d
+		}
+	}
+}
+
+// WithLogger routes the tracker's logs to l, e.g. a JSON handler or one with
+// a higher level to silence per-transaction warnings, which are logged at
+// debug level. A nil logger is ignored. Defaults to slog.Default(), which
+// logs at info level through the standard log package.
+func WithLogger(l *slog.Logger) Option {
+	return func(t *WalletTracker) {
+		if l != nil {
+			t.logger = l
+		}
+	}
+}
+
+// WithBalanceStrategy selects how token balances are computed: by netting
+// transfers (the default) or with balanceOf calls on the default chain, which
+// needs WithRPCURL. Unknown strategies are ignored.
+func WithBalanceStrategy(s BalanceStrategy) Option {
+	return func(t *WalletTracker) {
+		if s == BalanceFromTransfers || s == BalanceOnChain {
+			t.balanceStrategy = s
+		}
+	}
+}
+
+// WithCORSOrigins lets browser pages on the given origins (e.g.
+// "https://dashboard.example.com") call the HTTP API. "*" allows any origin
+// and must be listed explicitly. Defaults to none, i.e. same-origin only.
+func WithCORSOrigins(origins []string) Option {
+	return func(t *WalletTracker) {
+		t.cors = newCORSPolicy(origins)
+	}
+}
+
+// WithRetryMetrics records every failed Etherscan attempt in s, such as a
+// PrometheusMetrics, by reason. Defaults to none.
+func WithRetryMetrics(s RetrySink) Option {
+	return func(t *WalletTracker) {
+		t.retrySink = s
+	}
+}
+
+// WithMetricsHandler serves h, such as a PrometheusMetrics, at /metrics on the
+// HTTP API. Defaults to none, i.e. no /metrics route.
+func WithMetricsHandler(h http.Handler) Option {
+	return func(t *WalletTracker) {
+		t.metrics = h
+	}
+}
+
+// WithRPCTimeout sets the per-request timeout of the JSON-RPC client,
+// independently of the Etherscan client. Defaults to 5s.
+func WithRPCTimeout(d time.Duration) Option {
+	return func(t *WalletTracker) {
+		if d > 0 {
+			t.rpcTimeout = d
+		}
+	}
+}
+
+// WithRPCRetries sets how many times a failed JSON-RPC request is retried
+// after network errors, 429s or 5xx responses. Defaults to 2; zero disables
+// retries.
+func WithRPCRetries(n int) Option {
+	return func(t *WalletTracker) {
+		if n >= 0 {
+			t.rpcRetries = n
+		}
+	}
+}
+
+// WithENSResolver overrides the resolver used for ENS names. By default names
+// are resolved through the endpoint configured with WithRPCURL.
+func WithENSResolver(r ENSResolver) Option {
+	return func(t *WalletTracker) {
+		t.ens = r
+	}
+}
+
+// WithBlockHeightProvider overrides where the latest block number comes from.
+// By default the JSON-RPC endpoint is used when configured, otherwise
+// Etherscan. Results are cached for a few seconds either way.
+func WithBlockHeightProvider(p BlockHeightProvider) Option {
+	return func(t *WalletTracker) {
+		t.blockHeight = p
+	}
+}
+
+// WithPriceProvider enables USD valuation of token balances through p, e.g.
+// NewCoinGeckoPriceProvider. Prices are cached for a minute. Without it,
+// balances are reported unpriced.
+func WithPriceProvider(p PriceProvider) Option {
+	return func(t *WalletTracker) {
+		t.prices = p
+	}
+}
+
+// WithSpamFilter replaces the default SpamFilter, e.g. to add a blocklist of
+// contract addresses with NewSpamFilter. A nil filter is ignored.
+func WithSpamFilter(f *SpamFilter) Option {
+	return func(t *WalletTracker) {
+		if f != nil {
+			t.spam = f
+		}
+	}
+}
+
+// WithDrainerList sets the known drainer addresses wallet_risk checks
+// approvals against. Keep l to update the list later with Set. A nil list is
+// ignored; by default the list is empty.
+func WithDrainerList(l *DrainerList) Option {
+	return func(t *WalletTracker) {
+		if l != nil {
+			t.drainers = l
+		}
+	}
+}
+
+// WithCache caches GetWalletTokens results by chain and address in c, e.g.
+// NewTTLCache. A miss falls through to Etherscan. Without it, every lookup
+// queries Etherscan.
+func WithCache(c Cache) Option {
+	return func(t *WalletTracker) {
+		t.cache = c
+	}
+}
+
+// WithENSConcurrency bounds how many ENS lookups run against the resolver at
+// once, across all tools. Values below one are ignored. Defaults to 4.
+func WithENSConcurrency(n int) Option {
+	return func(t *WalletTracker) {
+		if n > 0 {
+			t.ensConcurrency = n
+		}
+	}
+}
+
+// WithENSCache sizes the ENS lookup cache. Resolved names are kept for ttl and
+// names that do not resolve for negativeTTL; a zero TTL disables caching of
+// that kind, and a zero size disables the cache. Negative values are ignored.
+// Defaults to 1000 entries, 1h and 5m.
+func WithENSCache(size int, ttl, negativeTTL time.Duration) Option {
+	return func(t *WalletTracker) {
+		if size >= 0 {
+			t.ensCacheSize = size
+		}
+		if ttl >= 0 {
+			t.ensCacheTTL = ttl
+		}
+		if negativeTTL >= 0 {
+			t.ensNegativeTTL = negativeTTL
+		}
+	}
+}
+
+// WithDecimalsOverrides supplies explicit decimals per token contract for
+// tokens whose on-chain or Etherscan-reported decimals are wrong or missing.
+// Overrides take precedence over every other decimals source. Negative values
+// are ignored.
+func WithDecimalsOverrides(overrides map[string]int) Option {
+	return func(t *WalletTracker) {
+		if t.decimalOverrides == nil {
+			t.decimalOverrides = make(map[string]int, len(overrides))
+		}
+		for contract, decimals := range overrides {
+			if decimals < 0 {
+				continue
+			}
+			t.decimalOverrides[strings.ToLower(strings.TrimSpace(contract))] = decimals
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+const (
+	pendingSourceTxPool = "txpool"
+	pendingSourceBlock  = "pending_block"
+	// pendingSourceUnavailable marks a response from an endpoint that exposes
+	// neither source, which only carries the pending count.
+	pendingSourceUnavailable = "unavailable"
+)
+
+// ErrPendingUnsupported is returned when no RPC endpoint is configured, in
+// which case it also wraps ErrRPCNotConfigured, or when the tracker is on
+// another chain than the endpoint's Ethereum mainnet.
+var ErrPendingUnsupported = errors.New("pending transactions need an rpc endpoint")
+
+type PendingTransaction struct {
+	Hash  string `json:"hash"`
+	Nonce uint64 `json:"nonce"`
+	To    string `json:"to"`
+	Value string `json:"value"`
+}
+
+type PendingResponse struct {
+	Address      string `json:"address"`
+	NativeSymbol string `json:"native_symbol"`
+	// PendingCount is the gap between the pending and latest nonce, i.e. how
+	// many transactions from the wallet the node knows are not yet mined.
+	PendingCount uint64               `json:"pending_count"`
+	Source       string               `json:"source,omitempty"`
+	Transactions []PendingTransaction `json:"transactions"`
+}
+
+type rpcPendingTx struct {
+	Hash  string  `json:"hash"`
+	Nonce string  `json:"nonce"`
+	From  string  `json:"from"`
+	To    *string `json:"to"`
+	Value string  `json:"value"`
+}
+
+// GetPendingTransactions reports unconfirmed transactions sent by the wallet.
+// Support depends on the RPC endpoint: txpool_contentFrom (geth-style nodes)
+// is tried first, then the "pending" block. When neither is available the
+// response still carries the pending count, with Source set to "unavailable"
+// and no transactions. Without an RPC endpoint, or on a chain other than
+// mainnet, which is the only one the endpoint serves, the error wraps
+// ErrPendingUnsupported.
+func (t *WalletTracker) GetPendingTransactions(ctx context.Context, walletAddress string) (*PendingResponse, error) {
+	if err := ValidateAddress(walletAddress); err != nil {
+		return nil, err
+	}
+	if t.chainID != defaultChain.ID {
+		return nil, fmt.Errorf("%w: the rpc endpoint serves %s, but the tracker is on %s", ErrPendingUnsupported, defaultChain.Name, t.chain().Name)
+	}
+	rpc, err := t.requireRPC()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPendingUnsupported, err)
+	}
+
+	resp := &PendingResponse{
+		Address:      walletAddress,
+		NativeSymbol: t.chain().NativeSymbol,
+		Transactions: []PendingTransaction{},
+	}
+
+	count, err := pendingNonceGap(ctx, rpc, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	resp.PendingCount = count
+
+	txs, source, err := fetchPendingFromTxPool(ctx, rpc, walletAddress)
+	if err != nil {
+		txs, source, err = fetchPendingFromBlock(ctx, rpc, walletAddress)
+	}
+	if err != nil {
+		t.logger.Debug("pending transactions unavailable", "address", walletAddress, "error", err)
+		resp.Source = pendingSourceUnavailable
+		return resp, nil
+	}
+
+	resp.Source = source
+	for _, tx := range txs {
+		nonce, _ := parseHexUint64(tx.Nonce)
+		value, err := parseHexBig(tx.Value)
+		if err != nil {
+			continue
+		}
+		to := ""
+		if tx.To != nil {
+			to = *tx.To
+		}
+		resp.Transactions = append(resp.Transactions, PendingTransaction{
+			Hash:  tx.Hash,
+			Nonce: nonce,
+			To:    to,
+			Value: formatTokenBalance(value, nativeDecimals),
+		})
+	}
+
+	sort.Slice(resp.Transactions, func(i, j int) bool {
+		return resp.Transactions[i].Nonce < resp.Transactions[j].Nonce
+	})
+	return resp, nil
+}
+
+func pendingNonceGap(ctx context.Context, rpc *rpcClient, walletAddress string) (uint64, error) {
+	var latestRaw, pendingRaw string
+	if err := rpc.call(ctx, "eth_getTransactionCount", []any{walletAddress, "latest"}, &latestRaw); err != nil {
+		return 0, fmt.Errorf("fetching latest nonce: %w", err)
+	}
+	if err := rpc.call(ctx, "eth_getTransactionCount", []any{walletAddress, "pending"}, &pendingRaw); err != nil {
+		return 0, fmt.Errorf("fetching pending nonce: %w", err)
+	}
+
+	latest, err := parseHexUint64(latestRaw)
+	if err != nil {
+		return 0, err
+	}
+	pending, err := parseHexUint64(pendingRaw)
+	if err != nil {
+		return 0, err
+	}
+	if pending < latest {
+		return 0, nil
+	}
+	return pending - latest, nil
+}
+
+func fetchPendingFromTxPool(ctx context.Context, rpc *rpcClient, walletAddress string) ([]rpcPendingTx, string, error) {
+	var content struct {
+		Pending map[string]rpcPendingTx `json:"pending"`
+	}
+	if err := rpc.call(ctx, "txpool_contentFrom", []any{walletAddress}, &content); err != nil {
+		return nil, "", err
+	}
+
+	txs := make([]rpcPendingTx, 0, len(content.Pending))
+	for _, tx := range content.Pending {
+		txs = append(txs, tx)
+	}
+	return txs, pendingSourceTxPool, nil
+}
+
+func fetchPendingFromBlock(ctx context.Context, rpc *rpcClient, walletAddress string) ([]rpcPendingTx, string, error) {
+	var block *struct {
+		Transactions []rpcPendingTx `json:"transactions"`
+	}
+	if err := rpc.call(ctx, "eth_getBlockByNumber", []any{"pending", true}, &block); err != nil {
+		return nil, "", err
+	}
+	if block == nil {
+		return nil, "", errors.New("pending block not available")
+	}
+
+	wallet := strings.ToLower(walletAddress)
+	var txs []rpcPendingTx
+	for _, tx := range block.Transactions {
+		if strings.ToLower(tx.From) == wallet {
+			txs = append(txs, tx)
+		}
+	}
+	return txs, pendingSourceBlock, nil
+}
+
+type PendingRequest struct {
+	WalletAddress string `json:"wallet_address" description:"The Ethereum wallet address to inspect"`
+}
+
+func registerPending(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_pending", "Best-effort list of a wallet's pending (mempool) transactions; support depends on the RPC endpoint", trackCall(ctx, tracker, func(ctx context.Context, req PendingRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := tracker.GetPendingTransactions(ctx, wallet)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatPendingResponse(resp)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatPendingResponse(resp *PendingResponse) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallet Address: %s\nPending transactions known to the node: %d\n", resp.Address, resp.PendingCount))
+
+	if resp.Source == pendingSourceUnavailable {
+		builder.WriteString("Note: the RPC endpoint exposes neither txpool_contentFrom nor the pending block, so transaction details are unavailable.")
+		return builder.String()
+	}
+	if len(resp.Transactions) == 0 {
+		builder.WriteString("No pending transaction details available.")
+		return builder.String()
+	}
+
+	builder.WriteString(fmt.Sprintf("Transactions (source: %s):\n", resp.Source))
+	for _, tx := range resp.Transactions {
+		to := tx.To
+		if to == "" {
+			to = "contract creation"
+		}
+		builder.WriteString(fmt.Sprintf("- nonce %d: %s %s to %s (tx %s)\n", tx.Nonce, tx.Value, resp.NativeSymbol, to, tx.Hash))
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newRPCTestTracker(t *testing.T, handle func(method string, params []json.RawMessage) (string, *rpcError)) *WalletTracker {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int64             `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding rpc request: %v", err)
+			return
+		}
+		result, rpcErr := handle(req.Method, req.Params)
+		if rpcErr != nil {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":%d,"message":%q}}`, req.ID, rpcErr.Code, rpcErr.Message)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, result)
+	}))
+	t.Cleanup(srv.Close)
+
+	tracker, err := NewWalletTracker("key", WithRPCURL(srv.URL), WithRPCRetries(0))
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+	return tracker
+}
+
+func TestGetPendingTransactionsFallsBackToPendingBlock(t *testing.T) {
+	tracker := newRPCTestTracker(t, func(method string, params []json.RawMessage) (string, *rpcError) {
+		switch method {
+		case "eth_getTransactionCount":
+			if string(params[1]) == `"pending"` {
+				return `"0x7"`, nil
+			}
+			return `"0x5"`, nil
+		case "txpool_contentFrom":
+			return "", &rpcError{Code: -32601, Message: "the method txpool_contentFrom does not exist"}
+		case "eth_getBlockByNumber":
+			return fmt.Sprintf(`{"transactions":[
+				{"hash":"0xbb","nonce":"0x6","from":"%[1]s","to":"0x2222222222222222222222222222222222222222","value":"0xde0b6b3a7640000"},
+				{"hash":"0xcc","nonce":"0x1","from":"0x3333333333333333333333333333333333333333","to":"%[1]s","value":"0x0"},
+				{"hash":"0xaa","nonce":"0x5","from":"%[1]s","to":null,"value":"0x0"}
+			]}`, testWalletA), nil
+		}
+		return "", &rpcError{Code: -32601, Message: "unexpected method " + method}
+	})
+
+	resp, err := tracker.GetPendingTransactions(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetPendingTransactions returned error: %v", err)
+	}
+	if resp.PendingCount != 2 || resp.Source != pendingSourceBlock {
+		t.Fatalf("unexpected summary: count=%d source=%s", resp.PendingCount, resp.Source)
+	}
+	if len(resp.Transactions) != 2 || resp.Transactions[0].Hash != "0xaa" || resp.Transactions[1].Value != "1" {
+		t.Fatalf("unexpected transactions: %+v", resp.Transactions)
+	}
+	if content := formatPendingResponse(resp); !strings.Contains(content, "- nonce 6: 1 ETH to 0x2222") {
+		t.Fatalf("expected the value in the chain's native currency, got:\n%s", content)
+	}
+}
+
+func TestGetPendingTransactionsUnsupported(t *testing.T) {
+	tracker := newRPCTestTracker(t, func(method string, params []json.RawMessage) (string, *rpcError) {
+		if method == "eth_getTransactionCount" {
+			return `"0x1"`, nil
+		}
+		return "", &rpcError{Code: -32601, Message: "method not found"}
+	})
+
+	resp, err := tracker.GetPendingTransactions(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetPendingTransactions returned error: %v", err)
+	}
+	if resp.PendingCount != 0 || resp.Source != pendingSourceUnavailable || resp.Transactions == nil || len(resp.Transactions) != 0 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if content := formatPendingResponse(resp); !strings.Contains(content, "transaction details are unavailable") {
+		t.Fatalf("expected a note about the endpoint, got:\n%s", content)
+	}
+
+	withoutRPC, err := NewWalletTracker("key")
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+	if _, err := withoutRPC.GetPendingTransactions(context.Background(), testWalletA); !errors.Is(err, ErrPendingUnsupported) || !errors.Is(err, ErrRPCNotConfigured) {
+		t.Fatalf("expected ErrPendingUnsupported, got %v", err)
+	}
+
+	// The endpoint only serves mainnet, so another chain's pending
+	// transactions cannot be read from it.
+	tracker.chainID = 137
+	if _, err := tracker.GetPendingTransactions(context.Background(), testWalletA); !errors.Is(err, ErrPendingUnsupported) || !strings.Contains(err.Error(), "polygon") {
+		t.Fatalf("expected ErrPendingUnsupported off mainnet, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+const (
+	TierFree    = "free"
+	TierPro     = "pro"
+	TierUnknown = "unknown"
+)
+
+type PingResult struct {
+	Chain string `json:"chain"`
+	OK    bool   `json:"ok"`
+	// Latency is the round trip of the block number call; JSON carries it
+	// as LatencyMS, in whole milliseconds.
+	Latency     time.Duration `json:"-"`
+	LatencyMS   int64         `json:"latency_ms"`
+	BlockNumber uint64        `json:"block_number,omitempty"`
+	Tier        string        `json:"tier,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// Ping makes a minimal authenticated Etherscan call (the latest block number)
+// and reports whether it succeeded, how long it took, and the API tier of the
+// key. Failures are reported in the result rather than as an error.
+func (t *WalletTracker) Ping(ctx context.Context) PingResult {
+	result := PingResult{Chain: t.chain().Name}
+
+	provider := &etherscanBlockHeight{tracker: t, chainID: t.chainID}
+	start := time.Now()
+	height, err := provider.BlockHeight(ctx)
+	result.Latency = time.Since(start)
+	result.LatencyMS = result.Latency.Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.OK = true
+	result.BlockNumber = height
+	result.Tier = t.detectAPITier(ctx)
+	return result
+}
+
+// detectAPITier probes balancehistory, which Etherscan only serves to API Pro
+// keys; free keys get an explanatory "API Pro endpoint" error instead.
+func (t *WalletTracker) detectAPITier(ctx context.Context) string {
+	params := url.Values{}
+	params.Set("module", "account")
+	params.Set("action", "balancehistory")
+	params.Set("address", "0x0000000000000000000000000000000000000000")
+	params.Set("blockno", "1")
+
+	apiResp, err := t.queryEtherscan(ctx, t.chainID, params)
+	if err != nil {
+		return TierUnknown
+	}
+	if apiResp.Status == "1" {
+		return TierPro
+	}
+
+	var text string
+	if err := json.Unmarshal(apiResp.Result, &text); err == nil && strings.Contains(strings.ToLower(text), "api pro") {
+		return TierFree
+	}
+	return TierUnknown
+}
+
+type PingRequest struct{}
+
+func registerPing(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("ping", "Check connectivity to Etherscan and measure its latency", trackCall(ctx, tracker, func(ctx context.Context, req PingRequest) (*mcp_golang.ToolResponse, error) {
+		content := formatPingResult(tracker.Ping(ctx))
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatPingResult(result PingResult) string {
+	latency := result.Latency.Round(time.Millisecond)
+	if !result.OK {
+		return fmt.Sprintf("Etherscan (%s): unreachable after %s\nError: %s", result.Chain, latency, result.Error)
+	}
+	return fmt.Sprintf("Etherscan (%s): OK in %s\nLatest block: %d\nAPI tier: %s", result.Chain, latency, result.BlockNumber, result.Tier)
+}
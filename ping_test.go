@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("action") {
+		case "eth_blockNumber":
+			fmt.Fprint(w, `{"jsonrpc":"2.0","id":83,"result":"0x10"}`)
+		case "balancehistory":
+			fmt.Fprint(w, `{"status":"0","message":"NOTOK","result":"Sorry, it looks like you are trying to access an API Pro endpoint. Contact us to upgrade to API Pro."}`)
+		default:
+			t.Errorf("unexpected request: %s", r.URL.RawQuery)
+		}
+	})
+
+	result := tracker.Ping(context.Background())
+	if !result.OK || result.BlockNumber != 16 || result.Tier != TierFree || result.Chain != "ethereum" {
+		t.Fatalf("unexpected ping result: %+v", result)
+	}
+	if text := formatPingResult(result); !strings.Contains(text, "OK in") || !strings.Contains(text, "API tier: free") {
+		t.Fatalf("unexpected output:\n%s", text)
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("marshalling result: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"latency_ms":`) || strings.Contains(string(encoded), `"latency":`) {
+		t.Fatalf("expected the latency in milliseconds, got %s", encoded)
+	}
+}
+
+func TestPingReportsFailure(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"0","message":"NOTOK","result":"Invalid API Key"}`)
+	})
+
+	result := tracker.Ping(context.Background())
+	if result.OK || !strings.Contains(result.Error, "Invalid API Key") {
+		t.Fatalf("expected a failed ping naming the cause, got %+v", result)
+	}
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	coinGeckoBaseURL = "https://api.coingecko.com/api/v3"
+	// coinGeckoBatchSize bounds the contract addresses sent in one request to
+	// keep the URL a reasonable length.
+	coinGeckoBatchSize = 50
+	defaultPriceTTL    = time.Minute
+	usdDecimals        = 2
+	// maxPriceHistory is how far back historical prices are looked up:
+	// CoinGecko's public and demo plans serve the past 365 days only.
+	maxPriceHistory = 365 * 24 * time.Hour
+)
+
+// ErrHistoricalPricingUnsupported is returned by lookups that need past
+// prices when the PriceProvider is not a HistoricalPriceProvider.
+var ErrHistoricalPricingUnsupported = errors.New("the price provider has no historical prices")
+
+// PriceProvider looks up USD prices of tokens by contract address. Contracts
+// without a known price are left out of the result rather than failing the
+// lookup. Result keys are lowercase contract addresses.
+type PriceProvider interface {
+	TokenPrices(ctx context.Context, chain Chain, contracts []string) (map[string]*big.Rat, error)
+}
+
+// HistoricalPrice is a token's USD price at a past time.
+type HistoricalPrice struct {
+	USD  *big.Rat
+	Time time.Time
+}
+
+// HistoricalPriceProvider is implemented by PriceProviders that also serve
+// past prices. TokenPriceHistory returns a token's prices between from and
+// to, oldest first, at whatever granularity the source has; it returns none
+// for a token the source does not know.
+type HistoricalPriceProvider interface {
+	TokenPriceHistory(ctx context.Context, chain Chain, contract string, from, to time.Time) ([]HistoricalPrice, error)
+}
+
+// priceAt picks the price in history closest to at.
+func priceAt(history []HistoricalPrice, at time.Time) (HistoricalPrice, bool) {
+	var (
+		best    HistoricalPrice
+		bestGap time.Duration = -1
+	)
+	for _, price := range history {
+		gap := price.Time.Sub(at)
+		if gap < 0 {
+			gap = -gap
+		}
+		if bestGap < 0 || gap < bestGap {
+			best, bestGap = price, gap
+		}
+	}
+	return best, bestGap >= 0
+}
+
+// historicalPrices returns the tracker's PriceProvider as a
+// HistoricalPriceProvider, looking through the price cache, which only
+// caches current prices.
+func (t *WalletTracker) historicalPrices() (HistoricalPriceProvider, string, error) {
+	provider := t.prices
+	if cached, ok := provider.(*cachedPrices); ok {
+		provider = cached.provider
+	}
+	if provider == nil {
+		return nil, "", ErrPricingDisabled
+	}
+	history, ok := provider.(HistoricalPriceProvider)
+	if !ok {
+		return nil, "", ErrHistoricalPricingUnsupported
+	}
+	return history, priceSourceName(provider), nil
+}
+
+// PriceSourceCoinGecko is the PriceSource of prices from
+// NewCoinGeckoPriceProvider.
+const PriceSourceCoinGecko = "coingecko"
+
+// priceSourceName names p in TokenBalance.PriceSource: the result of its
+// Name method when it has one, "custom" otherwise.
+func priceSourceName(p PriceProvider) string {
+	if named, ok := p.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return "custom"
+}
+
+// priceQuote is a price together with where and when it was fetched.
+type priceQuote struct {
+	price     *big.Rat
+	source    string
+	fetchedAt time.Time
+}
+
+// coinGeckoPlatforms maps chain IDs to CoinGecko asset platform IDs.
+var coinGeckoPlatforms = map[int64]string{
+	1:     "ethereum",
+	10:    "optimistic-ethereum",
+	56:    "binance-smart-chain",
+	137:   "polygon-pos",
+	8453:  "base",
+	42161: "arbitrum-one",
+	43114: "avalanche",
+}
+
+type coinGeckoPrices struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewCoinGeckoPriceProvider returns a PriceProvider backed by CoinGecko's
+// simple/token_price endpoint. apiKey is an optional demo API key; without it
+// the public, more tightly rate-limited API is used.
+func NewCoinGeckoPriceProvider(apiKey string) PriceProvider {
+	return &coinGeckoPrices{
+		baseURL: coinGeckoBaseURL,
+		apiKey:  strings.TrimSpace(apiKey),
+		client:  &http.Client{Timeout: defaultHTTPTimeout},
+	}
+}
+
+func (p *coinGeckoPrices) Name() string {
+	return PriceSourceCoinGecko
+}
+
+func (p *coinGeckoPrices) TokenPrices(ctx context.Context, chain Chain, contracts []string) (map[string]*big.Rat, error) {
+	prices := make(map[string]*big.Rat)
+	platform, ok := coinGeckoPlatforms[chain.ID]
+	if !ok {
+		return prices, nil
+	}
+
+	for start := 0; start < len(contracts); start += coinGeckoBatchSize {
+		end := min(start+coinGeckoBatchSize, len(contracts))
+		if err := p.fetch(ctx, platform, contracts[start:end], prices); err != nil {
+			return nil, err
+		}
+	}
+	return prices, nil
+}
+
+func (p *coinGeckoPrices) fetch(ctx context.Context, platform string, contracts []string, prices map[string]*big.Rat) error {
+	query := url.Values{}
+	query.Set("contract_addresses", strings.ToLower(strings.Join(contracts, ",")))
+	query.Set("vs_currencies", "usd")
+	endpoint := fmt.Sprintf("%s/simple/token_price/%s?%s", p.baseURL, platform, query.Encode())
+
+	var result map[string]map[string]json.Number
+	if err := p.get(ctx, endpoint, &result); err != nil {
+		return err
+	}
+	for contract, quote := range result {
+		if price, ok := new(big.Rat).SetString(quote["usd"].String()); ok {
+			prices[strings.ToLower(contract)] = price
+		}
+	}
+	return nil
+}
+
+// TokenPriceHistory uses CoinGecko's market_chart/range endpoint, which
+// returns 5-minute points for ranges up to a day, hourly points up to 90 days
+// and daily points beyond.
+func (p *coinGeckoPrices) TokenPriceHistory(ctx context.Context, chain Chain, contract string, from, to time.Time) ([]HistoricalPrice, error) {
+	platform, ok := coinGeckoPlatforms[chain.ID]
+	if !ok {
+		return nil, nil
+	}
+
+	query := url.Values{}
+	query.Set("vs_currency", "usd")
+	query.Set("from", strconv.FormatInt(from.Unix(), 10))
+	query.Set("to", strconv.FormatInt(to.Unix(), 10))
+	endpoint := fmt.Sprintf("%s/coins/%s/contract/%s/market_chart/range?%s", p.baseURL, platform, strings.ToLower(contract), query.Encode())
+
+	var result struct {
+		Prices [][]json.Number `json:"prices"`
+	}
+	if err := p.get(ctx, endpoint, &result); err != nil {
+		if errors.Is(err, errCoinGeckoNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	history := make([]HistoricalPrice, 0, len(result.Prices))
+	for _, point := range result.Prices {
+		if len(point) != 2 {
+			continue
+		}
+		ms, err := point[0].Int64()
+		if err != nil {
+			continue
+		}
+		if usd, ok := new(big.Rat).SetString(point[1].String()); ok {
+			history = append(history, HistoricalPrice{USD: usd, Time: time.UnixMilli(ms).UTC()})
+		}
+	}
+	return history, nil
+}
+
+// errCoinGeckoNotFound is returned by get for a 404, which CoinGecko answers
+// for contracts it does not list.
+var errCoinGeckoNotFound = errors.New("not found on coingecko")
+
+// get fetches endpoint and decodes its JSON body into out, keeping numbers
+// as json.Number.
+func (p *coinGeckoPrices) get(ctx context.Context, endpoint string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("creating coingecko request: %w", err)
+	}
+	if p.apiKey != "" {
+		req.Header.Set("x-cg-demo-api-key", p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling coingecko: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errCoinGeckoNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("coingecko responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("decoding coingecko response: %w", err)
+	}
+	return nil
+}
+
+type priceCacheEntry struct {
+	// price is nil for a contract the provider had no price for; those are
+	// cached too so unpriced tokens do not trigger a lookup on every call.
+	price     *big.Rat
+	fetchedAt time.Time
+	expires   time.Time
+}
+
+// cachedPrices remembers prices, and their absence, for ttl so that repeated
+// wallet lookups do not hammer the price API.
+type cachedPrices struct {
+	provider PriceProvider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]priceCacheEntry
+}
+
+func newCachedPrices(provider PriceProvider, ttl time.Duration) *cachedPrices {
+	return &cachedPrices{
+		provider: provider,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]priceCacheEntry),
+	}
+}
+
+func (c *cachedPrices) Name() string {
+	return priceSourceName(c.provider)
+}
+
+func (c *cachedPrices) TokenPrices(ctx context.Context, chain Chain, contracts []string) (map[string]*big.Rat, error) {
+	quotes, err := c.quotes(ctx, chain, contracts)
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[string]*big.Rat, len(quotes))
+	for contract, quote := range quotes {
+		prices[contract] = quote.price
+	}
+	return prices, nil
+}
+
+// quotes is TokenPrices with each price's source and the time it was
+// fetched, which is earlier than now for a cached price.
+func (c *cachedPrices) quotes(ctx context.Context, chain Chain, contracts []string) (map[string]priceQuote, error) {
+	source := priceSourceName(c.provider)
+	prices := make(map[string]priceQuote, len(contracts))
+	var missing []string
+
+	c.mu.Lock()
+	now := c.now()
+	for _, contract := range contracts {
+		key := fmt.Sprintf("%d:%s", chain.ID, strings.ToLower(contract))
+		entry, ok := c.entries[key]
+		switch {
+		case !ok || !now.Before(entry.expires):
+			missing = append(missing, strings.ToLower(contract))
+		case entry.price != nil:
+			prices[strings.ToLower(contract)] = priceQuote{price: entry.price, source: source, fetchedAt: entry.fetchedAt}
+		}
+	}
+	c.mu.Unlock()
+
+	if len(missing) == 0 {
+		return prices, nil
+	}
+	fetched, err := c.provider.TokenPrices(ctx, chain, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fetchedAt := c.now()
+	expires := fetchedAt.Add(c.ttl)
+	for _, contract := range missing {
+		price := fetched[contract]
+		c.entries[fmt.Sprintf("%d:%s", chain.ID, contract)] = priceCacheEntry{price: price, fetchedAt: fetchedAt, expires: expires}
+		if price != nil {
+			prices[contract] = priceQuote{price: price, source: source, fetchedAt: fetchedAt}
+		}
+	}
+	return prices, nil
+}
+
+// priceQuotes looks up the prices of contracts with their attribution. Only
+// cachedPrices knows when a price was fetched; other providers are taken to
+// have fetched theirs just now.
+func (t *WalletTracker) priceQuotes(ctx context.Context, chain Chain, contracts []string) (map[string]priceQuote, error) {
+	if cached, ok := t.prices.(*cachedPrices); ok {
+		return cached.quotes(ctx, chain, contracts)
+	}
+	prices, err := t.prices.TokenPrices(ctx, chain, contracts)
+	if err != nil {
+		return nil, err
+	}
+	source, now := priceSourceName(t.prices), time.Now()
+	quotes := make(map[string]priceQuote, len(prices))
+	for contract, price := range prices {
+		quotes[contract] = priceQuote{price: price, source: source, fetchedAt: now}
+	}
+	return quotes, nil
+}
+
+// applyPrices fills in USDValue on every priced token, including the wrapped
+// native summary, with the price's PriceSource and PriceUpdatedAt, TotalUSD
+// as their sum, and each priced token's share of it. A failed lookup leaves
+// the response unpriced rather than failing it.
+func (t *WalletTracker) applyPrices(ctx context.Context, chain Chain, resp *WalletResponse) {
+	tokens := resp.pricedTokens()
+	if len(tokens) == 0 {
+		return
+	}
+
+	contracts := make([]string, len(tokens))
+	for i, token := range tokens {
+		contracts[i] = token.Address
+	}
+	quotes, err := t.priceQuotes(ctx, chain, contracts)
+	if err != nil {
+		t.logger.Warn("Price lookup failed", "address", resp.Address, "chain", chain.Name, "error", err)
+		return
+	}
+	resp.setUSDValues(quotes)
+}
+
+// pricedTokens returns the tokens USD values apply to: Tokens and the wrapped
+// native summary.
+func (r *WalletResponse) pricedTokens() []*TokenBalance {
+	tokens := make([]*TokenBalance, 0, len(r.Tokens)+1)
+	for i := range r.Tokens {
+		tokens = append(tokens, &r.Tokens[i])
+	}
+	if r.WrappedNative != nil {
+		tokens = append(tokens, r.WrappedNative)
+	}
+	return tokens
+}
+
+// setUSDValues values the response's tokens at quotes, keyed by lowercase
+// contract address, replacing any earlier valuation.
+func (r *WalletResponse) setUSDValues(quotes map[string]priceQuote) {
+	tokens := r.pricedTokens()
+	total := new(big.Rat)
+	priced := false
+	r.TotalUSD = ""
+	for _, token := range tokens {
+		token.USDValue, token.PortfolioPct, token.PriceSource, token.PriceUpdatedAt = "", "", "", nil
+		quote, ok := quotes[strings.ToLower(token.Address)]
+		if !ok {
+			continue
+		}
+		balance, ok := new(big.Rat).SetString(token.Balance)
+		if !ok {
+			continue
+		}
+		value := balance.Mul(balance, quote.price)
+		token.USDValue = value.FloatString(usdDecimals)
+		token.PriceSource = quote.source
+		updatedAt := quote.fetchedAt.UTC()
+		token.PriceUpdatedAt = &updatedAt
+		total.Add(total, value)
+		priced = true
+	}
+	if priced {
+		r.TotalUSD = total.FloatString(usdDecimals)
+		setPortfolioShares(tokens, total)
+	}
+}
+
+// setPortfolioShares sets PortfolioPct on every token with a USD value, as a
+// share of total. Unpriced tokens are not part of total and get none.
+func setPortfolioShares(tokens []*TokenBalance, total *big.Rat) {
+	if total.Sign() <= 0 {
+		return
+	}
+	hundred := big.NewRat(100, 1)
+	for _, token := range tokens {
+		token.PortfolioPct = ""
+		value, ok := new(big.Rat).SetString(token.USDValue)
+		if !ok {
+			continue
+		}
+		share := value.Mul(value, hundred)
+		token.PortfolioPct = share.Quo(share, total).FloatString(usdDecimals)
+	}
+}
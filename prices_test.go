@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakePriceProvider struct {
+	prices map[string]string
+	err    error
+	calls  atomic.Int32
+	asked  []string
+}
+
+func (f *fakePriceProvider) TokenPrices(ctx context.Context, chain Chain, contracts []string) (map[string]*big.Rat, error) {
+	f.calls.Add(1)
+	f.asked = append(f.asked, contracts...)
+	if f.err != nil {
+		return nil, f.err
+	}
+	prices := make(map[string]*big.Rat)
+	for _, contract := range contracts {
+		if raw, ok := f.prices[strings.ToLower(contract)]; ok {
+			prices[strings.ToLower(contract)], _ = new(big.Rat).SetString(raw)
+		}
+	}
+	return prices, nil
+}
+
+func TestCoinGeckoPriceProvider(t *testing.T) {
+	var gotPath, gotContracts, gotKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotContracts = r.URL.Query().Get("contract_addresses")
+		gotKey = r.Header.Get("x-cg-demo-api-key")
+		fmt.Fprint(w, `{"0xc0ffee0000000000000000000000000000000000":{"usd":1.0005},"0xbad0000000000000000000000000000000000000":{}}`)
+	}))
+	defer srv.Close()
+
+	provider := NewCoinGeckoPriceProvider("demo-key").(*coinGeckoPrices)
+	provider.baseURL = srv.URL
+	polygon, _ := LookupChain("polygon")
+
+	prices, err := provider.TokenPrices(context.Background(), polygon, []string{"0xC0FFEE0000000000000000000000000000000000", "0xbad0000000000000000000000000000000000000"})
+	if err != nil {
+		t.Fatalf("TokenPrices returned error: %v", err)
+	}
+	if gotPath != "/simple/token_price/polygon-pos" || gotKey != "demo-key" {
+		t.Fatalf("unexpected request: path %q, key %q", gotPath, gotKey)
+	}
+	if gotContracts != "0xc0ffee0000000000000000000000000000000000,0xbad0000000000000000000000000000000000000" {
+		t.Fatalf("unexpected contract_addresses %q", gotContracts)
+	}
+	if len(prices) != 1 || prices["0xc0ffee0000000000000000000000000000000000"].FloatString(4) != "1.0005" {
+		t.Fatalf("expected only the quoted token to be priced, got %v", prices)
+	}
+}
+
+func TestCoinGeckoTokenPriceHistory(t *testing.T) {
+	var gotPath, gotRange string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotRange = r.URL.Query().Get("vs_currency") + " " + r.URL.Query().Get("from") + "-" + r.URL.Query().Get("to")
+		if strings.Contains(r.URL.Path, "0xbad") {
+			http.Error(w, `{"error":"coin not found"}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"prices":[[1700000000000,1.25],[1700003600000,1.5]],"market_caps":[],"total_volumes":[]}`)
+	}))
+	defer srv.Close()
+
+	provider := NewCoinGeckoPriceProvider("").(*coinGeckoPrices)
+	provider.baseURL = srv.URL
+	polygon, _ := LookupChain("polygon")
+	from, to := time.Unix(1699990000, 0), time.Unix(1700010000, 0)
+
+	history, err := provider.TokenPriceHistory(context.Background(), polygon, "0xC0FFEE0000000000000000000000000000000000", from, to)
+	if err != nil {
+		t.Fatalf("TokenPriceHistory returned error: %v", err)
+	}
+	if gotPath != "/coins/polygon-pos/contract/0xc0ffee0000000000000000000000000000000000/market_chart/range" || gotRange != "usd 1699990000-1700010000" {
+		t.Fatalf("unexpected request: path %q, range %q", gotPath, gotRange)
+	}
+	if len(history) != 2 || history[1].USD.FloatString(2) != "1.50" || !history[1].Time.Equal(time.Unix(1700003600, 0)) {
+		t.Fatalf("unexpected history %+v", history)
+	}
+	if price, ok := priceAt(history, time.Unix(1700002000, 0)); !ok || price.USD.FloatString(2) != "1.50" {
+		t.Fatalf("expected the closest point, got %+v", price)
+	}
+
+	if history, err := provider.TokenPriceHistory(context.Background(), polygon, "0xbad0000000000000000000000000000000000000", from, to); err != nil || len(history) != 0 {
+		t.Fatalf("expected no history for an unknown token, got %v (err %v)", history, err)
+	}
+}
+
+func TestCachedPricesExpire(t *testing.T) {
+	provider := &fakePriceProvider{prices: map[string]string{"0xaaa": "2"}}
+	cache := newCachedPrices(provider, time.Minute)
+	now := time.Unix(1700000000, 0)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		prices, err := cache.TokenPrices(context.Background(), defaultChain, []string{"0xAAA", "0xbbb"})
+		if err != nil || len(prices) != 1 || prices["0xaaa"].RatString() != "2" {
+			t.Fatalf("unexpected prices %v (err %v)", prices, err)
+		}
+	}
+	if n := provider.calls.Load(); n != 1 {
+		t.Fatalf("expected priced and unpriced tokens to be cached, got %d provider calls", n)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := cache.TokenPrices(context.Background(), defaultChain, []string{"0xaaa"}); err != nil {
+		t.Fatalf("TokenPrices returned error: %v", err)
+	}
+	if n := provider.calls.Load(); n != 2 {
+		t.Fatalf("expected an expired entry to be fetched again, got %d provider calls", n)
+	}
+}
+
+func TestGetWalletTokensUSDValues(t *testing.T) {
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+			{"contractAddress":"0xc0ffee0000000000000000000000000000000000","tokenName":"Priced","tokenSymbol":"PRC","tokenDecimal":"0","value":"3","from":"0x3333333333333333333333333333333333333333","to":"%[1]s"},
+			{"contractAddress":"0xbad0000000000000000000000000000000000000","tokenName":"Unpriced","tokenSymbol":"UNP","tokenDecimal":"0","value":"5","from":"0x3333333333333333333333333333333333333333","to":"%[1]s"}]}`, testWalletA)
+	}))
+	provider := &fakePriceProvider{prices: map[string]string{"0xc0ffee0000000000000000000000000000000000": "1.5"}}
+	tracker.prices = provider
+
+	resp, err := tracker.GetWalletTokens(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetWalletTokens returned error: %v", err)
+	}
+	values := map[string]string{}
+	shares := map[string]string{}
+	for _, token := range resp.Tokens {
+		values[token.Symbol] = token.USDValue
+		shares[token.Symbol] = token.PortfolioPct
+	}
+	if values["PRC"] != "4.50" || values["UNP"] != "" || resp.TotalUSD != "4.50" {
+		t.Fatalf("unexpected valuation: %v, total %q", values, resp.TotalUSD)
+	}
+	if shares["PRC"] != "100.00" || shares["UNP"] != "" {
+		t.Fatalf("expected only the priced token to get a portfolio share, got %v", shares)
+	}
+	if text := formatWalletResponse(resp, formatOptions{}); !strings.Contains(text, "Priced (PRC): 3 ($4.50, 100.00%)") || !strings.Contains(text, "Total value of priced tokens: $4.50") {
+		t.Fatalf("expected USD values in the output, got:\n%s", text)
+	}
+
+	provider.err = errors.New("price api down")
+	resp, err = tracker.GetWalletTokens(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("expected a price failure not to fail the lookup, got %v", err)
+	}
+	if resp.TotalUSD != "" || len(resp.Tokens) != 2 {
+		t.Fatalf("expected an unpriced response, got %+v", resp)
+	}
+}
+
+// namedPriceProvider gives a fakePriceProvider a source name.
+type namedPriceProvider struct {
+	*fakePriceProvider
+}
+
+func (namedPriceProvider) Name() string { return "fake" }
+
+func TestGetWalletTokensPriceAttribution(t *testing.T) {
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+			{"contractAddress":"0xc0ffee0000000000000000000000000000000000","tokenName":"Priced","tokenSymbol":"PRC","tokenDecimal":"0","value":"3","from":"0x3333333333333333333333333333333333333333","to":"%[1]s"},
+			{"contractAddress":"0xbad0000000000000000000000000000000000000","tokenName":"Unpriced","tokenSymbol":"UNP","tokenDecimal":"0","value":"5","from":"0x3333333333333333333333333333333333333333","to":"%[1]s"}]}`, testWalletA)
+	}))
+	provider := namedPriceProvider{&fakePriceProvider{prices: map[string]string{"0xc0ffee0000000000000000000000000000000000": "1.5"}}}
+	cache := newCachedPrices(provider, time.Minute)
+	fetchedAt := time.Unix(1700000000, 0)
+	now := fetchedAt
+	cache.now = func() time.Time { return now }
+	tracker.prices = cache
+
+	for i := 0; i < 2; i++ {
+		resp, err := tracker.GetWalletTokens(context.Background(), testWalletA)
+		if err != nil {
+			t.Fatalf("GetWalletTokens returned error: %v", err)
+		}
+		for _, token := range resp.Tokens {
+			switch token.Symbol {
+			case "PRC":
+				// A cached price keeps the time it was fetched.
+				if token.PriceSource != "fake" || token.PriceUpdatedAt == nil || !token.PriceUpdatedAt.Equal(fetchedAt) {
+					t.Fatalf("expected attribution to the fake provider at %s, got %q %v", fetchedAt, token.PriceSource, token.PriceUpdatedAt)
+				}
+			case "UNP":
+				if token.PriceSource != "" || token.PriceUpdatedAt != nil {
+					t.Fatalf("expected no attribution on an unpriced token, got %+v", token)
+				}
+			}
+		}
+		now = now.Add(30 * time.Second)
+	}
+
+	tracker.prices = nil
+	resp, err := tracker.GetWalletTokens(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetWalletTokens returned error: %v", err)
+	}
+	if body, _ := json.Marshal(resp); strings.Contains(string(body), "price_") {
+		t.Fatalf("expected no attribution with pricing off, got %s", body)
+	}
+}
+
+func TestSetPortfolioShares(t *testing.T) {
+	tokens := []*TokenBalance{{USDValue: "30.00"}, {USDValue: "60.00"}, {}, {USDValue: "10.00"}}
+	setPortfolioShares(tokens, big.NewRat(100, 1))
+
+	want := []string{"30.00", "60.00", "", "10.00"}
+	for i, token := range tokens {
+		if token.PortfolioPct != want[i] {
+			t.Fatalf("token %d: got share %q, want %q", i, token.PortfolioPct, want[i])
+		}
+	}
+
+	zero := []*TokenBalance{{USDValue: "0.00"}}
+	setPortfolioShares(zero, new(big.Rat))
+	if zero[0].PortfolioPct != "" {
+		t.Fatalf("expected no share of a zero total, got %q", zero[0].PortfolioPct)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// portfolioGuidance asks the model for a risk and diversification review of
+// the holdings it follows.
+const portfolioGuidance = `Please analyze this portfolio:
+1. Concentration: which holdings dominate its value, and how exposed is the wallet to any single token?
+2. Diversification: how is it spread across stablecoins, the native currency, blue-chip tokens and long-tail tokens?
+3. Risk: flag tokens without a price, with small or unknown liquidity, or that look like spam, and note anything in the data that looks unreliable (approximate or truncated balances).
+4. Suggestions: what would make the portfolio more resilient, given the holdings above?
+Base the analysis only on the data above and say when it is insufficient to judge.`
+
+// PortfolioPromptRequest holds the analyze_portfolio arguments. mcp-golang
+// lists prompt arguments under their Go field names and reads descriptions
+// from the jsonschema tag, split at commas, so the fields carry no json tags
+// and no commas in their descriptions: clients pass WalletAddress and Chain.
+type PortfolioPromptRequest struct {
+	WalletAddress string `jsonschema:"required,description=The wallet address or ENS name whose portfolio to analyze; an EIP-681 URI (ethereum:0x...@137) analyzes it on another chain"`
+	Chain         string `jsonschema:"description=The chain to query by name (ethereum or polygon or ...) or chain ID; defaults to the server's configured chain"`
+}
+
+// registerPortfolioPrompt registers the analyze_portfolio prompt, which fills
+// a portfolio review request in with the wallet's holdings from wallets.
+func registerPortfolioPrompt(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker, wallets WalletService) error {
+	handler := trackCall(ctx, tracker, func(ctx context.Context, req PortfolioPromptRequest) (*mcp_golang.PromptResponse, error) {
+		wallet, chain, explicit, err := tracker.walletChainArg("WalletAddress", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+		if chain, err = tracker.chainArg("Chain", req.Chain, chain, explicit); err != nil {
+			return nil, err
+		}
+
+		walletResp, err := wallets.GetWalletTokens(ctx, wallet, OnChain(chain), SortTokens(SortByValue, true))
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatPortfolioPrompt(walletResp, chain)
+		return mcp_golang.NewPromptResponse(fmt.Sprintf("Portfolio analysis of %s on %s", walletResp.Address, chain.Name), mcp_golang.NewPromptMessage(mcp_golang.NewTextContent(content), mcp_golang.RoleUser)), nil
+	})
+
+	// mcp-golang derives the prompt's arguments from the handler's first
+	// parameter, so the handler cannot take the request context; calls are
+	// still bounded by the tool timeout.
+	return server.RegisterPrompt("analyze_portfolio", "Analyze a wallet's token holdings for risk and diversification", func(req PortfolioPromptRequest) (*mcp_golang.PromptResponse, error) {
+		return handler(ctx, req)
+	})
+}
+
+func formatPortfolioPrompt(resp *WalletResponse, chain Chain) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Here are the current holdings of a wallet on %s, largest value first:\n\n", chain.Name))
+	builder.WriteString(formatWalletResponse(resp, formatOptions{Labels: LabelContract, TrailingNewline: true}))
+	builder.WriteString("\n")
+
+	if len(resp.Tokens) == 0 && resp.WrappedNative == nil {
+		builder.WriteString("The wallet holds no tokens on this chain, so there is no token portfolio to analyze. ")
+		builder.WriteString("Please say so, comment on the native balance if there is one, and suggest checking other chains or addresses the owner may use.")
+		return builder.String()
+	}
+	builder.WriteString(portfolioGuidance)
+	return builder.String()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+func TestPortfolioPrompt(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected etherscan request %s", r.URL.RawQuery)
+	})
+	wallets := NewMockWalletTracker(map[string]*WalletResponse{
+		testWalletA: {Address: testWalletA, Tokens: []TokenBalance{
+			{Address: "0xc0ffee0000000000000000000000000000000000", Name: "Alpha", Symbol: "ALP", Balance: "1", RawBalance: "1", USDValue: "10"},
+			{Address: "0xbeef000000000000000000000000000000000000", Name: "Beta", Symbol: "BET", Balance: "5", RawBalance: "5", USDValue: "500"},
+		}},
+	})
+
+	httpTransport := newMCPHTTPTransport()
+	server := mcp_golang.NewServer(httpTransport)
+	if err := registerPortfolioPrompt(context.Background(), server, tracker, wallets); err != nil {
+		t.Fatalf("registerPortfolioPrompt returned error: %v", err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve returned error: %v", err)
+	}
+	srv := httptest.NewServer(httpTransport)
+	t.Cleanup(srv.Close)
+
+	_, body := postMCP(t, srv.URL, `{"jsonrpc":"2.0","id":0,"method":"prompts/list","params":{}}`)
+	if !strings.Contains(body, `"name":"WalletAddress"`) || !strings.Contains(body, `"required":true`) {
+		t.Fatalf("expected a required WalletAddress argument, got %s", body)
+	}
+
+	_, body = postMCP(t, srv.URL, `{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":{"name":"analyze_portfolio","arguments":{"WalletAddress":"`+testWalletA+`"}}}`)
+	if !strings.Contains(body, "Diversification") || !strings.Contains(body, `"role":"user"`) {
+		t.Fatalf("expected the analysis guidance, got %s", body)
+	}
+	if beta, alpha := strings.Index(body, "Beta (BET"), strings.Index(body, "Alpha (ALP"); beta < 0 || alpha < 0 || beta > alpha {
+		t.Fatalf("expected the holdings, largest value first, got %s", body)
+	}
+
+	_, body = postMCP(t, srv.URL, `{"jsonrpc":"2.0","id":2,"method":"prompts/get","params":{"name":"analyze_portfolio","arguments":{"WalletAddress":"`+testWalletB+`"}}}`)
+	if !strings.Contains(body, "holds no tokens") || strings.Contains(body, "Diversification") {
+		t.Fatalf("expected a note that the wallet has no tokens, got %s", body)
+	}
+
+	_, body = postMCP(t, srv.URL, `{"jsonrpc":"2.0","id":3,"method":"prompts/get","params":{"name":"analyze_portfolio","arguments":{"WalletAddress":"0x123"}}}`)
+	if !strings.Contains(body, "invalid ethereum address") {
+		t.Fatalf("expected an invalid address error, got %s", body)
+	}
+}
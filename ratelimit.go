@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces calls at least interval apart, in the order they ask. It
+// is shared by every lookup on a tracker, so concurrent tool calls, batches and
+// paginated histories together stay under the provider's calls-per-second
+// limit. A nil limiter never waits.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(rps int) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Second / time.Duration(rps)}
+}
+
+// wait blocks until the caller's slot. A caller that gives up keeps its slot
+// reserved, which only ever makes later calls slower, never faster.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterSerializesBursts(t *testing.T) {
+	const rps = 20
+	interval := time.Second / rps
+
+	var (
+		mu    sync.Mutex
+		times []time.Time
+	)
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+		w.Write([]byte(`{"status":"1","message":"OK","result":"0"}`))
+	})
+	tracker.limiter = newRateLimiter(rps)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			params := url.Values{"module": {"proxy"}, "action": {"eth_blockNumber"}}
+			if _, err := tracker.queryEtherscan(context.Background(), 1, params); err != nil {
+				t.Errorf("queryEtherscan returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(times) != 5 {
+		t.Fatalf("expected 5 calls, got %d", len(times))
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	// Allow for timer granularity; an unlimited burst lands within microseconds.
+	if spread := times[4].Sub(times[0]); spread < 4*interval-10*time.Millisecond {
+		t.Fatalf("expected the burst to be spread over %s, got %s", 4*interval, spread)
+	}
+}
+
+func TestRateLimiterWaitHonoursContext(t *testing.T) {
+	limiter := newRateLimiter(1)
+	if err := limiter.wait(context.Background()); err != nil {
+		t.Fatalf("first call should not wait, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := limiter.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to cut the wait short, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("expected wait to return when the context ended")
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	tracker, err := NewWalletTracker("key")
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+	if tracker.limiter == nil || tracker.limiter.interval != time.Second/defaultRateLimit {
+		t.Fatalf("expected a %d/s limiter by default, got %+v", defaultRateLimit, tracker.limiter)
+	}
+
+	if tracker, err = NewWalletTracker("key", WithRateLimit(0)); err != nil || tracker.limiter != nil {
+		t.Fatalf("expected zero to disable limiting, got %+v, %v", tracker.limiter, err)
+	}
+	if tracker, err = NewWalletTracker("key", WithRateLimit(-1)); err != nil || tracker.rateLimit != defaultRateLimit {
+		t.Fatalf("expected a negative rate to be ignored, got %d, %v", tracker.rateLimit, err)
+	}
+	if err := (*rateLimiter)(nil).wait(context.Background()); err != nil {
+		t.Fatalf("nil limiter should never wait, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+const (
+	walletResourceScheme   = "wallet://"
+	walletResourceTemplate = walletResourceScheme + "{chain}/{address}"
+)
+
+// JSON-RPC error codes for failed resource reads.
+const (
+	jsonRPCInvalidParams = -32602
+	jsonRPCInternalError = -32603
+)
+
+// ErrInvalidResourceURI is returned for a wallet resource URI that is not of
+// the form wallet://{chain}/{address}.
+var ErrInvalidResourceURI = errors.New("invalid wallet resource URI")
+
+// walletResourceArg parses a wallet://{chain}/{address} URI, where chain is a
+// name or chain ID and address a 0x address or an ENS name, returned
+// normalized for GetWalletTokens to resolve.
+func walletResourceArg(uri string) (string, Chain, error) {
+	rest, ok := strings.CutPrefix(uri, walletResourceScheme)
+	if !ok {
+		return "", Chain{}, fmt.Errorf("%w %q: expected %s", ErrInvalidResourceURI, uri, walletResourceTemplate)
+	}
+	chainPart, address, ok := strings.Cut(rest, "/")
+	if !ok || chainPart == "" || address == "" || strings.ContainsAny(address, "/?#") {
+		return "", Chain{}, fmt.Errorf("%w %q: expected %s", ErrInvalidResourceURI, uri, walletResourceTemplate)
+	}
+
+	chain, err := LookupChain(chainPart)
+	if err != nil {
+		return "", Chain{}, fmt.Errorf("%s: %w", uri, err)
+	}
+	if isENSName(address) {
+		return normalizeENSName(address), chain, nil
+	}
+	if err := ValidateAddress(address); err != nil {
+		return "", Chain{}, fmt.Errorf("%s: %w: expected 0x followed by 40 hex characters or an ENS name", uri, err)
+	}
+	return address, chain, nil
+}
+
+var _ transport.Transport = (*walletResourceTransport)(nil)
+
+// walletResourceTransport serves resources/read requests for wallet:// URIs
+// in front of the MCP server and passes every other message through.
+// mcp-golang only reads resources registered under an exact URI, and does not
+// tell their handlers which URI was read, so it cannot serve a template
+// itself; registerWalletResource lists the template for discovery.
+type walletResourceTransport struct {
+	transport.Transport
+	read func(context.Context, string) (*mcp_golang.ResourceResponse, error)
+}
+
+// newWalletResourceTransport wraps next, answering wallet resource reads with
+// wallets the way wallet_tracker does; tracker bounds the reads like tool
+// calls, and root cancels them.
+func newWalletResourceTransport(root context.Context, next transport.Transport, tracker *WalletTracker, wallets WalletService) *walletResourceTransport {
+	return &walletResourceTransport{
+		Transport: next,
+		read: trackCall(root, tracker, func(ctx context.Context, uri string) (*mcp_golang.ResourceResponse, error) {
+			return readWalletResource(ctx, wallets, uri)
+		}),
+	}
+}
+
+func (w *walletResourceTransport) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	w.Transport.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		if message.Type != transport.BaseMessageTypeJSONRPCRequestType || message.JsonRpcRequest.Method != "resources/read" {
+			handler(ctx, message)
+			return
+		}
+		var params struct {
+			URI string `json:"uri"`
+		}
+		if err := json.Unmarshal(message.JsonRpcRequest.Params, &params); err != nil || !strings.HasPrefix(params.URI, walletResourceScheme) {
+			handler(ctx, message)
+			return
+		}
+		// Reads call Etherscan, so answer them in the background like the
+		// server does tool calls.
+		go w.serveRead(ctx, message.JsonRpcRequest.Id, params.URI)
+	})
+}
+
+func (w *walletResourceTransport) serveRead(ctx context.Context, id transport.RequestId, uri string) {
+	resp, err := w.read(ctx, uri)
+	var result json.RawMessage
+	if err == nil {
+		result, err = json.Marshal(resp)
+	}
+	if err != nil {
+		code := jsonRPCInternalError
+		if errors.Is(err, ErrInvalidResourceURI) || errors.Is(err, ErrInvalidWalletAddress) || errors.Is(err, ErrUnsupportedChain) || errors.Is(err, ErrENSNameNotFound) {
+			code = jsonRPCInvalidParams
+		}
+		w.Send(ctx, transport.NewBaseMessageError(&transport.BaseJSONRPCError{
+			Jsonrpc: "2.0",
+			Id:      id,
+			Error:   transport.BaseJSONRPCErrorInner{Code: code, Message: err.Error()},
+		}))
+		return
+	}
+	w.Send(ctx, transport.NewBaseMessageResponse(&transport.BaseJSONRPCResponse{Jsonrpc: "2.0", Id: id, Result: result}))
+}
+
+// readWalletResource returns the wallet's balances on the URI's chain as the
+// JSON WalletResponse, as wallet_tracker does with format=json.
+func readWalletResource(ctx context.Context, wallets WalletService, uri string) (*mcp_golang.ResourceResponse, error) {
+	wallet, chain, err := walletResourceArg(uri)
+	if err != nil {
+		return nil, err
+	}
+	walletResp, err := wallets.GetWalletTokens(ctx, wallet, OnChain(chain))
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.MarshalIndent(walletResp, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding wallet response: %w", err)
+	}
+	return mcp_golang.NewResourceResponse(mcp_golang.NewTextEmbeddedResource(uri, string(encoded), "application/json")), nil
+}
+
+// registerWalletResource lists the wallet://{chain}/{address} template, whose
+// reads are served by walletResourceTransport.
+func registerWalletResource(server *mcp_golang.Server) error {
+	return server.RegisterResourceTemplate(walletResourceTemplate, "wallet", "Token balances of a wallet on a chain (a name such as polygon, or a chain ID), as the wallet_tracker JSON response", "application/json")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+func TestWalletResourceArg(t *testing.T) {
+	wallet, chain, err := walletResourceArg("wallet://polygon/" + testWalletA)
+	if err != nil || wallet != testWalletA || chain.ID != 137 {
+		t.Fatalf("got %q on %+v, %v", wallet, chain, err)
+	}
+	if wallet, chain, err = walletResourceArg("wallet://1/Vitalik.ETH"); err != nil || wallet != "vitalik.eth" || chain.ID != 1 {
+		t.Fatalf("expected a normalized ENS name on mainnet, got %q on %+v, %v", wallet, chain, err)
+	}
+
+	for uri, want := range map[string]error{
+		"wallet://polygon":                       ErrInvalidResourceURI,
+		"wallet:///" + testWalletA:               ErrInvalidResourceURI,
+		"wallet://polygon/" + testWalletA + "/x": ErrInvalidResourceURI,
+		"https://polygon/" + testWalletA:         ErrInvalidResourceURI,
+		"wallet://dogechain/" + testWalletA:      ErrUnsupportedChain,
+		"wallet://polygon/0x123":                 ErrInvalidWalletAddress,
+	} {
+		if _, _, err := walletResourceArg(uri); !errors.Is(err, want) {
+			t.Errorf("%s: got %v, want %v", uri, err, want)
+		}
+	}
+}
+
+func TestWalletResourceRead(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected etherscan request %s", r.URL.RawQuery)
+	})
+	wallets := NewMockWalletTracker(map[string]*WalletResponse{
+		testWalletA: {Address: testWalletA, Tokens: []TokenBalance{{Address: "0xc0ffee0000000000000000000000000000000000", Name: "Test", Symbol: "TST", Balance: "3"}}},
+	})
+
+	httpTransport := newMCPHTTPTransport()
+	server := mcp_golang.NewServer(newWalletResourceTransport(context.Background(), httpTransport, tracker, wallets))
+	if err := registerWalletResource(server); err != nil {
+		t.Fatalf("registerWalletResource returned error: %v", err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve returned error: %v", err)
+	}
+	srv := httptest.NewServer(httpTransport)
+	t.Cleanup(srv.Close)
+
+	_, body := postMCP(t, srv.URL, `{"jsonrpc":"2.0","id":1,"method":"resources/templates/list","params":{}}`)
+	if !strings.Contains(body, `"uriTemplate":"wallet://{chain}/{address}"`) {
+		t.Fatalf("expected the wallet template to be listed, got %s", body)
+	}
+
+	uri := "wallet://polygon/" + testWalletA
+	_, body = postMCP(t, srv.URL, `{"jsonrpc":"2.0","id":2,"method":"resources/read","params":{"uri":"`+uri+`"}}`)
+	var read struct {
+		ID     int `json:"id"`
+		Result struct {
+			Contents []struct {
+				URI      string `json:"uri"`
+				MimeType string `json:"mimeType"`
+				Text     string `json:"text"`
+			} `json:"contents"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(body), &read); err != nil {
+		t.Fatalf("decoding response %s: %v", body, err)
+	}
+	if read.ID != 2 || len(read.Result.Contents) != 1 || read.Result.Contents[0].URI != uri || read.Result.Contents[0].MimeType != "application/json" {
+		t.Fatalf("unexpected resources/read response: %s", body)
+	}
+	var walletResp WalletResponse
+	if err := json.Unmarshal([]byte(read.Result.Contents[0].Text), &walletResp); err != nil {
+		t.Fatalf("expected a WalletResponse, got %q: %v", read.Result.Contents[0].Text, err)
+	}
+	if len(walletResp.Tokens) != 1 || walletResp.Tokens[0].Symbol != "TST" {
+		t.Fatalf("unexpected wallet response %+v", walletResp)
+	}
+
+	_, body = postMCP(t, srv.URL, `{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"wallet://polygon/0x123"}}`)
+	var failed struct {
+		ID    int `json:"id"`
+		Error struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(body), &failed); err != nil {
+		t.Fatalf("decoding response %s: %v", body, err)
+	}
+	if failed.ID != 3 || failed.Error.Code != jsonRPCInvalidParams || !strings.Contains(failed.Error.Message, "0x123") {
+		t.Fatalf("expected an invalid-params error for a bad address, got %s", body)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// RiskHigh is the Risk of an approval to a known drainer.
+const RiskHigh = "high"
+
+// DrainerList holds the addresses of known malicious spenders, such as
+// wallet drainer contracts. It is safe for concurrent use, and Set replaces
+// the list while the server runs, e.g. from a periodically refreshed feed.
+type DrainerList struct {
+	mu        sync.RWMutex
+	addresses map[string]bool
+}
+
+// NewDrainerList returns a DrainerList holding addresses.
+func NewDrainerList(addresses []string) *DrainerList {
+	l := &DrainerList{}
+	l.Set(addresses)
+	return l
+}
+
+// Set replaces the list with addresses. Blank entries are ignored.
+func (l *DrainerList) Set(addresses []string) {
+	set := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		if address = strings.ToLower(strings.TrimSpace(address)); address != "" {
+			set[address] = true
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.addresses = set
+}
+
+// Contains reports whether address is on the list.
+func (l *DrainerList) Contains(address string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.addresses[strings.ToLower(address)]
+}
+
+// Len returns how many addresses are on the list.
+func (l *DrainerList) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.addresses)
+}
+
+// RiskyApproval is an outstanding approval flagged by GetWalletRisk.
+type RiskyApproval struct {
+	Approval
+	Risk   string `json:"risk"`
+	Reason string `json:"reason"`
+}
+
+type WalletRiskResponse struct {
+	Address string `json:"address"`
+	// Risky lists the flagged approvals, unlimited ones first and then
+	// newest first, the order to revoke them in.
+	Risky []RiskyApproval `json:"risky"`
+	// Approvals is how many outstanding approvals were checked, and
+	// Drainers how many known drainers they were checked against.
+	Approvals int `json:"approvals"`
+	Drainers  int `json:"drainers"`
+	// Truncated is passed on from GetApprovals: approvals may be missing.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// GetWalletRisk checks the wallet's outstanding ERC-20 approvals against the
+// tracker's DrainerList and flags every approval to a known drainer as high
+// risk. Approvals to other spenders are not flagged, so an empty result is
+// only as good as the list.
+func (t *WalletTracker) GetWalletRisk(ctx context.Context, walletAddress string) (*WalletRiskResponse, error) {
+	approvals, err := t.GetApprovals(ctx, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &WalletRiskResponse{
+		Address:   walletAddress,
+		Risky:     []RiskyApproval{},
+		Approvals: len(approvals.Approvals),
+		Drainers:  t.drainers.Len(),
+		Truncated: approvals.Truncated,
+	}
+	for _, approval := range approvals.Approvals {
+		if t.drainers.Contains(approval.Spender) {
+			resp.Risky = append(resp.Risky, RiskyApproval{
+				Approval: approval,
+				Risk:     RiskHigh,
+				Reason:   "spender is a known drainer",
+			})
+		}
+	}
+	return resp, nil
+}
+
+type WalletRiskRequest struct {
+	WalletAddress string `json:"wallet_address" description:"The cryptocurrency wallet address whose approvals to check"`
+}
+
+func registerWalletRisk(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_risk", "Flag a wallet's token approvals to known drainer contracts, which can empty it and should be revoked", trackCall(ctx, tracker, func(ctx context.Context, req WalletRiskRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := tracker.GetWalletRisk(ctx, wallet)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatWalletRiskResponse(resp)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatWalletRiskResponse(resp *WalletRiskResponse) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallet Address: %s\n", resp.Address))
+	builder.WriteString(fmt.Sprintf("Checked %d outstanding approval(s) against %d known drainer(s).\n", resp.Approvals, resp.Drainers))
+	if resp.Drainers == 0 {
+		builder.WriteString("Warning: the drainer list is empty, so no approval can be flagged; set DRAINER_BLOCKLIST.\n")
+	}
+	if len(resp.Risky) == 0 {
+		builder.WriteString("No approvals to known drainers found.\n")
+	} else {
+		builder.WriteString("High-risk approvals (revoke these first):\n")
+	}
+	for _, risky := range resp.Risky {
+		token := tokenLabel(TokenBalance{Address: risky.Token, Name: risky.TokenName, Symbol: risky.TokenSymbol}, LabelContract)
+		amount := risky.Allowance
+		if risky.Unlimited {
+			amount = "UNLIMITED"
+		}
+		builder.WriteString(fmt.Sprintf("- [%s] %s: %s may spend %s; %s (approved %s, tx %s)\n",
+			strings.ToUpper(risky.Risk), token, risky.Spender, amount, risky.Reason, risky.Timestamp.Format(time.RFC3339), risky.Hash))
+	}
+	if resp.Truncated {
+		builder.WriteString("Warning: approval history truncated; the most recent approvals may be missing.\n")
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetWalletRisk(t *testing.T) {
+	const (
+		token   = "0xc0ffee0000000000000000000000000000000000"
+		drainer = "0x3333333333333333333333333333333333333333"
+		dex     = "0x5555555555555555555555555555555555555555"
+	)
+	topic := func(address string) string {
+		return "0x" + strings.Repeat("0", 24) + address[2:]
+	}
+	approval := func(spender, data, block string) string {
+		return fmt.Sprintf(`{"address":"%s","topics":["%s","%s","%s"],"data":"%s","blockNumber":"%s","timeStamp":"0x65000000","transactionHash":"0x%s"}`,
+			token, erc20ApprovalTopic, topic(testWalletA), topic(spender), data, block, strings.TrimPrefix(block, "0x"))
+	}
+
+	drainers := NewDrainerList(nil)
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("action") != "getLogs" {
+			fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[%s,%s]}`,
+			approval(drainer, "0x"+strings.Repeat("f", 64), "0x10"),
+			approval(dex, "0x64", "0x11"))
+	})
+	WithDrainerList(drainers)(tracker)
+
+	resp, err := tracker.GetWalletRisk(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetWalletRisk returned error: %v", err)
+	}
+	if resp.Approvals != 2 || resp.Drainers != 0 || len(resp.Risky) != 0 {
+		t.Fatalf("expected nothing flagged against an empty list, got %+v", resp)
+	}
+
+	// The list is updated in place, with addresses matched case-insensitively.
+	drainers.Set([]string{" " + strings.ToUpper(drainer) + " ", ""})
+	resp, err = tracker.GetWalletRisk(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetWalletRisk returned error: %v", err)
+	}
+	if resp.Drainers != 1 || len(resp.Risky) != 1 {
+		t.Fatalf("expected the approval to the drainer to be flagged, got %+v", resp.Risky)
+	}
+	if got := resp.Risky[0]; got.Spender != drainer || got.Risk != RiskHigh || !got.Unlimited {
+		t.Fatalf("unexpected risky approval %+v", got)
+	}
+
+	content := formatWalletRiskResponse(resp)
+	if !strings.Contains(content, "[HIGH] ") || !strings.Contains(content, drainer+" may spend UNLIMITED") || strings.Contains(content, dex) {
+		t.Fatalf("unexpected output:\n%s", content)
+	}
+}
+
+func TestWalletRiskEmptyDrainerList(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"0","message":"No records found","result":[]}`)
+	})
+
+	resp, err := tracker.GetWalletRisk(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetWalletRisk returned error: %v", err)
+	}
+	if content := formatWalletRiskResponse(resp); !strings.Contains(content, "drainer list is empty") {
+		t.Fatalf("expected a warning about the empty list, got:\n%s", content)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultRPCTimeout      = 5 * time.Second
+	defaultRPCRetries      = 2
+	defaultRPCRetryBackoff = 250 * time.Millisecond
+)
+
+var ErrRPCNotConfigured = errors.New("no JSON-RPC endpoint configured")
+
+// rpcClient talks to an Ethereum JSON-RPC endpoint. It is configured
+// independently of the Etherscan client so the two integrations can be tuned
+// separately.
+type rpcClient struct {
+	url     string
+	client  *http.Client
+	retries int
+	backoff time.Duration
+	nextID  atomic.Int64
+}
+
+func newRPCClient(url string, timeout time.Duration, retries int) *rpcClient {
+	return &rpcClient{
+		url:     url,
+		client:  &http.Client{Timeout: timeout},
+		retries: retries,
+		backoff: defaultRPCRetryBackoff,
+	}
+}
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int64  `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// retryableRPCError marks transport-level failures worth another attempt.
+type retryableRPCError struct {
+	err error
+}
+
+func (e *retryableRPCError) Error() string { return e.err.Error() }
+func (e *retryableRPCError) Unwrap() error { return e.err }
+
+// call invokes method with params and decodes the result into out. Network
+// errors, 429s and 5xx responses are retried; JSON-RPC errors are not.
+func (c *rpcClient) call(ctx context.Context, method string, params []any, out any) error {
+	if params == nil {
+		params = []any{}
+	}
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      c.nextID.Add(1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding rpc request: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(c.backoff * time.Duration(attempt)):
+			}
+		}
+
+		lastErr = c.do(ctx, body, out)
+		var retryable *retryableRPCError
+		if lastErr == nil || !errors.As(lastErr, &retryable) || ctx.Err() != nil {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+func (c *rpcClient) do(ctx context.Context, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating rpc request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return &retryableRPCError{fmt.Errorf("calling rpc endpoint: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("rpc endpoint responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return &retryableRPCError{err}
+		}
+		return err
+	}
+
+	var rpcResp rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("decoding rpc response: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(rpcResp.Result, out); err != nil {
+		return fmt.Errorf("decoding rpc result: %w", err)
+	}
+	return nil
+}
+
+// parseHexBig decodes a 0x-prefixed JSON-RPC quantity.
+func parseHexBig(raw string) (*big.Int, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(raw, "0x"), "0X")
+	if digits == "" {
+		return big.NewInt(0), nil
+	}
+	value, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid hex quantity %q", raw)
+	}
+	return value, nil
+}
+
+func parseHexUint64(raw string) (uint64, error) {
+	value, err := parseHexBig(raw)
+	if err != nil {
+		return 0, err
+	}
+	if !value.IsUint64() {
+		return 0, fmt.Errorf("hex quantity %q overflows uint64", raw)
+	}
+	return value.Uint64(), nil
+}
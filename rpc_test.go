@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRPCClientRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "unavailable", http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x10"}`)
+	}))
+	defer srv.Close()
+
+	client := newRPCClient(srv.URL, time.Second, 2)
+	client.backoff = time.Millisecond
+
+	var result string
+	if err := client.call(context.Background(), "eth_blockNumber", nil, &result); err != nil {
+		t.Fatalf("call returned error: %v", err)
+	}
+	if result != "0x10" || calls.Load() != 3 {
+		t.Fatalf("expected result 0x10 after 3 calls, got %q after %d", result, calls.Load())
+	}
+}
+
+func TestRPCClientDoesNotRetryRPCErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`)
+	}))
+	defer srv.Close()
+
+	client := newRPCClient(srv.URL, time.Second, 2)
+	client.backoff = time.Millisecond
+
+	err := client.call(context.Background(), "txpool_content", nil, nil)
+	var rpcErr *rpcError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Fatalf("expected rpc error -32601, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected a single call, got %d", calls.Load())
+	}
+}
+
+func TestRPCOptionsAreIndependentOfEtherscan(t *testing.T) {
+	tracker, err := NewWalletTracker("key", WithRPCURL("http://localhost:8545"), WithRPCTimeout(3*time.Second), WithRPCRetries(0))
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+	if tracker.rpc.client.Timeout != 3*time.Second || tracker.rpc.retries != 0 {
+		t.Fatalf("unexpected rpc settings: timeout=%s retries=%d", tracker.rpc.client.Timeout, tracker.rpc.retries)
+	}
+	if tracker.client.Timeout != defaultHTTPTimeout {
+		t.Fatalf("etherscan timeout changed to %s", tracker.client.Timeout)
+	}
+
+	plain, _ := NewWalletTracker("key")
+	if _, err := plain.requireRPC(); !errors.Is(err, ErrRPCNotConfigured) {
+		t.Fatalf("expected ErrRPCNotConfigured, got %v", err)
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMissingAPIKey is returned by Config.Validate when no Etherscan API key is
+// configured and the server is not in mock mode.
+var ErrMissingAPIKey = errors.New("an Etherscan API key is required (ETHERSCAN_API_KEY or api_key)")
+
+// Duration is a time.Duration written as a Go duration string, such as "30s",
+// in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Config is the server's configuration. LoadConfig reads it from a JSON file,
+// LoadFromEnvironment overrides it from environment variables, and Options
+// turns it into tracker options. Fields left unset keep the defaults of
+// DefaultConfig.
+type Config struct {
+	APIKey           string   `json:"api_key,omitempty"`
+	Chain            string   `json:"chain,omitempty"`
+	BaseURL          string   `json:"base_url,omitempty"`
+	HTTPTimeout      Duration `json:"http_timeout"`
+	ToolTimeout      Duration `json:"tool_timeout"`
+	RateLimit        int      `json:"rate_limit"`
+	MaxResponseBytes int64    `json:"max_response_bytes"`
+	CacheTTL         Duration `json:"cache_ttl"`
+	RPCURL           string   `json:"rpc_url,omitempty"`
+	BalanceStrategy  string   `json:"balance_strategy,omitempty"`
+	PriceProvider    string   `json:"price_provider,omitempty"`
+	CoinGeckoAPIKey  string   `json:"coingecko_api_key,omitempty"`
+	SpamBlocklist    []string `json:"spam_blocklist,omitempty"`
+	DrainerBlocklist []string `json:"drainer_blocklist,omitempty"`
+	CORSOrigins      []string `json:"cors_origins,omitempty"`
+	LogLevel         string   `json:"log_level,omitempty"`
+	LogFormat        string   `json:"log_format,omitempty"`
+	// ListenAddr, when set, also serves the HTTP API on that address.
+	ListenAddr string `json:"listen_addr,omitempty"`
+	// MCPTransport is how MCP clients connect: stdio (the default) or http,
+	// which serves MCP at /mcp on MCPAddr for hosted deployments.
+	MCPTransport string `json:"mcp_transport,omitempty"`
+	MCPAddr      string `json:"mcp_addr,omitempty"`
+	// WebhookURL, when set, receives a POST with the changes of every wallet
+	// in MonitorWallets, polled every MonitorInterval (see Monitor).
+	WebhookURL      string   `json:"webhook_url,omitempty"`
+	MonitorWallets  []string `json:"monitor_wallets,omitempty"`
+	MonitorInterval Duration `json:"monitor_interval"`
+	// Fixtures, when set, serves wallet_tracker from a fixtures file (see
+	// LoadWalletFixtures), and the API key becomes optional.
+	Fixtures string `json:"fixtures,omitempty"`
+}
+
+// DefaultConfig returns the configuration used for anything a file or the
+// environment does not set.
+func DefaultConfig() Config {
+	return Config{
+		Chain:            defaultChain.Name,
+		HTTPTimeout:      Duration(defaultHTTPTimeout),
+		ToolTimeout:      Duration(defaultToolTimeout),
+		RateLimit:        defaultRateLimit,
+		MaxResponseBytes: defaultMaxResponseBytes,
+		CacheTTL:         Duration(defaultWalletCacheTTL),
+		BalanceStrategy:  string(BalanceFromTransfers),
+		PriceProvider:    "coingecko",
+		MCPTransport:     TransportStdio,
+		MCPAddr:          defaultMCPAddr,
+		MonitorInterval:  Duration(defaultMonitorInterval),
+	}
+}
+
+// LoadConfig reads a JSON configuration file over DefaultConfig. An empty
+// path returns the defaults.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// LoadFromEnvironment overrides c with the environment variables that are
+// set and not empty, so the environment wins over a config file.
+func (c *Config) LoadFromEnvironment() error {
+	text := map[string]*string{
+		"ETHERSCAN_API_KEY":   &c.APIKey,
+		"ETHERSCAN_CHAIN_ID":  &c.Chain,
+		"ETHERSCAN_BASE_URL":  &c.BaseURL,
+		"ETH_RPC_URL":         &c.RPCURL,
+		"BALANCE_STRATEGY":    &c.BalanceStrategy,
+		"PRICE_PROVIDER":      &c.PriceProvider,
+		"COINGECKO_API_KEY":   &c.CoinGeckoAPIKey,
+		"LOG_LEVEL":           &c.LogLevel,
+		"LOG_FORMAT":          &c.LogFormat,
+		"WALLET_TRACKER_ADDR": &c.ListenAddr,
+		"WALLET_FIXTURES":     &c.Fixtures,
+		"MCP_TRANSPORT":       &c.MCPTransport,
+		"MCP_ADDR":            &c.MCPAddr,
+		"WEBHOOK_URL":         &c.WebhookURL,
+	}
+	for name, field := range text {
+		if raw := os.Getenv(name); raw != "" {
+			*field = raw
+		}
+	}
+
+	lists := map[string]*[]string{
+		"SPAM_BLOCKLIST":       &c.SpamBlocklist,
+		"DRAINER_BLOCKLIST":    &c.DrainerBlocklist,
+		"CORS_ALLOWED_ORIGINS": &c.CORSOrigins,
+		"MONITOR_WALLETS":      &c.MonitorWallets,
+	}
+	for name, field := range lists {
+		if raw := os.Getenv(name); raw != "" {
+			*field = strings.Split(raw, ",")
+		}
+	}
+
+	durations := map[string]*Duration{
+		"ETHERSCAN_TIMEOUT": &c.HTTPTimeout,
+		"TOOL_TIMEOUT":      &c.ToolTimeout,
+		"WALLET_CACHE_TTL":  &c.CacheTTL,
+		"MONITOR_INTERVAL":  &c.MonitorInterval,
+	}
+	for name, field := range durations {
+		if raw := os.Getenv(name); raw != "" {
+			d, err := time.ParseDuration(raw)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			*field = Duration(d)
+		}
+	}
+
+	if raw := os.Getenv("ETHERSCAN_MAX_RESPONSE_BYTES"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("ETHERSCAN_MAX_RESPONSE_BYTES: want a positive number of bytes, got %q", raw)
+		}
+		c.MaxResponseBytes = n
+	}
+	if raw := os.Getenv("ETHERSCAN_RATE_LIMIT"); raw != "" {
+		rps, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("ETHERSCAN_RATE_LIMIT: want a non-negative number of calls per second, got %q", raw)
+		}
+		c.RateLimit = rps
+	}
+	return nil
+}
+
+// Validate checks c before the server starts, so a bad setting fails at
+// startup rather than on the first request. The base URL is checked by
+// NewWalletTracker.
+func (c Config) Validate() error {
+	if strings.TrimSpace(c.APIKey) == "" && c.Fixtures == "" {
+		return ErrMissingAPIKey
+	}
+	if _, err := LookupChain(c.Chain); err != nil {
+		return fmt.Errorf("chain: %w", err)
+	}
+	if _, err := ParseBalanceStrategy(c.BalanceStrategy); err != nil {
+		return fmt.Errorf("balance_strategy: %w", err)
+	}
+	switch c.PriceProvider {
+	case "", "coingecko", "none":
+	default:
+		return fmt.Errorf("price_provider: unsupported provider %q (want coingecko or none)", c.PriceProvider)
+	}
+	switch c.MCPTransport {
+	case "", TransportStdio, TransportHTTP:
+	default:
+		return fmt.Errorf("mcp_transport: unsupported transport %q (want stdio or http)", c.MCPTransport)
+	}
+	if c.RateLimit < 0 {
+		return fmt.Errorf("rate_limit: want a non-negative number of calls per second, got %d", c.RateLimit)
+	}
+	if c.MaxResponseBytes <= 0 {
+		return fmt.Errorf("max_response_bytes: want a positive number of bytes, got %d", c.MaxResponseBytes)
+	}
+	if c.HTTPTimeout < 0 || c.ToolTimeout < 0 || c.CacheTTL < 0 {
+		return errors.New("http_timeout, tool_timeout and cache_ttl must not be negative")
+	}
+	if c.WebhookURL != "" {
+		if err := validateWebhookURL(c.WebhookURL); err != nil {
+			return fmt.Errorf("webhook_url: %w", err)
+		}
+	}
+	if len(c.MonitorWallets) > 0 && c.WebhookURL == "" {
+		return errors.New("monitor_wallets: set webhook_url to receive their changes")
+	}
+	for _, wallet := range c.MonitorWallets {
+		wallet = strings.TrimSpace(wallet)
+		if !isENSName(wallet) && ValidateAddress(wallet) != nil {
+			return fmt.Errorf("monitor_wallets: %w: %q", ErrInvalidWalletAddress, wallet)
+		}
+	}
+	if c.MonitorInterval <= 0 {
+		return fmt.Errorf("monitor_interval: want a positive duration, got %s", time.Duration(c.MonitorInterval))
+	}
+	if _, err := newLogger(c.LogLevel, c.LogFormat); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Options returns the tracker options for c, which must be valid. Logging is
+// left to the caller, which also routes the standard log package through it.
+func (c Config) Options() []Option {
+	chain, _ := LookupChain(c.Chain)
+	strategy, _ := ParseBalanceStrategy(c.BalanceStrategy)
+
+	opts := []Option{
+		WithChainID(chain.ID),
+		WithHTTPTimeout(time.Duration(c.HTTPTimeout)),
+		WithToolTimeout(time.Duration(c.ToolTimeout)),
+		WithRateLimit(c.RateLimit),
+		WithMaxResponseBytes(c.MaxResponseBytes),
+		WithBalanceStrategy(strategy),
+	}
+	if c.BaseURL != "" {
+		opts = append(opts, WithBaseURL(c.BaseURL))
+	}
+	if c.RPCURL != "" {
+		opts = append(opts, WithRPCURL(c.RPCURL))
+	}
+	if c.CacheTTL > 0 {
+		opts = append(opts, WithCache(NewTTLCache(time.Duration(c.CacheTTL))))
+	}
+	if len(c.SpamBlocklist) > 0 {
+		opts = append(opts, WithSpamFilter(NewSpamFilter(c.SpamBlocklist)))
+	}
+	if len(c.DrainerBlocklist) > 0 {
+		opts = append(opts, WithDrainerList(NewDrainerList(c.DrainerBlocklist)))
+	}
+	if c.PriceProvider != "none" {
+		opts = append(opts, WithPriceProvider(NewCoinGeckoPriceProvider(c.CoinGeckoAPIKey)))
+	}
+	if len(c.CORSOrigins) > 0 {
+		opts = append(opts, WithCORSOrigins(c.CORSOrigins))
+	}
+	return opts
+}
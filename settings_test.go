@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(`{"api_key": "file-key", "chain": "polygon", "rate_limit": 0, "http_timeout": "30s", "cors_origins": ["https://a.example"]}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if cfg.APIKey != "file-key" || cfg.Chain != "polygon" || cfg.RateLimit != 0 || cfg.HTTPTimeout != Duration(30*time.Second) {
+		t.Fatalf("expected the file's settings, got %+v", cfg)
+	}
+	if cfg.CacheTTL != Duration(defaultWalletCacheTTL) || cfg.ToolTimeout != Duration(defaultToolTimeout) {
+		t.Fatalf("expected defaults for unset fields, got %+v", cfg)
+	}
+
+	t.Setenv("ETHERSCAN_API_KEY", "env-key")
+	t.Setenv("ETHERSCAN_RATE_LIMIT", "2")
+	t.Setenv("WALLET_CACHE_TTL", "0")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://b.example,https://c.example")
+	t.Setenv("ETHERSCAN_CHAIN_ID", "")
+	t.Setenv("ETHERSCAN_MAX_RESPONSE_BYTES", "1048576")
+	if err := cfg.LoadFromEnvironment(); err != nil {
+		t.Fatalf("LoadFromEnvironment returned error: %v", err)
+	}
+	if cfg.APIKey != "env-key" || cfg.RateLimit != 2 || cfg.CacheTTL != 0 || len(cfg.CORSOrigins) != 2 || cfg.Chain != "polygon" {
+		t.Fatalf("expected the environment to override the file, got %+v", cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate returned error: %v", err)
+	}
+
+	tracker, err := NewWalletTracker(cfg.APIKey, cfg.Options()...)
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+	if got := tracker.GetConfig(); got.Chain != "polygon (137)" || got.RateLimit != 2 || got.HTTPTimeout != "30s" || got.WalletCacheEnabled || got.MaxResponseBytes != 1<<20 {
+		t.Fatalf("expected the config to reach the tracker, got %+v", got)
+	}
+
+	t.Setenv("TOOL_TIMEOUT", "soon")
+	if err := cfg.LoadFromEnvironment(); err == nil {
+		t.Fatal("expected an invalid duration to fail")
+	}
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected a missing file to fail")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := DefaultConfig()
+	valid.APIKey = "key"
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   error
+	}{
+		{"valid", func(*Config) {}, nil},
+		{"missing key", func(c *Config) { c.APIKey = " " }, ErrMissingAPIKey},
+		{"mock mode without key", func(c *Config) { c.APIKey, c.Fixtures = "", "fixtures.json" }, nil},
+		{"unknown chain", func(c *Config) { c.Chain = "dogechain" }, ErrUnsupportedChain},
+	}
+	for _, tt := range tests {
+		cfg := valid
+		tt.modify(&cfg)
+		if err := cfg.Validate(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	for _, modify := range []func(*Config){
+		func(c *Config) { c.RateLimit = -1 },
+		func(c *Config) { c.BalanceStrategy = "guess" },
+		func(c *Config) { c.PriceProvider = "oracle" },
+		func(c *Config) { c.LogLevel = "loud" },
+		func(c *Config) { c.CacheTTL = Duration(-time.Second) },
+		func(c *Config) { c.MCPTransport = "sse" },
+		func(c *Config) { c.MaxResponseBytes = 0 },
+		func(c *Config) { c.WebhookURL = "hooks.example.com/wallets" },
+		func(c *Config) { c.MonitorWallets = []string{testWalletA} },
+		func(c *Config) { c.WebhookURL, c.MonitorWallets = "https://hooks.example.com", []string{"0x123"} },
+		func(c *Config) { c.MonitorInterval = 0 },
+	} {
+		cfg := valid
+		modify(&cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", cfg)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShuttingDown is returned for tool calls that arrive after shutdown began.
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// callGate admits tool calls until it is drained and tracks the ones in
+// flight so shutdown can wait for them.
+type callGate struct {
+	mu       sync.Mutex
+	draining bool
+	inFlight sync.WaitGroup
+}
+
+func (g *callGate) enter() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.draining {
+		return ErrShuttingDown
+	}
+	g.inFlight.Add(1)
+	return nil
+}
+
+func (g *callGate) leave() {
+	g.inFlight.Done()
+}
+
+// drain refuses new calls and waits for those in flight, giving up when ctx
+// is done.
+func (g *callGate) drain(ctx context.Context) error {
+	g.mu.Lock()
+	g.draining = true
+	g.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		g.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Drain stops the tracker's tools from accepting new calls, which then fail
+// with ErrShuttingDown, and waits until the calls in flight have finished or
+// ctx is done.
+func (t *WalletTracker) Drain(ctx context.Context) error {
+	return t.calls.drain(ctx)
+}
+
+// trackCall wraps a tool or resource handler so it is refused once the
+// tracker drains and counted while it runs. The handler's context is the
+// call's own context from the MCP framework, bounded by the tracker's tool
+// timeout and cancelled along with root.
+func trackCall[T, R any](root context.Context, tracker *WalletTracker, handler func(context.Context, T) (R, error)) func(context.Context, T) (R, error) {
+	return func(ctx context.Context, req T) (R, error) {
+		if err := tracker.calls.enter(); err != nil {
+			var zero R
+			return zero, err
+		}
+		defer tracker.calls.leave()
+
+		ctx, cancel := context.WithTimeout(ctx, tracker.toolTimeout)
+		defer cancel()
+		stop := context.AfterFunc(root, cancel)
+		defer stop()
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+func TestDrainWaitsForInFlightCalls(t *testing.T) {
+	tracker, err := NewWalletTracker("test-key")
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	slow := trackCall(context.Background(), tracker, func(ctx context.Context, req PingRequest) (*mcp_golang.ToolResponse, error) {
+		close(started)
+		<-release
+		return mcp_golang.NewToolResponse(), nil
+	})
+	quick := trackCall(context.Background(), tracker, func(ctx context.Context, req PingRequest) (*mcp_golang.ToolResponse, error) {
+		return mcp_golang.NewToolResponse(), nil
+	})
+
+	callDone := make(chan error, 1)
+	go func() {
+		_, err := slow(context.Background(), PingRequest{})
+		callDone <- err
+	}()
+	<-started
+
+	drained := make(chan error, 1)
+	go func() { drained <- tracker.Drain(context.Background()) }()
+
+	// Drain refuses new calls straight away but keeps waiting for the slow one.
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := quick(context.Background(), PingRequest{}); errors.Is(err, ErrShuttingDown) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected new calls to be refused while draining")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-drained:
+		t.Fatalf("Drain returned %v before the in-flight call finished", err)
+	default:
+	}
+
+	close(release)
+	if err := <-callDone; err != nil {
+		t.Fatalf("in-flight call failed: %v", err)
+	}
+	if err := <-drained; err != nil {
+		t.Fatalf("Drain returned error: %v", err)
+	}
+}
+
+func TestDrainGivesUpAfterDeadline(t *testing.T) {
+	tracker, err := NewWalletTracker("test-key")
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+	if err := tracker.calls.enter(); err != nil {
+		t.Fatalf("enter returned error: %v", err)
+	}
+	defer tracker.calls.leave()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := tracker.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected Drain to give up with the deadline, got %v", err)
+	}
+}
+
+func TestTrackCallBoundsContext(t *testing.T) {
+	tracker, err := NewWalletTracker("test-key", WithToolTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+	wait := func(ctx context.Context, req PingRequest) (*mcp_golang.ToolResponse, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	if _, err := trackCall(context.Background(), tracker, wait)(context.Background(), PingRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the tool timeout to end the call, got %v", err)
+	}
+
+	WithToolTimeout(time.Minute)(tracker)
+	root, cancelRoot := context.WithCancel(context.Background())
+	cancelRoot()
+	if _, err := trackCall(root, tracker, wait)(context.Background(), PingRequest{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelling the root context to end the call, got %v", err)
+	}
+
+	call, cancelCall := context.WithCancel(context.Background())
+	cancelCall()
+	if _, err := trackCall(context.Background(), tracker, wait)(call, PingRequest{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the call's own cancellation to be honoured, got %v", err)
+	}
+}
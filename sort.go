@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Token orderings accepted by SortTokens.
+const (
+	SortByName    = "name"
+	SortByBalance = "balance"
+	SortByValue   = "value"
+)
+
+// SortTokens orders WalletResponse.Tokens by name (the default), balance or
+// USD value, descending when desc is set. Unknown fields keep the default.
+func SortTokens(by string, desc bool) QueryOption {
+	return func(o *queryOptions) {
+		if by == SortByName || by == SortByBalance || by == SortByValue {
+			o.sortBy = by
+		}
+		o.sortDesc = desc
+	}
+}
+
+func parseSortBy(raw string) (string, error) {
+	switch by := strings.ToLower(strings.TrimSpace(raw)); by {
+	case "":
+		return SortByName, nil
+	case SortByName, SortByBalance, SortByValue:
+		return by, nil
+	default:
+		return "", fmt.Errorf("unknown sort order %q: expected name, balance or value", raw)
+	}
+}
+
+// sortTokens orders tokens in place. Balances are compared exactly, as raw
+// amounts scaled by each token's decimals. Unpriced tokens sort after priced
+// ones in either direction when sorting by value, and ties fall back to the
+// name so the order is stable across calls.
+func sortTokens(tokens []TokenBalance, by string, desc bool) {
+	type entry struct {
+		token TokenBalance
+		key   *big.Rat
+	}
+	entries := make([]entry, len(tokens))
+	for i, token := range tokens {
+		entries[i].token = token
+		switch by {
+		case SortByBalance:
+			entries[i].key = scaledBalance(token)
+		case SortByValue:
+			if value, ok := new(big.Rat).SetString(token.USDValue); ok {
+				entries[i].key = value
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if by != SortByName {
+			switch {
+			case a.key != nil && b.key == nil:
+				return true
+			case a.key == nil && b.key != nil:
+				return false
+			case a.key != nil:
+				if c := a.key.Cmp(b.key); c != 0 {
+					return (c < 0) != desc
+				}
+				return compareTokenNames(a.token, b.token) < 0
+			}
+		}
+		c := compareTokenNames(a.token, b.token)
+		if by == SortByName && desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	for i, e := range entries {
+		tokens[i] = e.token
+	}
+}
+
+// compareTokenNames orders tokens by name case-insensitively, then by
+// contract address.
+func compareTokenNames(a, b TokenBalance) int {
+	if c := strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)); c != 0 {
+		return c
+	}
+	return strings.Compare(strings.ToLower(a.Address), strings.ToLower(b.Address))
+}
+
+// scaledBalance is the token's raw balance divided by 10^decimals.
+func scaledBalance(token TokenBalance) *big.Rat {
+	raw, ok := new(big.Int).SetString(token.RawBalance, 10)
+	if !ok {
+		return nil
+	}
+	value := new(big.Rat).SetInt(raw)
+	if token.Decimals > 0 {
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(token.Decimals)), nil)
+		value.Quo(value, new(big.Rat).SetInt(scale))
+	}
+	return value
+}
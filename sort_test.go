@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func sortTestTokens() []TokenBalance {
+	return []TokenBalance{
+		// 2 whole tokens; the larger raw amount hides the smaller balance.
+		{Name: "Alpha", Address: "0xa", RawBalance: "2000000000000000000", Decimals: 18, USDValue: "4.00"},
+		{Name: "Bravo", Address: "0xb", RawBalance: "30000000", Decimals: 6},
+		{Name: "Charlie", Address: "0xc", RawBalance: "5", Decimals: 0, USDValue: "100.00"},
+	}
+}
+
+func tokenNames(tokens []TokenBalance) string {
+	names := make([]string, len(tokens))
+	for i, token := range tokens {
+		names[i] = token.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSortTokens(t *testing.T) {
+	tests := []struct {
+		by   string
+		desc bool
+		want string
+	}{
+		{SortByName, false, "Alpha,Bravo,Charlie"},
+		{SortByName, true, "Charlie,Bravo,Alpha"},
+		{SortByBalance, false, "Alpha,Charlie,Bravo"},
+		{SortByBalance, true, "Bravo,Charlie,Alpha"},
+		// Unpriced Bravo stays last both ways.
+		{SortByValue, true, "Charlie,Alpha,Bravo"},
+		{SortByValue, false, "Alpha,Charlie,Bravo"},
+	}
+	for _, tt := range tests {
+		tokens := sortTestTokens()
+		sortTokens(tokens, tt.by, tt.desc)
+		if got := tokenNames(tokens); got != tt.want {
+			t.Errorf("sort by %s desc=%t: got %s, want %s", tt.by, tt.desc, got, tt.want)
+		}
+	}
+
+	if _, err := parseSortBy("size"); err == nil {
+		t.Fatalf("expected an unknown sort order to fail")
+	}
+}
+
+func TestWalletRouteSort(t *testing.T) {
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+			{"blockNumber":"1","contractAddress":"0xc0ffee0000000000000000000000000000000001","tokenName":"Alpha","tokenSymbol":"A","tokenDecimal":"0","value":"1","from":"%[1]s","to":"%[2]s"},
+			{"blockNumber":"2","contractAddress":"0xc0ffee0000000000000000000000000000000002","tokenName":"Bravo","tokenSymbol":"B","tokenDecimal":"0","value":"9","from":"%[1]s","to":"%[2]s"}
+		]}`, testWalletB, testWalletA)
+	}))
+	WithCache(NewTTLCache(defaultWalletCacheTTL))(tracker)
+	router := setupRoutes(tracker, tracker)
+
+	for _, tt := range []struct{ query, want string }{
+		{"?sort_by=balance&sort_desc=true", "Bravo,Alpha"},
+		// The cached response keeps the default order for other callers.
+		{"", "Alpha,Bravo"},
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wallet/"+testWalletA+tt.query, nil))
+		var resp WalletResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%q: decoding response: %v (%s)", tt.query, err, rec.Body.String())
+		}
+		if got := tokenNames(resp.Tokens); got != tt.want {
+			t.Fatalf("%q: got order %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wallet/"+testWalletA+"?sort_by=size", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown sort order, got %d", rec.Code)
+	}
+}
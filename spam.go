@@ -0,0 +1,68 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// urlPattern matches the link forms spam airdrops put in their names to lure
+// holders to a phishing site: a scheme, "www.", or a host on a TLD commonly
+// used for such sites. Dotted names of legitimate tokens such as "USDC.e"
+// or "Curve.Fi" do not match.
+var urlPattern = regexp.MustCompile(`(?i)(://|\bwww\.|[a-z0-9-]\.(com|net|org|io|xyz|app|site|online|top|club|gift|claim|live|link|pro|vip|cc|co|me)\b)`)
+
+// SpamFilter hides airdropped spam tokens. A token is spam when its contract
+// is on the blocklist, or when its name or symbol contains a URL or an emoji.
+// The heuristics are deliberately conservative: legitimate tokens do not
+// advertise websites in their names, and emoji are limited to pictographic
+// ranges so that currency signs such as ₮ are unaffected.
+type SpamFilter struct {
+	blocked map[string]bool
+}
+
+// NewSpamFilter returns a SpamFilter that, besides the heuristics, treats the
+// given contract addresses as spam.
+func NewSpamFilter(blocklist []string) *SpamFilter {
+	f := &SpamFilter{blocked: make(map[string]bool, len(blocklist))}
+	for _, contract := range blocklist {
+		if contract = strings.ToLower(strings.TrimSpace(contract)); contract != "" {
+			f.blocked[contract] = true
+		}
+	}
+	return f
+}
+
+// IsSpam reports whether the token should be hidden.
+func (f *SpamFilter) IsSpam(token TokenBalance) bool {
+	if f.blocked[strings.ToLower(token.Address)] {
+		return true
+	}
+	for _, text := range []string{token.Name, token.Symbol} {
+		if urlPattern.MatchString(text) || containsEmoji(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns the tokens that are not spam and how many were removed.
+func (f *SpamFilter) filter(tokens []TokenBalance) ([]TokenBalance, int) {
+	kept := tokens[:0]
+	for _, token := range tokens {
+		if !f.IsSpam(token) {
+			kept = append(kept, token)
+		}
+	}
+	return kept, len(tokens) - len(kept)
+}
+
+func containsEmoji(s string) bool {
+	for _, r := range s {
+		switch {
+		case r >= 0x1F000 && r <= 0x1FAFF, // pictographs, emoticons, transport, symbols
+			r >= 0x2600 && r <= 0x27BF: // miscellaneous symbols and dingbats
+			return true
+		}
+	}
+	return false
+}
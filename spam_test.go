@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSpamFilter(t *testing.T) {
+	blocked := "0xBAD0000000000000000000000000000000000000"
+	filter := NewSpamFilter([]string{" " + blocked + " ", ""})
+
+	cases := []struct {
+		token TokenBalance
+		spam  bool
+	}{
+		{TokenBalance{Name: "USD Coin", Symbol: "USDC"}, false},
+		{TokenBalance{Name: "Bridged USDC", Symbol: "USDC.e"}, false},
+		{TokenBalance{Name: "Curve.Fi USD Stablecoin", Symbol: "crvUSD"}, false},
+		{TokenBalance{Name: "USDT0", Symbol: "USD₮0"}, false},
+		{TokenBalance{Name: "Claim rewards at https://phish.example"}, true},
+		{TokenBalance{Name: "Visit www.free-airdrop.net", Symbol: "FREE"}, true},
+		{TokenBalance{Name: "Airdrop", Symbol: "eth-gift.xyz"}, true},
+		{TokenBalance{Name: "Reward 🎁", Symbol: "RWD"}, true},
+		{TokenBalance{Name: "Lucky", Symbol: "✅WIN"}, true},
+		{TokenBalance{Address: "0xbad0000000000000000000000000000000000000", Name: "Plain Name", Symbol: "PLN"}, true},
+	}
+	for _, tc := range cases {
+		if got := filter.IsSpam(tc.token); got != tc.spam {
+			t.Errorf("IsSpam(%q, %q) = %v, want %v", tc.token.Name, tc.token.Symbol, got, tc.spam)
+		}
+	}
+
+	tokens := []TokenBalance{cases[0].token, cases[4].token, cases[1].token}
+	kept, hidden := filter.filter(tokens)
+	if len(kept) != 2 || hidden != 1 || kept[1].Symbol != "USDC.e" {
+		t.Fatalf("unexpected filter result: kept %+v, hidden %d", kept, hidden)
+	}
+}
+
+func TestGetWalletTokensHidesSpam(t *testing.T) {
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+			{"contractAddress":"0x0000000000000000000000000000000000000001","tokenName":"Legit","tokenSymbol":"LGT","tokenDecimal":"0","value":"1","from":"0x3333333333333333333333333333333333333333","to":"%[1]s"},
+			{"contractAddress":"0x0000000000000000000000000000000000000002","tokenName":"Claim at spam.xyz","tokenSymbol":"SPAM","tokenDecimal":"0","value":"1","from":"0x3333333333333333333333333333333333333333","to":"%[1]s"}]}`, testWalletA)
+	}))
+
+	resp, err := tracker.GetWalletTokens(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetWalletTokens returned error: %v", err)
+	}
+	if len(resp.Tokens) != 1 || resp.HiddenSpam != 1 {
+		t.Fatalf("expected the spam token to be hidden, got %+v", resp)
+	}
+	if text := formatWalletResponse(resp, formatOptions{}); !strings.Contains(text, "1 suspected spam token(s) hidden") {
+		t.Fatalf("expected a hidden spam note, got:\n%s", text)
+	}
+
+	resp, err = tracker.GetWalletTokens(context.Background(), testWalletA, IncludeSpam())
+	if err != nil {
+		t.Fatalf("GetWalletTokens returned error: %v", err)
+	}
+	if len(resp.Tokens) != 2 || resp.HiddenSpam != 0 {
+		t.Fatalf("expected every token with IncludeSpam, got %+v", resp)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// WalletSummary gives a quick overview of a wallet's token activity. A wallet
+// without token transfers has zero counts and no times.
+type WalletSummary struct {
+	Address string `json:"address"`
+	// FirstTransfer and LastTransfer are nil when no transfer has a
+	// timestamp.
+	FirstTransfer  *time.Time `json:"first_transfer,omitempty"`
+	LastTransfer   *time.Time `json:"last_transfer,omitempty"`
+	TransferCount  int        `json:"transfer_count"`
+	DistinctTokens int        `json:"distinct_tokens"`
+	// Truncated reports that the history hit the page cap, so the counts
+	// only cover its oldest part. LastTransfer is then looked up separately
+	// and still the latest.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// GetWalletSummary derives the wallet's activity overview from its token
+// transfer history on the configured chain.
+func (t *WalletTracker) GetWalletSummary(ctx context.Context, walletAddress string) (*WalletSummary, error) {
+	if err := ValidateAddress(walletAddress); err != nil {
+		return nil, err
+	}
+
+	txs, truncated, err := t.fetchTokenTransactions(ctx, t.chainID, walletAddress, 0, 0)
+	if err != nil && !errors.Is(err, ErrNoTransactions) {
+		return nil, err
+	}
+
+	summary := summarizeActivity(txs)
+	summary.Address = walletAddress
+	summary.Truncated = truncated
+	if truncated {
+		var latest []tokenTransaction
+		if err := t.fetchList(ctx, t.chainID, lastEntryParams("tokentx", walletAddress), &latest); err != nil {
+			return nil, fmt.Errorf("fetching the latest transfer: %w", err)
+		}
+		if len(latest) > 0 {
+			if ts := latest[0].timestamp(); !ts.IsZero() {
+				summary.LastTransfer = &ts
+			}
+		}
+	}
+	return summary, nil
+}
+
+func summarizeActivity(txs []tokenTransaction) *WalletSummary {
+	summary := &WalletSummary{}
+	tokens := make(map[string]bool)
+	var first, last time.Time
+	for _, tx := range txs {
+		if isNativePseudoContract(tx.ContractAddress) {
+			continue
+		}
+		summary.TransferCount++
+		tokens[strings.ToLower(tx.ContractAddress)] = true
+
+		ts := tx.timestamp()
+		if ts.IsZero() {
+			continue
+		}
+		if first.IsZero() || ts.Before(first) {
+			first = ts
+		}
+		if ts.After(last) {
+			last = ts
+		}
+	}
+	summary.DistinctTokens = len(tokens)
+	if !first.IsZero() {
+		summary.FirstTransfer, summary.LastTransfer = &first, &last
+	}
+	return summary
+}
+
+type WalletSummaryRequest struct {
+	WalletAddress string `json:"wallet_address" description:"The cryptocurrency wallet address to summarize"`
+}
+
+func registerWalletSummary(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_summary", "Give a quick overview of a wallet's token activity: first and last transfer, transfer count and distinct tokens", trackCall(ctx, tracker, func(ctx context.Context, req WalletSummaryRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		summary, err := tracker.GetWalletSummary(ctx, wallet)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatWalletSummary(summary)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatWalletSummary(s *WalletSummary) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallet Address: %s\n", s.Address))
+	if s.TransferCount == 0 {
+		builder.WriteString("No token transfers found.")
+		return builder.String()
+	}
+	if s.FirstTransfer != nil {
+		builder.WriteString(fmt.Sprintf("First transfer: %s\n", s.FirstTransfer.Format(time.RFC3339)))
+	}
+	if s.LastTransfer != nil {
+		builder.WriteString(fmt.Sprintf("Last transfer: %s\n", s.LastTransfer.Format(time.RFC3339)))
+	}
+	builder.WriteString(fmt.Sprintf("Token transfers: %d\n", s.TransferCount))
+	builder.WriteString(fmt.Sprintf("Distinct tokens: %d\n", s.DistinctTokens))
+	if s.Truncated {
+		builder.WriteString("\nWarning: the transfer history is longer than the server fetches; the counts only reflect the earliest transfers.")
+	}
+	return strings.TrimRight(builder.String(), "\n")
+}
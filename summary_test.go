@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGetWalletSummary(t *testing.T) {
+	usdc := "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	other := "0x3333333333333333333333333333333333333333"
+
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("address") != testWalletA {
+			fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+			{"hash":"0x01","blockNumber":"1","timeStamp":"1700000000","contractAddress":"%[1]s","value":"1","from":"%[2]s","to":"%[3]s"},
+			{"hash":"0x02","blockNumber":"2","timeStamp":"1700086400","contractAddress":"0xc0ffee0000000000000000000000000000000000","value":"1","from":"%[2]s","to":"%[3]s"},
+			{"hash":"0x03","blockNumber":"3","timeStamp":"1700172800","contractAddress":"%[4]s","value":"1","from":"%[3]s","to":"%[2]s"},
+			{"hash":"0x04","blockNumber":"4","timeStamp":"1700259200","contractAddress":"","value":"1","from":"%[2]s","to":"%[3]s"}
+		]}`, usdc, other, testWalletA, "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	})
+
+	summary, err := tracker.GetWalletSummary(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetWalletSummary returned error: %v", err)
+	}
+	if summary.TransferCount != 3 || summary.DistinctTokens != 2 {
+		t.Fatalf("expected 3 transfers of 2 tokens, got %+v", summary)
+	}
+	if summary.FirstTransfer == nil || summary.FirstTransfer.Unix() != 1700000000 || summary.LastTransfer.Unix() != 1700172800 {
+		t.Fatalf("unexpected activity window %v - %v", summary.FirstTransfer, summary.LastTransfer)
+	}
+	want := "Wallet Address: " + testWalletA + "\nFirst transfer: 2023-11-14T22:13:20Z\nLast transfer: 2023-11-16T22:13:20Z\nToken transfers: 3\nDistinct tokens: 2"
+	if got := formatWalletSummary(summary); got != want {
+		t.Fatalf("unexpected output:\n%s", got)
+	}
+
+	empty, err := tracker.GetWalletSummary(context.Background(), testWalletB)
+	if err != nil {
+		t.Fatalf("expected an empty history to succeed, got %v", err)
+	}
+	if empty.TransferCount != 0 || empty.DistinctTokens != 0 || empty.FirstTransfer != nil || empty.LastTransfer != nil {
+		t.Fatalf("expected zeros, got %+v", empty)
+	}
+	if got := formatWalletSummary(empty); got != "Wallet Address: "+testWalletB+"\nNo token transfers found." {
+		t.Fatalf("unexpected output: %q", got)
+	}
+}
+
+func TestGetWalletSummaryTruncatedFetchesLatestTransfer(t *testing.T) {
+	var latestQuery string
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("sort") == "desc" {
+			latestQuery = q.Get("page") + "/" + q.Get("offset")
+			fmt.Fprintf(w, `{"status":"1","message":"OK","result":[{"hash":"0x09","blockNumber":"90","timeStamp":"1800000000","contractAddress":"0xc0ffee0000000000000000000000000000000000","value":"1","from":"%s","to":"%s"}]}`, testWalletB, testWalletA)
+			return
+		}
+		// A full page within one block stops the paging as truncated.
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+			{"hash":"0x01","blockNumber":"1","timeStamp":"1700000000","contractAddress":"0xc0ffee0000000000000000000000000000000000","value":"1","from":"%[1]s","to":"%[2]s"},
+			{"hash":"0x02","blockNumber":"1","timeStamp":"1700000000","contractAddress":"0xc0ffee0000000000000000000000000000000000","value":"1","from":"%[1]s","to":"%[2]s"}]}`, testWalletB, testWalletA)
+	})
+	tracker.txPageSize = 2
+
+	summary, err := tracker.GetWalletSummary(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetWalletSummary returned error: %v", err)
+	}
+	if !summary.Truncated || summary.TransferCount != 2 || latestQuery != "1/1" {
+		t.Fatalf("expected a truncated summary and a single-entry latest lookup, got %+v (query %q)", summary, latestQuery)
+	}
+	if summary.FirstTransfer.Unix() != 1700000000 || summary.LastTransfer == nil || summary.LastTransfer.Unix() != 1800000000 {
+		t.Fatalf("expected the last transfer from the latest lookup, got %v - %v", summary.FirstTransfer, summary.LastTransfer)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidTimeRange = errors.New("invalid time range")
+
+// parseTimeBound parses a transfer-listing time bound given as RFC 3339 (e.g.
+// "2024-01-31T00:00:00Z") or unix seconds. An empty bound is the zero time,
+// meaning unbounded.
+func parseTimeBound(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if secs, err := strconv.ParseInt(raw, 10, 64); err == nil && secs > 0 {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	ts, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q is neither RFC 3339 nor unix seconds", ErrInvalidTimeRange, raw)
+	}
+	return ts, nil
+}
+
+// parseTimeRange parses both bounds and checks that they are in order.
+func parseTimeRange(start, end string) (since, until time.Time, err error) {
+	if since, err = parseTimeBound(start); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("start_time: %w", err)
+	}
+	if until, err = parseTimeBound(end); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("end_time: %w", err)
+	}
+	if err := (TransferQuery{Since: since, Until: until}).checkTimeRange(); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return since, until, nil
+}
+
+func (q TransferQuery) checkTimeRange() error {
+	if !q.Since.IsZero() && !q.Until.IsZero() && q.Until.Before(q.Since) {
+		return fmt.Errorf("%w: end %s is before start %s", ErrInvalidTimeRange, q.Until.Format(time.RFC3339), q.Since.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (q TransferQuery) hasTimeRange() bool {
+	return !q.Since.IsZero() || !q.Until.IsZero()
+}
+
+// inTimeRange reports whether ts falls within q's bounds, both inclusive.
+// Transfers without a timestamp are outside any range.
+func (q TransferQuery) inTimeRange(ts time.Time) bool {
+	if !q.hasTimeRange() {
+		return true
+	}
+	if ts.IsZero() {
+		return false
+	}
+	return !ts.Before(q.Since) && (q.Until.IsZero() || !ts.After(q.Until))
+}
+
+// withinTimeRange keeps the items whose timestamp lies within q's bounds.
+func withinTimeRange[T any](items []T, q TransferQuery, timestamp func(T) time.Time) []T {
+	if !q.hasTimeRange() {
+		return items
+	}
+	kept := items[:0:0]
+	for _, item := range items {
+		if q.inTimeRange(timestamp(item)) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// boundBlocks narrows a list query to the blocks spanning q's time range, so
+// that Etherscan returns less history. Etherscan cannot filter by time itself,
+// and each bound costs a getblocknobytime call, so a bound whose block cannot
+// be found is left open: the client-side filter still applies, only the
+// download is larger.
+func (t *WalletTracker) boundBlocks(ctx context.Context, chainID int64, q TransferQuery, params url.Values) {
+	if !q.Since.IsZero() {
+		if block, err := t.blockAtTime(ctx, chainID, q.Since, "after"); err == nil {
+			params.Set("startblock", strconv.FormatUint(block, 10))
+		} else {
+			t.logger.Warn("Looking up start block failed", "time", q.Since, "error", err)
+		}
+	}
+	if !q.Until.IsZero() && q.Until.Before(time.Now()) {
+		if block, err := t.blockAtTime(ctx, chainID, q.Until, "before"); err == nil {
+			params.Set("endblock", strconv.FormatUint(block, 10))
+		} else {
+			t.logger.Warn("Looking up end block failed", "time", q.Until, "error", err)
+		}
+	}
+}
+
+// blockAtTime returns the block closest to ts, at or "before" it or at or
+// "after" it, using Etherscan's getblocknobytime action.
+func (t *WalletTracker) blockAtTime(ctx context.Context, chainID int64, ts time.Time, closest string) (uint64, error) {
+	params := url.Values{}
+	params.Set("module", "block")
+	params.Set("action", "getblocknobytime")
+	params.Set("timestamp", strconv.FormatInt(ts.Unix(), 10))
+	params.Set("closest", closest)
+
+	apiResp, err := t.queryEtherscan(ctx, chainID, params)
+	if err != nil {
+		return 0, err
+	}
+	if err := apiResp.statusErr(); err != nil {
+		return 0, err
+	}
+	var raw string
+	if err := json.Unmarshal(apiResp.Result, &raw); err != nil {
+		return 0, fmt.Errorf("parsing block number: %w", err)
+	}
+	block, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing block number %q: %w", raw, err)
+	}
+	return block, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseTimeRange(t *testing.T) {
+	since, until, err := parseTimeRange("2023-11-14T22:13:20Z", " 1700086400 ")
+	if err != nil {
+		t.Fatalf("parseTimeRange returned error: %v", err)
+	}
+	if since.Unix() != 1700000000 || until.Unix() != 1700086400 {
+		t.Fatalf("unexpected bounds %v - %v", since, until)
+	}
+
+	if since, until, err = parseTimeRange("", ""); err != nil || !since.IsZero() || !until.IsZero() {
+		t.Fatalf("expected empty bounds to be open, got %v - %v, %v", since, until, err)
+	}
+	for _, bounds := range [][2]string{{"yesterday", ""}, {"", "2024-13-01"}, {"1700086400", "1700000000"}} {
+		if _, _, err := parseTimeRange(bounds[0], bounds[1]); !errors.Is(err, ErrInvalidTimeRange) {
+			t.Errorf("%q: expected ErrInvalidTimeRange, got %v", bounds, err)
+		}
+	}
+}
+
+func TestGetTokenTransfersTimeRange(t *testing.T) {
+	contract := "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+	other := "0x3333333333333333333333333333333333333333"
+
+	var startBlock, endBlock string
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch q.Get("action") {
+		case "getblocknobytime":
+			switch q.Get("closest") {
+			case "after":
+				fmt.Fprint(w, `{"status":"1","message":"OK","result":"200"}`)
+			default:
+				fmt.Fprint(w, `{"status":"0","message":"NOTOK","result":"Error! No closest block found"}`)
+			}
+		case "tokentx":
+			startBlock, endBlock = q.Get("startblock"), q.Get("endblock")
+			// Block 200 starts a few seconds before the window, so Etherscan
+			// returns its transfer and the client-side filter drops it.
+			fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+				{"hash":"0x01","blockNumber":"200","timeStamp":"1699999990","tokenSymbol":"USDT","tokenDecimal":"6","value":"1000000","from":"%[1]s","to":"%[2]s"},
+				{"hash":"0x02","blockNumber":"201","timeStamp":"1700000000","tokenSymbol":"USDT","tokenDecimal":"6","value":"2000000","from":"%[1]s","to":"%[2]s"},
+				{"hash":"0x03","blockNumber":"202","timeStamp":"1700000500","tokenSymbol":"USDT","tokenDecimal":"6","value":"3000000","from":"%[1]s","to":"%[2]s"}
+			]}`, other, testWalletA)
+		default:
+			t.Errorf("unexpected action %q", q.Get("action"))
+		}
+	})
+
+	q := TransferQuery{Since: time.Unix(1700000000, 0), Until: time.Unix(1700000100, 0)}
+	resp, err := tracker.GetTokenTransfers(context.Background(), testWalletA, contract, q)
+	if err != nil {
+		t.Fatalf("GetTokenTransfers returned error: %v", err)
+	}
+	if len(resp.Transfers) != 1 || resp.Transfers[0].Hash != "0x02" {
+		t.Fatalf("expected only the transfer inside the window, got %+v", resp.Transfers)
+	}
+	// The end block could not be found, so only the start bounds the query.
+	if startBlock != "200" || endBlock != "999999999" {
+		t.Fatalf("expected blocks 200 to open end, got %s to %s", startBlock, endBlock)
+	}
+	if resp.Symbol != "USDT" {
+		t.Fatalf("expected the symbol from the history, got %q", resp.Symbol)
+	}
+
+	q = TransferQuery{Since: time.Unix(1700000500, 0), Until: time.Unix(1700000000, 0)}
+	if _, err := tracker.GetTokenTransfers(context.Background(), testWalletA, contract, q); !errors.Is(err, ErrInvalidTimeRange) {
+		t.Fatalf("expected a reversed range to be rejected, got %v", err)
+	}
+}
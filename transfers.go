@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+const (
+	defaultTransferLimit = 50
+	maxTransferLimit     = 1000
+)
+
+const (
+	DirectionIn   = "in"
+	DirectionOut  = "out"
+	DirectionSelf = "self"
+)
+
+var ErrInvalidDirection = errors.New("invalid transfer direction")
+
+type TokenTransfer struct {
+	Hash        string    `json:"hash"`
+	BlockNumber string    `json:"block_number"`
+	Timestamp   time.Time `json:"timestamp"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Direction   string    `json:"direction"`
+	Amount      string    `json:"amount"`
+}
+
+type TokenTransfersResponse struct {
+	Address   string          `json:"address"`
+	Contract  string          `json:"contract"`
+	Name      string          `json:"name"`
+	Symbol    string          `json:"symbol"`
+	Transfers []TokenTransfer `json:"transfers"`
+	// Truncated is set when the server stopped paging before finding limit
+	// matching transfers; older matches may then be missing.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// TransferQuery narrows a transfer listing. An empty Direction returns
+// transfers in both directions; a non-positive Limit uses the default. Since
+// and Until, when set, keep only transfers made within them, inclusive.
+type TransferQuery struct {
+	Direction string
+	Limit     int
+	Since     time.Time
+	Until     time.Time
+}
+
+// GetTokenTransfers returns the most recent transfers of a single token that
+// involve the wallet, newest first. Etherscan filters by contract server-side,
+// so only that token's history is downloaded.
+func (t *WalletTracker) GetTokenTransfers(ctx context.Context, walletAddress, contractAddress string, q TransferQuery) (*TokenTransfersResponse, error) {
+	if err := ValidateAddress(walletAddress); err != nil {
+		return nil, err
+	}
+	if err := ValidateAddress(contractAddress); err != nil {
+		return nil, fmt.Errorf("contract address: %w", err)
+	}
+	direction, err := normalizeDirection(q.Direction)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.checkTimeRange(); err != nil {
+		return nil, err
+	}
+
+	resp := &TokenTransfersResponse{
+		Address:   walletAddress,
+		Contract:  contractAddress,
+		Transfers: []TokenTransfer{},
+	}
+
+	limit := transferLimit(q.Limit)
+	params := accountListParams("tokentx", walletAddress)
+	params.Set("contractaddress", contractAddress)
+	t.boundBlocks(ctx, t.chainID, q, params)
+
+	resp.Truncated, err = fetchNewestFirst(ctx, t, t.chainID, params, limit, func(txs []tokenTransaction) bool {
+		// Name and symbol come from any transfer of the token, in range or not.
+		if resp.Name == "" && resp.Symbol == "" && len(txs) > 0 {
+			resp.Name = txs[0].displayName()
+			resp.Symbol = txs[0].displaySymbol()
+		}
+		txs = withinTimeRange(txs, q, tokenTransaction.timestamp)
+		resp.Transfers = append(resp.Transfers, filterTokenTransfers(walletAddress, txs, direction, limit-len(resp.Transfers), t.decimalOverrides)...)
+		return len(resp.Transfers) >= limit
+	})
+	if err != nil && !errors.Is(err, ErrNoTransactions) {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// fetchNewestFirst pages through a list query newest first, pageSize records
+// at a time, handing each page to visit until it reports it has enough or the
+// history runs out. Etherscan serves at most its first 10,000 records of a
+// query, and the tracker fetches at most maxTxPages pages, so running into
+// either limit stops early and reports the listing as truncated.
+func fetchNewestFirst[T any](ctx context.Context, t *WalletTracker, chainID int64, params url.Values, pageSize int, visit func([]T) bool) (truncated bool, err error) {
+	params.Set("sort", "desc")
+	params.Set("offset", strconv.Itoa(pageSize))
+	for page := 1; ; page++ {
+		params.Set("page", strconv.Itoa(page))
+		batch := []T{}
+		if err := t.fetchList(ctx, chainID, params, &batch); err != nil {
+			if errors.Is(err, ErrNoTransactions) && page > 1 {
+				return false, nil
+			}
+			return false, err
+		}
+		if visit(batch) || len(batch) < pageSize {
+			return false, nil
+		}
+		if page >= t.maxTxPages || (page+1)*pageSize > tokenTxPageSize {
+			return true, nil
+		}
+	}
+}
+
+// fetchForward pages through a list query oldest first, pageSize records at a
+// time, from fromBlock on. Etherscan serves at most its first 10,000 records
+// of a query, so rather than paging by number, every page restarts the query
+// at the block of the previous page's last record, set in the fromParam
+// parameter; that block's records are left to the next page instead of being
+// fetched twice. blockOf reads a record's block number. truncated is set when
+// maxTxPages runs out before the history does, or when a single block fills a
+// whole page.
+func fetchForward[T any](ctx context.Context, t *WalletTracker, chainID int64, params url.Values, fromParam string, fromBlock uint64, pageSize int, blockOf func(T) (uint64, error)) (records []T, truncated bool, err error) {
+	records = []T{}
+	params.Set("page", "1")
+	params.Set("offset", strconv.Itoa(pageSize))
+	for page := 1; ; page++ {
+		params.Set(fromParam, strconv.FormatUint(fromBlock, 10))
+		batch := []T{}
+		if err := t.fetchList(ctx, chainID, params, &batch); err != nil {
+			if errors.Is(err, ErrNoTransactions) && page > 1 {
+				return records, false, nil
+			}
+			return nil, false, err
+		}
+		if len(batch) < pageSize {
+			return append(records, batch...), false, nil
+		}
+
+		lastBlock, err := blockOf(batch[len(batch)-1])
+		if err != nil {
+			return nil, false, err
+		}
+		if page >= t.maxTxPages || lastBlock <= fromBlock {
+			return append(records, batch...), true, nil
+		}
+
+		end := len(batch)
+		for end > 0 {
+			if block, err := blockOf(batch[end-1]); err != nil || block != lastBlock {
+				break
+			}
+			end--
+		}
+		records = append(records, batch[:end]...)
+		fromBlock = lastBlock
+	}
+}
+
+// transferLimit applies the default and the cap to a requested limit.
+func transferLimit(limit int) int {
+	if limit <= 0 {
+		return defaultTransferLimit
+	}
+	return min(limit, maxTransferLimit)
+}
+
+func normalizeDirection(direction string) (string, error) {
+	switch d := strings.ToLower(strings.TrimSpace(direction)); d {
+	case "", "all":
+		return "", nil
+	case DirectionIn, DirectionOut:
+		return d, nil
+	default:
+		return "", fmt.Errorf("%w %q: expected in, out or all", ErrInvalidDirection, direction)
+	}
+}
+
+// filterTokenTransfers keeps up to limit of txs (newest first) that match
+// direction, in order.
+func filterTokenTransfers(walletAddress string, txs []tokenTransaction, direction string, limit int, decimalOverrides map[string]int) []TokenTransfer {
+	wallet := strings.ToLower(walletAddress)
+	result := make([]TokenTransfer, 0, max(0, min(limit, len(txs))))
+
+	for _, tx := range txs {
+		if len(result) >= limit {
+			break
+		}
+		dir := transferDirection(wallet, strings.ToLower(tx.From), strings.ToLower(tx.To))
+		if dir == "" {
+			continue
+		}
+		if direction != "" && dir != direction && dir != DirectionSelf {
+			continue
+		}
+
+		amount := "0"
+		if qty := tx.quantity(); qty != nil {
+			amount = formatTokenBalance(qty, tx.decimals(decimalOverrides))
+		}
+		result = append(result, TokenTransfer{
+			Hash:        tx.Hash,
+			BlockNumber: tx.BlockNumber,
+			Timestamp:   tx.timestamp(),
+			From:        tx.From,
+			To:          tx.To,
+			Direction:   dir,
+			Amount:      amount,
+		})
+	}
+	return result
+}
+
+func transferDirection(wallet, from, to string) string {
+	switch {
+	case from == wallet && to == wallet:
+		return DirectionSelf
+	case to == wallet:
+		return DirectionIn
+	case from == wallet:
+		return DirectionOut
+	}
+	return ""
+}
+
+type TokenTransfersRequest struct {
+	WalletAddress   string `json:"wallet_address" description:"The cryptocurrency wallet address to inspect"`
+	ContractAddress string `json:"contract_address" description:"The ERC-20 token contract address"`
+	Direction       string `json:"direction,omitempty" description:"Filter by direction: in, out or all (default all)"`
+	Limit           int    `json:"limit,omitempty" description:"Maximum number of transfers to return, newest first (default 50, max 1000)"`
+	StartTime       string `json:"start_time,omitempty" description:"Only transfers at or after this time, RFC 3339 or unix seconds"`
+	EndTime         string `json:"end_time,omitempty" description:"Only transfers at or before this time, RFC 3339 or unix seconds"`
+}
+
+func registerTokenTransfers(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_token_transfers", "List a wallet's transfers of a single ERC-20 token", trackCall(ctx, tracker, func(ctx context.Context, req TokenTransfersRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+		contract, err := tracker.walletArg("contract_address", req.ContractAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		since, until, err := parseTimeRange(req.StartTime, req.EndTime)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := tracker.GetTokenTransfers(ctx, wallet, contract, TransferQuery{
+			Direction: req.Direction,
+			Limit:     req.Limit,
+			Since:     since,
+			Until:     until,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatTokenTransfersResponse(resp)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatTokenTransfersResponse(resp *TokenTransfersResponse) string {
+	if len(resp.Transfers) == 0 {
+		return fmt.Sprintf("Wallet Address: %s\nContract: %s\nNo transfers found.", resp.Address, resp.Contract)
+	}
+
+	token := resp.Contract
+	if resp.Name != "" {
+		token = resp.Name
+	}
+	unit := ""
+	if resp.Symbol != "" {
+		token = fmt.Sprintf("%s (%s)", token, resp.Symbol)
+		unit = " " + resp.Symbol
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallet Address: %s\nToken: %s\nTransfers:\n", resp.Address, token))
+	for _, tr := range resp.Transfers {
+		var action string
+		switch tr.Direction {
+		case DirectionIn:
+			action = fmt.Sprintf("received %s%s from %s", tr.Amount, unit, tr.From)
+		case DirectionOut:
+			action = fmt.Sprintf("sent %s%s to %s", tr.Amount, unit, tr.To)
+		default:
+			action = fmt.Sprintf("self-transfer of %s%s", tr.Amount, unit)
+		}
+		builder.WriteString(fmt.Sprintf("- %s: %s (tx %s)\n", tr.Timestamp.Format(time.RFC3339), action, tr.Hash))
+	}
+	if resp.Truncated {
+		builder.WriteString("Warning: the transfer history is longer than the server pages through; older transfers may be missing.\n")
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGetTokenTransfersFiltersByContractAndDirection(t *testing.T) {
+	wallet := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	contract := "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+
+	// Newest first, as Etherscan returns them with sort=desc.
+	history := []string{
+		fmt.Sprintf(`{"hash":"0x03","timeStamp":"1700000200","tokenName":"Tether USD","tokenSymbol":"USDT","tokenDecimal":"6","value":"2000000","from":"0x3333333333333333333333333333333333333333","to":"%s"}`, wallet),
+		fmt.Sprintf(`{"hash":"0x02","timeStamp":"1700000100","tokenName":"Tether USD","tokenSymbol":"USDT","tokenDecimal":"6","value":"1500000","from":"%s","to":"0x2222222222222222222222222222222222222222"}`, wallet),
+		fmt.Sprintf(`{"hash":"0x01","timeStamp":"1700000000","tokenName":"Tether USD","tokenSymbol":"USDT","tokenDecimal":"6","value":"5000000","from":"0x1111111111111111111111111111111111111111","to":"%s"}`, wallet),
+	}
+	var pages []string
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if got := q.Get("contractaddress"); got != contract {
+			t.Errorf("expected contractaddress=%s, got %q", contract, got)
+		}
+		if q.Get("sort") != "desc" {
+			t.Errorf("expected the newest transfers first, got sort=%q", q.Get("sort"))
+		}
+		page, _ := strconv.Atoi(q.Get("page"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		pages = append(pages, q.Get("page"))
+		start, end := min((page-1)*offset, len(history)), min(page*offset, len(history))
+		if start == end {
+			fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[%s]}`, strings.Join(history[start:end], ","))
+	})
+
+	resp, err := tracker.GetTokenTransfers(context.Background(), wallet, contract, TransferQuery{Direction: "in", Limit: 1})
+	if err != nil {
+		t.Fatalf("GetTokenTransfers returned error: %v", err)
+	}
+	if resp.Symbol != "USDT" {
+		t.Fatalf("expected symbol USDT, got %q", resp.Symbol)
+	}
+	if len(resp.Transfers) != 1 {
+		t.Fatalf("expected 1 transfer, got %d", len(resp.Transfers))
+	}
+	if got := resp.Transfers[0]; got.Hash != "0x03" || got.Direction != DirectionIn || got.Amount != "2" {
+		t.Fatalf("unexpected transfer: %+v", got)
+	}
+	if len(pages) != 1 {
+		t.Fatalf("expected the newest page to suffice, fetched pages %v", pages)
+	}
+
+	// The newest transfer is incoming, so the outgoing one is on page 2.
+	pages = nil
+	resp, err = tracker.GetTokenTransfers(context.Background(), wallet, contract, TransferQuery{Direction: "out", Limit: 1})
+	if err != nil {
+		t.Fatalf("GetTokenTransfers returned error: %v", err)
+	}
+	if len(resp.Transfers) != 1 || resp.Transfers[0].Hash != "0x02" || resp.Truncated || len(pages) != 2 {
+		t.Fatalf("expected the outgoing transfer from page 2, got %+v after pages %v", resp, pages)
+	}
+
+	if _, err := tracker.GetTokenTransfers(context.Background(), wallet, contract, TransferQuery{Direction: "sideways"}); !errors.Is(err, ErrInvalidDirection) {
+		t.Fatalf("expected ErrInvalidDirection, got %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// erc20TransferTopic is keccak256("Transfer(address,address,uint256)").
+// ERC-721 transfers share it but index the token ID as a fourth topic.
+const erc20TransferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+var (
+	ErrInvalidTxHash = errors.New("invalid transaction hash")
+	// ErrTransactionNotFound is returned for a hash the chain has no receipt
+	// for: an unknown transaction, one on another chain, or a pending one.
+	ErrTransactionNotFound = errors.New("transaction not found")
+)
+
+// ValidateTxHash checks that hash is 0x followed by 64 hex characters.
+func ValidateTxHash(hash string) error {
+	if len(hash) != 66 || !strings.HasPrefix(hash, "0x") {
+		return ErrInvalidTxHash
+	}
+	if _, err := hex.DecodeString(hash[2:]); err != nil {
+		return ErrInvalidTxHash
+	}
+	return nil
+}
+
+// TokenFlow is one ERC-20 transfer within a transaction. Amount is scaled by
+// the token's decimals when they are known, and equals RawAmount otherwise.
+type TokenFlow struct {
+	Token       string `json:"token"`
+	TokenName   string `json:"token_name,omitempty"`
+	TokenSymbol string `json:"token_symbol,omitempty"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Amount      string `json:"amount"`
+	RawAmount   string `json:"raw_amount"`
+	Decimals    *int   `json:"decimals,omitempty"`
+	LogIndex    uint64 `json:"log_index"`
+}
+
+type TransactionTokenFlows struct {
+	Hash        string `json:"hash"`
+	BlockNumber uint64 `json:"block_number"`
+	// Failed is set for a reverted transaction, which moves no tokens.
+	Failed bool        `json:"failed,omitempty"`
+	Flows  []TokenFlow `json:"flows"`
+}
+
+type transactionReceipt struct {
+	Status      string `json:"status"`
+	BlockNumber string `json:"blockNumber"`
+	Logs        []struct {
+		eventLog
+		LogIndex string `json:"logIndex"`
+	} `json:"logs"`
+}
+
+// GetTransactionTokenFlows lists the ERC-20 transfers of a transaction on the
+// configured chain, in the order they happened, from the Transfer events in
+// its receipt. NFT transfers are left out.
+func (t *WalletTracker) GetTransactionTokenFlows(ctx context.Context, txHash string) (*TransactionTokenFlows, error) {
+	if err := ValidateTxHash(txHash); err != nil {
+		return nil, err
+	}
+
+	receipt, err := t.fetchTransactionReceipt(ctx, t.chainID, txHash)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := parseHexUint64(receipt.BlockNumber)
+	resp := &TransactionTokenFlows{Hash: txHash, BlockNumber: block, Failed: receipt.Status == "0x0", Flows: []TokenFlow{}}
+
+	tokens := make(map[string]TokenMetadata)
+	for _, log := range receipt.Logs {
+		if len(log.Topics) != 3 || !strings.EqualFold(log.Topics[0], erc20TransferTopic) || len(log.Topics[1]) < 40 || len(log.Topics[2]) < 40 {
+			continue
+		}
+		value, err := parseHexBig(log.Data)
+		if err != nil || len(strings.TrimPrefix(log.Data, "0x")) == 0 {
+			continue
+		}
+		index, _ := parseHexUint64(log.LogIndex)
+		flow := TokenFlow{
+			Token:     displayAddress(log.Address),
+			From:      displayAddress("0x" + strings.ToLower(log.Topics[1][len(log.Topics[1])-40:])),
+			To:        displayAddress("0x" + strings.ToLower(log.Topics[2][len(log.Topics[2])-40:])),
+			Amount:    value.String(),
+			RawAmount: value.String(),
+			LogIndex:  index,
+		}
+
+		key := strings.ToLower(flow.Token)
+		meta, ok := tokens[key]
+		if !ok {
+			// Metadata comes from a transfer of the token by either party;
+			// the sender of a mint is the zero address, which has none.
+			holder := flow.From
+			if holder == zeroAddress {
+				holder = flow.To
+			}
+			if meta, err = t.lookupTokenMetadata(ctx, t.chainID, holder, flow.Token); err != nil {
+				return nil, fmt.Errorf("looking up token %s: %w", flow.Token, err)
+			}
+			tokens[key] = meta
+		}
+		flow.TokenName = firstNonEmpty(meta.Name, meta.Symbol)
+		flow.TokenSymbol = meta.Symbol
+		if meta.HasDecimals {
+			flow.Amount = formatTokenBalance(value, meta.Decimals)
+			decimals := meta.Decimals
+			flow.Decimals = &decimals
+		}
+		resp.Flows = append(resp.Flows, flow)
+	}
+
+	return resp, nil
+}
+
+// fetchTransactionReceipt reads a receipt through Etherscan's
+// eth_getTransactionReceipt proxy.
+func (t *WalletTracker) fetchTransactionReceipt(ctx context.Context, chainID int64, txHash string) (*transactionReceipt, error) {
+	params := url.Values{}
+	params.Set("module", "proxy")
+	params.Set("action", "eth_getTransactionReceipt")
+	params.Set("txhash", txHash)
+
+	apiResp, err := t.queryEtherscan(ctx, chainID, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := apiResp.statusErr(); err != nil {
+		return nil, err
+	}
+
+	var receipt *transactionReceipt
+	if len(apiResp.Result) > 0 {
+		if err := json.Unmarshal(apiResp.Result, &receipt); err != nil {
+			return nil, fmt.Errorf("parsing transaction receipt: %w", err)
+		}
+	}
+	if receipt == nil {
+		return nil, fmt.Errorf("%w: %s has no receipt on chain %d; it may be pending or on another chain", ErrTransactionNotFound, txHash, chainID)
+	}
+	return receipt, nil
+}
+
+type TxTokenFlowsRequest struct {
+	TxHash string `json:"tx_hash" description:"The transaction hash (0x followed by 64 hex characters) whose token transfers to list"`
+}
+
+func registerTxTokenFlows(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("tx_token_flows", "List the ERC-20 token transfers that happened within a transaction", trackCall(ctx, tracker, func(ctx context.Context, req TxTokenFlowsRequest) (*mcp_golang.ToolResponse, error) {
+		hash := strings.ToLower(strings.TrimSpace(req.TxHash))
+		if hash == "" {
+			return nil, fmt.Errorf("%w: tx_hash", ErrMissingArgument)
+		}
+		if err := ValidateTxHash(hash); err != nil {
+			return nil, fmt.Errorf("tx_hash %q: %w: expected 0x followed by 64 hex characters", req.TxHash, err)
+		}
+
+		resp, err := tracker.GetTransactionTokenFlows(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatTransactionTokenFlows(resp)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatTransactionTokenFlows(resp *TransactionTokenFlows) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Transaction: %s\n", resp.Hash))
+	if resp.BlockNumber != 0 {
+		builder.WriteString(fmt.Sprintf("Block: %d\n", resp.BlockNumber))
+	}
+	if resp.Failed {
+		builder.WriteString("The transaction failed (reverted), so it moved no tokens.\n")
+		return strings.TrimRight(builder.String(), "\n")
+	}
+	if len(resp.Flows) == 0 {
+		builder.WriteString("No ERC-20 token transfers found.\n")
+	} else {
+		builder.WriteString("Token transfers:\n")
+	}
+	for _, flow := range resp.Flows {
+		token := tokenLabel(TokenBalance{Address: flow.Token, Name: flow.TokenName, Symbol: flow.TokenSymbol}, LabelContract)
+		amount := flow.Amount
+		if flow.Decimals == nil {
+			amount += " (raw, decimals unknown)"
+		}
+		builder.WriteString(fmt.Sprintf("- %s %s: %s -> %s\n", amount, token, flow.From, flow.To))
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateTxHash(t *testing.T) {
+	valid := "0x" + strings.Repeat("ab", 32)
+	if err := ValidateTxHash(valid); err != nil {
+		t.Fatalf("expected %s to be valid, got %v", valid, err)
+	}
+	for _, hash := range []string{"", "0x123", valid[2:] + "00", valid + "00", "0x" + strings.Repeat("zz", 32)} {
+		if err := ValidateTxHash(hash); !errors.Is(err, ErrInvalidTxHash) {
+			t.Errorf("%q: got %v, want ErrInvalidTxHash", hash, err)
+		}
+	}
+}
+
+func TestGetTransactionTokenFlows(t *testing.T) {
+	const (
+		tokenA = "0xc0ffee0000000000000000000000000000000000"
+		tokenB = "0xbeef000000000000000000000000000000000000"
+		nft    = "0x4444444444444444444444444444444444444444"
+		other  = "0x3333333333333333333333333333333333333333"
+	)
+	hash := "0x" + strings.Repeat("ab", 32)
+	topic := func(address string) string {
+		return "0x" + strings.Repeat("0", 24) + address[2:]
+	}
+	transfer := func(token, from, to, data, index string) string {
+		return fmt.Sprintf(`{"address":"%s","topics":["%s","%s","%s"],"data":"%s","blockNumber":"0x10","logIndex":"%s","transactionHash":"%s"}`,
+			token, erc20TransferTopic, topic(from), topic(to), data, index, hash)
+	}
+
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("action") == "eth_getTransactionReceipt" && q.Get("txhash") == hash:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":{"status":"0x1","blockNumber":"0x10","logs":[%s]}}`, strings.Join([]string{
+				transfer(tokenA, testWalletA, other, "0x4c4b40", "0x0"),
+				// An ERC-721 transfer, with the token ID as a fourth topic.
+				fmt.Sprintf(`{"address":"%s","topics":["%s","%s","%s","0x01"],"data":"0x","logIndex":"0x1"}`, nft, erc20TransferTopic, topic(other), topic(testWalletA)),
+				fmt.Sprintf(`{"address":"%s","topics":["%s","%s","%s"],"data":"0x01","logIndex":"0x2"}`, tokenA, erc20ApprovalTopic, topic(testWalletA), topic(other)),
+				transfer(tokenB, zeroAddress, testWalletA, "0x07", "0x3"),
+			}, ","))
+		case q.Get("action") == "eth_getTransactionReceipt":
+			fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":null}`)
+		case q.Get("action") == "tokentx" && strings.EqualFold(q.Get("contractaddress"), tokenA) && strings.EqualFold(q.Get("address"), testWalletA):
+			fmt.Fprintf(w, `{"status":"1","message":"OK","result":[{"contractAddress":"%s","tokenName":"Alpha","tokenSymbol":"ALP","tokenDecimal":"6","value":"1"}]}`, tokenA)
+		case q.Get("action") == "tokentx" && strings.EqualFold(q.Get("address"), testWalletA):
+			fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+		default:
+			t.Errorf("unexpected etherscan request %s", r.URL.RawQuery)
+			fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+		}
+	})
+
+	resp, err := tracker.GetTransactionTokenFlows(context.Background(), hash)
+	if err != nil {
+		t.Fatalf("GetTransactionTokenFlows returned error: %v", err)
+	}
+	if resp.BlockNumber != 16 || resp.Failed || len(resp.Flows) != 2 {
+		t.Fatalf("expected two ERC-20 flows in block 16, got %+v", resp)
+	}
+	if got := resp.Flows[0]; !strings.EqualFold(got.Token, tokenA) || got.From != testWalletA || got.To != other || got.Amount != "5" || got.RawAmount != "5000000" || got.TokenSymbol != "ALP" {
+		t.Fatalf("expected 5 ALP from wallet A, got %+v", got)
+	}
+	if got := resp.Flows[1]; got.From != zeroAddress || got.To != testWalletA || got.Amount != "7" || got.Decimals != nil || got.LogIndex != 3 {
+		t.Fatalf("expected a raw mint of 7 to wallet A, got %+v", got)
+	}
+
+	content := formatTransactionTokenFlows(resp)
+	if !strings.Contains(content, "- 5 Alpha (ALP, "+displayAddress(tokenA)+"): "+testWalletA+" -> "+other) || !strings.Contains(content, "7 (raw, decimals unknown)") {
+		t.Fatalf("unexpected output:\n%s", content)
+	}
+
+	if _, err := tracker.GetTransactionTokenFlows(context.Background(), "0x"+strings.Repeat("cd", 32)); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("expected ErrTransactionNotFound, got %v", err)
+	}
+}
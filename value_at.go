@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// historicalPriceWindow is how far on either side of the requested time
+// GetWalletValueAt looks for a price; a two-day range gets hourly points
+// from CoinGecko.
+const historicalPriceWindow = 24 * time.Hour
+
+// WalletValueAt is a wallet's holdings at a past time, valued at that time's
+// prices.
+type WalletValueAt struct {
+	Time time.Time `json:"time"`
+	// Block is the last block at or before Time, which the balances are
+	// reported at.
+	Block uint64 `json:"block"`
+	// Wallet holds the balances. Each priced token's PriceUpdatedAt is the
+	// time of the price point used, the one closest to Time.
+	Wallet *WalletResponse `json:"wallet"`
+}
+
+// GetWalletValueAt reconstructs the wallet's token balances as of at, from
+// the last block at or before it, and values them at the historical prices
+// closest to at. It needs a HistoricalPriceProvider, failing with
+// ErrPricingDisabled or ErrHistoricalPricingUnsupported otherwise, and at
+// must lie within the past year, the price history CoinGecko serves; other
+// times fail with ErrInvalidTimeRange. As with the latest balances, the
+// native balance is only included with a JSON-RPC endpoint and is not
+// valued.
+func (t *WalletTracker) GetWalletValueAt(ctx context.Context, walletAddress string, at time.Time, opts ...QueryOption) (*WalletValueAt, error) {
+	now := time.Now()
+	if at.After(now) {
+		return nil, fmt.Errorf("%w: %s is in the future", ErrInvalidTimeRange, at.Format(time.RFC3339))
+	}
+	if now.Sub(at) > maxPriceHistory {
+		return nil, fmt.Errorf("%w: %s is more than 365 days ago, beyond the available price history", ErrInvalidTimeRange, at.Format(time.RFC3339))
+	}
+	history, source, err := t.historicalPrices()
+	if err != nil {
+		return nil, err
+	}
+
+	q := queryOptions{chain: t.chain()}
+	for _, opt := range opts {
+		opt(&q)
+	}
+	block, err := t.blockAtTime(ctx, q.chain.ID, at, "before")
+	if err != nil {
+		return nil, fmt.Errorf("looking up the block at %s: %w", at.Format(time.RFC3339), err)
+	}
+	resp, err := t.GetWalletTokensAtBlock(ctx, walletAddress, block, opts...)
+	if err != nil {
+		return nil, err
+	}
+	resp.Block = block
+
+	quotes := make(map[string]priceQuote)
+	for _, token := range resp.pricedTokens() {
+		prices, err := history.TokenPriceHistory(ctx, q.chain, token.Address, at.Add(-historicalPriceWindow), at.Add(historicalPriceWindow))
+		if err != nil {
+			return nil, fmt.Errorf("looking up the price history of %s: %w", token.Address, err)
+		}
+		if price, ok := priceAt(prices, at); ok {
+			quotes[strings.ToLower(token.Address)] = priceQuote{price: price.USD, source: source, fetchedAt: price.Time}
+		}
+	}
+	resp.setUSDValues(quotes)
+
+	return &WalletValueAt{Time: at.UTC(), Block: block, Wallet: resp}, nil
+}
+
+// parseValueDate parses the time to value a wallet at: a date such as
+// "2024-12-31", meaning the end of that day in UTC, or a time bound as
+// accepted by parseTimeBound.
+func parseValueDate(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("%w: date", ErrMissingArgument)
+	}
+	if day, err := time.Parse(time.DateOnly, raw); err == nil {
+		return day.Add(24*time.Hour - time.Second), nil
+	}
+	at, err := parseTimeBound(raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("date: %w", err)
+	}
+	return at, nil
+}
+
+type WalletValueAtRequest struct {
+	WalletAddress string `json:"wallet_address" description:"The cryptocurrency wallet address to value"`
+	Date          string `json:"date" description:"When to value the wallet: a date such as 2024-12-31 (the end of that day, UTC), an RFC 3339 time or unix seconds, within the past 365 days"`
+}
+
+func registerWalletValueAt(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_value_at", "Estimate a wallet's token holdings and their USD value at a past date, using historical balances and prices, e.g. for accounting", trackCall(ctx, tracker, func(ctx context.Context, req WalletValueAtRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+		at, err := parseValueDate(req.Date)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := tracker.GetWalletValueAt(ctx, wallet, at)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatWalletValueAt(value)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatWalletValueAt(v *WalletValueAt) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Valued at: %s\n", v.Time.Format(time.RFC3339)))
+	builder.WriteString(formatWalletResponse(v.Wallet, formatOptions{Labels: LabelDefault, TrailingNewline: true}))
+	builder.WriteString("Note: an estimate. Balances are netted from transfers up to the block, and each token is valued at the historical price point closest to the time (hourly); tokens without a price then are not valued.")
+	return builder.String()
+}
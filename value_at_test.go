@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeHistoricalPrices serves a fixed price history per contract.
+type fakeHistoricalPrices struct {
+	fakePriceProvider
+	history map[string][]HistoricalPrice
+	ranges  []string
+}
+
+func (f *fakeHistoricalPrices) TokenPriceHistory(ctx context.Context, chain Chain, contract string, from, to time.Time) ([]HistoricalPrice, error) {
+	f.ranges = append(f.ranges, fmt.Sprintf("%s %d-%d", strings.ToLower(contract), from.Unix(), to.Unix()))
+	return f.history[strings.ToLower(contract)], nil
+}
+
+func TestGetWalletValueAt(t *testing.T) {
+	at := time.Now().Add(-30 * 24 * time.Hour).Truncate(time.Hour).UTC()
+	var endBlocks []string
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("action") == "getblocknobytime" {
+			if q.Get("timestamp") != fmt.Sprint(at.Unix()) || q.Get("closest") != "before" {
+				t.Errorf("unexpected block lookup %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"status":"1","message":"OK","result":"500"}`)
+			return
+		}
+		endBlocks = append(endBlocks, q.Get("endblock"))
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[
+			{"blockNumber":"400","contractAddress":"0xc0ffee0000000000000000000000000000000000","tokenName":"Coffee","tokenSymbol":"CAF","tokenDecimal":"0","value":"4","from":"0x3333333333333333333333333333333333333333","to":"%[1]s"},
+			{"blockNumber":"450","contractAddress":"0xbad0000000000000000000000000000000000000","tokenName":"Unpriced","tokenSymbol":"UNP","tokenDecimal":"0","value":"1","from":"0x3333333333333333333333333333333333333333","to":"%[1]s"}]}`, testWalletA)
+	}))
+	tracker.blockHeight = &fakeBlockHeight{height: 999}
+
+	if _, err := tracker.GetWalletValueAt(context.Background(), testWalletA, at); !errors.Is(err, ErrPricingDisabled) {
+		t.Fatalf("expected ErrPricingDisabled without pricing, got %v", err)
+	}
+	tracker.prices = newCachedPrices(&fakePriceProvider{}, time.Minute)
+	if _, err := tracker.GetWalletValueAt(context.Background(), testWalletA, at); !errors.Is(err, ErrHistoricalPricingUnsupported) {
+		t.Fatalf("expected ErrHistoricalPricingUnsupported, got %v", err)
+	}
+
+	provider := &fakeHistoricalPrices{
+		fakePriceProvider: fakePriceProvider{prices: map[string]string{"0xc0ffee0000000000000000000000000000000000": "9"}},
+		history: map[string][]HistoricalPrice{"0xc0ffee0000000000000000000000000000000000": {
+			{USD: big.NewRat(1, 1), Time: at.Add(-2 * time.Hour)},
+			{USD: big.NewRat(5, 2), Time: at.Add(-20 * time.Minute)},
+			{USD: big.NewRat(3, 1), Time: at.Add(40 * time.Minute)},
+		}},
+	}
+	// The price cache only holds current prices; history is looked up
+	// behind it.
+	tracker.prices = newCachedPrices(provider, time.Minute)
+
+	value, err := tracker.GetWalletValueAt(context.Background(), testWalletA, at)
+	if err != nil {
+		t.Fatalf("GetWalletValueAt returned error: %v", err)
+	}
+	if last := endBlocks[len(endBlocks)-1]; last != "500" || value.Block != 500 || value.Wallet.Block != 500 {
+		t.Fatalf("expected balances at block 500, got endblock=%s, %+v", last, value)
+	}
+	if want := fmt.Sprintf("0xc0ffee0000000000000000000000000000000000 %d-%d", at.Add(-24*time.Hour).Unix(), at.Add(24*time.Hour).Unix()); provider.ranges[0] != want {
+		t.Fatalf("expected a two-day price range around the time, got %v", provider.ranges)
+	}
+	for _, token := range value.Wallet.Tokens {
+		switch token.Symbol {
+		case "CAF":
+			if token.USDValue != "10.00" || token.PortfolioPct != "100.00" || token.PriceUpdatedAt == nil || !token.PriceUpdatedAt.Equal(at.Add(-20*time.Minute)) || token.PriceSource != "custom" {
+				t.Fatalf("expected CAF valued at the closest price of 2.5, got %+v", token)
+			}
+		case "UNP":
+			if token.USDValue != "" || token.PriceUpdatedAt != nil {
+				t.Fatalf("expected no value without a price history, got %+v", token)
+			}
+		}
+	}
+	if value.Wallet.TotalUSD != "10.00" {
+		t.Fatalf("expected a total of $10.00 at historical prices, got %q", value.Wallet.TotalUSD)
+	}
+	if content := formatWalletValueAt(value); !strings.Contains(content, "Valued at: "+at.Format(time.RFC3339)) || !strings.Contains(content, "As of block: 500") || !strings.Contains(content, "Coffee (CAF): 4 ($10.00, 100.00%)") {
+		t.Fatalf("unexpected output:\n%s", content)
+	}
+
+	for _, bad := range []time.Time{time.Now().Add(time.Hour), time.Now().Add(-366 * 24 * time.Hour)} {
+		if _, err := tracker.GetWalletValueAt(context.Background(), testWalletA, bad); !errors.Is(err, ErrInvalidTimeRange) {
+			t.Fatalf("%s: expected ErrInvalidTimeRange, got %v", bad, err)
+		}
+	}
+}
+
+func TestParseValueDate(t *testing.T) {
+	tests := map[string]string{
+		"2024-12-31":           "2024-12-31T23:59:59Z",
+		"2024-06-01T12:00:00Z": "2024-06-01T12:00:00Z",
+		"1717243200":           "2024-06-01T12:00:00Z",
+	}
+	for raw, want := range tests {
+		at, err := parseValueDate(raw)
+		if err != nil || at.Format(time.RFC3339) != want {
+			t.Fatalf("parseValueDate(%q) = %s, %v; want %s", raw, at.Format(time.RFC3339), err, want)
+		}
+	}
+	if _, err := parseValueDate(" "); !errors.Is(err, ErrMissingArgument) {
+		t.Fatalf("expected ErrMissingArgument for an empty date, got %v", err)
+	}
+	if _, err := parseValueDate("last year"); !errors.Is(err, ErrInvalidTimeRange) {
+		t.Fatalf("expected ErrInvalidTimeRange for a bad date, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+type WalletActivity struct {
+	Address     string `json:"address"`
+	HasActivity bool   `json:"has_activity"`
+	FirstTxHash string `json:"first_tx_hash,omitempty"`
+	FirstBlock  string `json:"first_block,omitempty"`
+	// FirstSeen is nil when the first transaction's timestamp is unknown.
+	FirstSeen *time.Time `json:"first_seen,omitempty"`
+	AgeDays   int        `json:"age_days,omitempty"`
+}
+
+// GetFirstActivity returns the wallet's earliest transaction. It asks
+// Etherscan for the first entry of txlist only, and falls back to the first
+// token transfer for wallets that have only ever received tokens.
+func (t *WalletTracker) GetFirstActivity(ctx context.Context, walletAddress string) (*WalletActivity, error) {
+	if err := ValidateAddress(walletAddress); err != nil {
+		return nil, err
+	}
+
+	activity := &WalletActivity{Address: walletAddress}
+
+	var first []normalTransaction
+	err := t.fetchList(ctx, t.chainID, firstEntryParams("txlist", walletAddress), &first)
+	if err != nil && !errors.Is(err, ErrNoTransactions) {
+		return nil, err
+	}
+	if len(first) > 0 {
+		activity.setFirst(first[0].Hash, first[0].BlockNumber, parseUnixTimestamp(first[0].TimeStamp))
+		return activity, nil
+	}
+
+	var firstToken []tokenTransaction
+	err = t.fetchList(ctx, t.chainID, firstEntryParams("tokentx", walletAddress), &firstToken)
+	if err != nil && !errors.Is(err, ErrNoTransactions) {
+		return nil, err
+	}
+	if len(firstToken) > 0 {
+		activity.setFirst(firstToken[0].Hash, firstToken[0].BlockNumber, firstToken[0].timestamp())
+	}
+	return activity, nil
+}
+
+func (a *WalletActivity) setFirst(hash, block string, seen time.Time) {
+	a.HasActivity = true
+	a.FirstTxHash = hash
+	a.FirstBlock = block
+	if !seen.IsZero() {
+		a.FirstSeen = &seen
+		a.AgeDays = int(time.Since(seen).Hours() / 24)
+	}
+}
+
+func firstEntryParams(action, walletAddress string) url.Values {
+	params := accountListParams(action, walletAddress)
+	params.Set("page", "1")
+	params.Set("offset", "1")
+	return params
+}
+
+// lastEntryParams asks for the latest entry of a list only.
+func lastEntryParams(action, walletAddress string) url.Values {
+	params := firstEntryParams(action, walletAddress)
+	params.Set("sort", "desc")
+	return params
+}
+
+type WalletAgeRequest struct {
+	WalletAddress string `json:"wallet_address" description:"The cryptocurrency wallet address to inspect"`
+}
+
+func registerWalletAge(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_age", "Show when a wallet was first active and how old it is", trackCall(ctx, tracker, func(ctx context.Context, req WalletAgeRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		activity, err := tracker.GetFirstActivity(ctx, wallet)
+		if err != nil {
+			return nil, err
+		}
+
+		content := formatWalletActivity(activity)
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+func formatWalletActivity(a *WalletActivity) string {
+	if !a.HasActivity {
+		return fmt.Sprintf("Wallet Address: %s\nNo activity found.", a.Address)
+	}
+	if a.FirstSeen == nil {
+		return fmt.Sprintf("Wallet Address: %s\nFirst activity: unknown time (block %s, tx %s)", a.Address, a.FirstBlock, a.FirstTxHash)
+	}
+	return fmt.Sprintf("Wallet Address: %s\nFirst activity: %s (block %s, tx %s)\nWallet age: %d days",
+		a.Address, a.FirstSeen.Format(time.RFC3339), a.FirstBlock, a.FirstTxHash, a.AgeDays)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGetFirstActivity(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("sort") != "asc" || q.Get("offset") != "1" {
+			t.Errorf("expected a single ascending entry, got %s", r.URL.RawQuery)
+		}
+		switch q.Get("address") {
+		case testWalletA:
+			fmt.Fprint(w, `{"status":"1","message":"OK","result":[{"hash":"0xabc","blockNumber":"46147","timeStamp":"1438918233"}]}`)
+		default:
+			fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+		}
+	})
+
+	activity, err := tracker.GetFirstActivity(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetFirstActivity returned error: %v", err)
+	}
+	if !activity.HasActivity || activity.FirstTxHash != "0xabc" || activity.FirstSeen == nil || activity.FirstSeen.Unix() != 1438918233 {
+		t.Fatalf("unexpected first activity: %+v", activity)
+	}
+	if activity.AgeDays <= 0 {
+		t.Fatalf("expected a positive wallet age, got %d", activity.AgeDays)
+	}
+
+	empty, err := tracker.GetFirstActivity(context.Background(), testWalletB)
+	if err != nil {
+		t.Fatalf("GetFirstActivity returned error for empty wallet: %v", err)
+	}
+	if empty.HasActivity {
+		t.Fatalf("expected no activity, got %+v", empty)
+	}
+	if got := formatWalletActivity(empty); got != "Wallet Address: "+testWalletB+"\nNo activity found." {
+		t.Fatalf("unexpected output: %q", got)
+	}
+}
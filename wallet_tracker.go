@@ -1,13 +1,17 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"math/big"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
 	"sort"
@@ -19,34 +23,195 @@ import (
 )
 
 const (
-	etherscanBaseURL   = "https://api.etherscan.io/v2/api"
-	defaultHTTPTimeout = 10 * time.Second
+	etherscanBaseURL       = "https://api.etherscan.io/v2/api"
+	defaultHTTPTimeout     = 10 * time.Second
+	defaultListenAddr      = ":8080"
+	defaultMaxConnsPerHost = 10
+	// defaultToolTimeout bounds a single tool call, generously enough for a
+	// fully paginated history with retries.
+	defaultToolTimeout = 2 * time.Minute
+	// shutdownGracePeriod bounds how long in-flight tool calls and HTTP
+	// requests may run after a shutdown signal.
+	shutdownGracePeriod = 15 * time.Second
+	// defaultMaxResponseBytes is far above any legitimate Etherscan page (the
+	// API caps list results at 10,000 entries) while still bounding memory.
+	defaultMaxResponseBytes = 50 << 20
+	// tokenTxPageSize is the most records Etherscan returns for one list
+	// query; longer histories are walked forward by start block.
+	tokenTxPageSize   = 10000
+	defaultMaxTxPages = 10
+	// Rate-limited Etherscan calls are retried this many times, waiting a
+	// jittered, doubling delay that starts at defaultEtherscanBackoff.
+	defaultEtherscanRetries = 2
+	defaultEtherscanBackoff = 500 * time.Millisecond
+	// defaultRateLimit matches the calls per second of a free Etherscan key.
+	defaultRateLimit = 5
 )
 
 var (
 	ErrInvalidWalletAddress = errors.New("invalid ethereum address")
 	ErrNoTransactions       = errors.New("no token transactions found")
+	ErrEmptyAPIKey          = errors.New("api key must not be empty")
+	ErrInvalidBaseURL       = errors.New("invalid explorer base URL")
+	// ErrUpstreamUnavailable reports a transient Etherscan outage: a network
+	// error, a 5xx response, or an HTML maintenance page served in place of
+	// the JSON API. Callers may retry.
+	ErrUpstreamUnavailable = errors.New("etherscan is temporarily unavailable")
+	ErrResponseTooLarge    = errors.New("etherscan response exceeds size limit")
+	// ErrRateLimited reports that Etherscan kept refusing a call for exceeding
+	// the key's rate limit after all retries.
+	ErrRateLimited = errors.New("etherscan rate limit reached")
+	// ErrInvalidAPIKey reports that Etherscan rejected the configured key.
+	ErrInvalidAPIKey = errors.New("invalid etherscan api key")
+	// ErrInvalidBlockRange reports a block range that starts after it ends.
+	ErrInvalidBlockRange = errors.New("invalid block range")
 )
 
 type WalletTracker struct {
-	client  *http.Client
-	baseURL string
-	apiKey  string
+	client          *http.Client
+	customClient    bool
+	baseURL         string
+	chainID         int64
+	apiKey          string
+	httpTimeout     time.Duration
+	maxConnsPerHost int
+	maxBatchSize    int
+	maxRespBytes    int64
+	txPageSize      int
+	maxTxPages      int
+	retries         int
+	retryBackoff    time.Duration
+	rateLimit       int
+	limiter         *rateLimiter
+
+	decimalOverrides map[string]int
+	tokenMetadata    *TokenMetadataCache
+	metadataResolver TokenMetadataResolver
+
+	calls           callGate
+	toolTimeout     time.Duration
+	logger          *slog.Logger
+	balanceStrategy BalanceStrategy
+	cors            corsPolicy
+	metrics         http.Handler
+	retrySink       RetrySink
+
+	rpc        *rpcClient
+	rpcURL     string
+	rpcTimeout time.Duration
+	rpcRetries int
+
+	ens            ENSResolver
+	ensCache       *ensCache
+	ensSem         chan struct{}
+	ensConcurrency int
+	ensCacheSize   int
+	ensCacheTTL    time.Duration
+	ensNegativeTTL time.Duration
+
+	blockHeight BlockHeightProvider
+	prices      PriceProvider
+	cache       Cache
+	spam        *SpamFilter
+	drainers    *DrainerList
 }
 
-func NewWalletTracker(apiKey string) (*WalletTracker, error) {
+func NewWalletTracker(apiKey string, opts ...Option) (*WalletTracker, error) {
 	apiKey = strings.TrimSpace(apiKey)
 	if apiKey == "" {
-		return nil, errors.New("api key must not be empty")
+		return nil, ErrEmptyAPIKey
 	}
 
-	return &WalletTracker{
-		client: &http.Client{
-			Timeout: defaultHTTPTimeout,
-		},
-		baseURL: etherscanBaseURL,
-		apiKey:  apiKey,
-	}, nil
+	tracker := &WalletTracker{
+		baseURL:         etherscanBaseURL,
+		chainID:         defaultChain.ID,
+		apiKey:          apiKey,
+		httpTimeout:     defaultHTTPTimeout,
+		toolTimeout:     defaultToolTimeout,
+		logger:          slog.Default(),
+		balanceStrategy: BalanceFromTransfers,
+		maxConnsPerHost: defaultMaxConnsPerHost,
+		maxBatchSize:    defaultMaxBatchSize,
+		maxRespBytes:    defaultMaxResponseBytes,
+		txPageSize:      tokenTxPageSize,
+		maxTxPages:      defaultMaxTxPages,
+		retries:         defaultEtherscanRetries,
+		retryBackoff:    defaultEtherscanBackoff,
+		rateLimit:       defaultRateLimit,
+		rpcTimeout:      defaultRPCTimeout,
+		rpcRetries:      defaultRPCRetries,
+		ensConcurrency:  defaultENSConcurrency,
+		ensCacheSize:    defaultENSCacheSize,
+		ensCacheTTL:     defaultENSCacheTTL,
+		ensNegativeTTL:  defaultENSNegativeTTL,
+		spam:            NewSpamFilter(nil),
+		drainers:        NewDrainerList(nil),
+	}
+	for _, opt := range opts {
+		opt(tracker)
+	}
+
+	if tracker.client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxConnsPerHost = tracker.maxConnsPerHost
+		transport.MaxIdleConnsPerHost = tracker.maxConnsPerHost
+
+		tracker.client = &http.Client{
+			Timeout:   tracker.httpTimeout,
+			Transport: transport,
+		}
+	}
+	if err := validateBaseURL(tracker.baseURL); err != nil {
+		return nil, err
+	}
+	tracker.limiter = newRateLimiter(tracker.rateLimit)
+	if tracker.rpcURL != "" {
+		tracker.rpc = newRPCClient(tracker.rpcURL, tracker.rpcTimeout, tracker.rpcRetries)
+	}
+	if tracker.metadataResolver == nil && tracker.rpc != nil {
+		tracker.metadataResolver = &rpcDecimalsResolver{rpc: tracker.rpc}
+	}
+	tracker.tokenMetadata = NewTokenMetadataCache(tracker.metadataResolver)
+	if tracker.balanceStrategy == BalanceOnChain && tracker.rpc == nil {
+		return nil, fmt.Errorf("%w: on-chain balances need WithRPCURL", ErrRPCNotConfigured)
+	}
+	if tracker.ens == nil && tracker.rpc != nil {
+		tracker.ens = &rpcENSResolver{rpc: tracker.rpc}
+	}
+	tracker.ensCache = newENSCache(tracker.ensCacheSize, tracker.ensCacheTTL, tracker.ensNegativeTTL)
+	tracker.ensSem = make(chan struct{}, tracker.ensConcurrency)
+	if tracker.blockHeight == nil {
+		// The JSON-RPC endpoint only serves Ethereum mainnet.
+		if tracker.rpc != nil && tracker.chainID == defaultChain.ID {
+			tracker.blockHeight = &rpcBlockHeight{rpc: tracker.rpc}
+		} else {
+			tracker.blockHeight = &etherscanBlockHeight{tracker: tracker, chainID: tracker.chainID}
+		}
+	}
+	tracker.blockHeight = newCachedBlockHeight(tracker.blockHeight, defaultBlockHeightTTL)
+	if tracker.prices != nil {
+		tracker.prices = newCachedPrices(tracker.prices, defaultPriceTTL)
+	}
+	return tracker, nil
+}
+
+// chain returns the chain the tracker queries unless told otherwise.
+func (t *WalletTracker) chain() Chain {
+	for _, c := range supportedChains {
+		if c.ID == t.chainID {
+			return c
+		}
+	}
+	return defaultChain
+}
+
+// requireRPC returns the JSON-RPC client, or ErrRPCNotConfigured when the
+// tracker was built without WithRPCURL.
+func (t *WalletTracker) requireRPC() (*rpcClient, error) {
+	if t.rpc == nil {
+		return nil, ErrRPCNotConfigured
+	}
+	return t.rpc, nil
 }
 
 type TokenBalance struct {
@@ -54,50 +219,493 @@ type TokenBalance struct {
 	Name    string `json:"name"`
 	Symbol  string `json:"symbol"`
 	Balance string `json:"balance"`
+	// RawBalance is the exact balance in the token's base units and Decimals
+	// the scale applied to produce Balance, for consumers that need lossless
+	// values.
+	RawBalance    string `json:"raw_balance"`
+	Decimals      int    `json:"decimals"`
+	TransferCount int    `json:"transfer_count,omitempty"`
+	// SkippedTransfers counts the token's transfers left out of Balance
+	// because their quantity could not be parsed; Balance may then be off.
+	SkippedTransfers int `json:"skipped_transfers,omitempty"`
+	// FirstSeen and LastActivity are the times of the wallet's earliest and
+	// latest transfer of the token, when derived from the transfer history.
+	FirstSeen    *time.Time `json:"first_seen,omitempty"`
+	LastActivity *time.Time `json:"last_activity,omitempty"`
+	// USDValue is the balance's value in US dollars, empty when the token
+	// has no known price or pricing is not configured.
+	USDValue string `json:"usd_value,omitempty"`
+	// PortfolioPct is USDValue as a percentage of the response's TotalUSD,
+	// e.g. "12.50"; empty when the token has no USD value.
+	PortfolioPct string `json:"portfolio_pct,omitempty"`
+	// PriceSource names the provider USDValue's price came from, e.g.
+	// "coingecko", and PriceUpdatedAt is when it fetched the price, which
+	// may be up to the price cache's TTL ago. Both are empty when USDValue
+	// is.
+	PriceSource    string     `json:"price_source,omitempty"`
+	PriceUpdatedAt *time.Time `json:"price_updated_at,omitempty"`
+	// Approximate marks a balance summed from transfers for a token whose
+	// balance also changes without transfers (rebasing or fee-on-transfer),
+	// when it could not be checked on-chain.
+	Approximate bool `json:"approximate,omitempty"`
 }
 
 type WalletResponse struct {
-	Address string         `json:"address"`
-	Tokens  []TokenBalance `json:"tokens"`
+	Address string `json:"address"`
+	// ENSName is the name the wallet was looked up by, when it was given as
+	// an ENS name rather than an address.
+	ENSName string `json:"ens_name,omitempty"`
+	// NativeBalance is the wallet's balance of the chain's native currency
+	// (NativeSymbol, e.g. ETH) in whole units. Like a token's RawBalance and
+	// Decimals, NativeRawBalance holds it exactly in base units (wei) and
+	// NativeDecimals the scale applied. All are empty when not fetched.
+	NativeSymbol     string         `json:"native_symbol,omitempty"`
+	NativeBalance    string         `json:"native_balance,omitempty"`
+	NativeRawBalance string         `json:"native_raw_balance,omitempty"`
+	NativeDecimals   int            `json:"native_decimals,omitempty"`
+	Tokens           []TokenBalance `json:"tokens"`
+	// WrappedNative holds the chain's wrapped native token (e.g. WETH) when
+	// the wrapped-native summary is requested; it is then omitted from Tokens.
+	WrappedNative       *TokenBalance `json:"wrapped_native,omitempty"`
+	SkippedTransactions int           `json:"skipped_transactions,omitempty"`
+	// Block is the block the balances are reported at; zero for the latest.
+	Block uint64 `json:"block,omitempty"`
+	// StartBlock, when nonzero, is the first block of a range (see
+	// InBlockRange): Tokens then hold net changes from it through Block.
+	StartBlock uint64 `json:"start_block,omitempty"`
+	// HiddenSpam counts tokens left out of Tokens as suspected spam.
+	HiddenSpam int `json:"hidden_spam,omitempty"`
+	// TotalUSD sums USDValue over the priced tokens; unpriced tokens and the
+	// native balance are not included.
+	TotalUSD string `json:"total_usd,omitempty"`
+	// Truncated is set when the wallet's transfer history exceeded the page
+	// cap, so balances only reflect the earliest transfers.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// setNativeBalance sets the native balance fields from wei.
+func (r *WalletResponse) setNativeBalance(symbol string, wei *big.Int) {
+	r.NativeSymbol = symbol
+	r.NativeBalance = formatTokenBalance(wei, nativeDecimals)
+	r.NativeRawBalance = wei.String()
+	r.NativeDecimals = nativeDecimals
+}
+
+// TokenMap indexes the response's tokens, including WrappedNative when set,
+// by lowercase contract address. Tokens remains the canonical, ordered form.
+func (r *WalletResponse) TokenMap() map[string]TokenBalance {
+	tokens := make(map[string]TokenBalance, len(r.Tokens)+1)
+	for _, token := range r.Tokens {
+		tokens[strings.ToLower(token.Address)] = token
+	}
+	if r.WrappedNative != nil {
+		tokens[strings.ToLower(r.WrappedNative.Address)] = *r.WrappedNative
+	}
+	return tokens
+}
+
+type queryOptions struct {
+	chain                Chain
+	wrappedNativeSummary bool
+	minBalance           *big.Rat
+	includeSpam          bool
+	// endBlock, when nonzero, reports balances as of that block.
+	endBlock uint64
+	// startBlock, when nonzero, nets only the transfers from that block on.
+	startBlock uint64
+	// sortBy and sortDesc order the tokens after aggregation and pricing.
+	sortBy   string
+	sortDesc bool
+}
+
+// QueryOption tunes a single GetWalletTokens call.
+type QueryOption func(*queryOptions)
+
+// OnChain queries the wallet on the given chain instead of the tracker's
+// configured chain.
+func OnChain(chain Chain) QueryOption {
+	return func(o *queryOptions) {
+		o.chain = chain
+	}
+}
+
+// WithWrappedNativeSummary reports the chain's wrapped native token (WETH,
+// WBNB, ...) separately in WalletResponse.WrappedNative, since it is
+// effectively spendable native currency.
+func WithWrappedNativeSummary() QueryOption {
+	return func(o *queryOptions) {
+		o.wrappedNativeSummary = true
+	}
+}
+
+// WithMinBalance drops tokens whose balance, in whole tokens, is below min,
+// such as dust and spam airdrops. Use parseMinBalance for user input; a nil
+// min keeps every nonzero balance.
+func WithMinBalance(min *big.Rat) QueryOption {
+	return func(o *queryOptions) {
+		o.minBalance = min
+	}
+}
+
+// InBlockRange nets only the transfers in blocks start through end,
+// inclusive; a zero end means the latest block, and InBlockRange(0, 0) the
+// full history. With a nonzero start, the token balances are the wallet's net
+// changes over the range, negative for net outflows, rather than its
+// holdings, so the native balance, on-chain balance checks and USD values,
+// which describe holdings, are left out. A start after end fails the call
+// with ErrInvalidBlockRange.
+func InBlockRange(start, end uint64) QueryOption {
+	return func(o *queryOptions) {
+		o.startBlock = start
+		o.endBlock = end
+	}
+}
+
+// validateBlockRange checks a block range where a zero end means the latest
+// block.
+func validateBlockRange(start, end uint64) error {
+	if end != 0 && start > end {
+		return fmt.Errorf("%w: start block %d is after end block %d", ErrInvalidBlockRange, start, end)
+	}
+	return nil
+}
+
+// IncludeSpam disables the tracker's SpamFilter for this call.
+func IncludeSpam() QueryOption {
+	return func(o *queryOptions) {
+		o.includeSpam = true
+	}
+}
+
+// GetWalletTokens returns the wallet's native and token balances. When a
+// Cache is configured, a recent result for the same chain and address is
+// returned without querying Etherscan.
+func (t *WalletTracker) GetWalletTokens(ctx context.Context, walletAddress string, opts ...QueryOption) (*WalletResponse, error) {
+	q := queryOptions{chain: t.chain()}
+	for _, opt := range opts {
+		opt(&q)
+	}
+	if err := validateBlockRange(q.startBlock, q.endBlock); err != nil {
+		return nil, err
+	}
+
+	walletAddress, ensName, err := t.resolveWallet(ctx, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.cache == nil {
+		resp, err := t.fetchWalletTokens(ctx, walletAddress, ensName, q)
+		if err != nil {
+			return nil, err
+		}
+		return q.sorted(resp), nil
+	}
+	key := walletCacheKey(q, walletAddress)
+	if cached, ok := t.cache.Get(key); ok {
+		resp := cached.clone()
+		resp.ENSName = ensName
+		return q.sorted(resp), nil
+	}
+	resp, err := t.fetchWalletTokens(ctx, walletAddress, ensName, q)
+	if err != nil {
+		return nil, err
+	}
+	t.cache.Set(key, resp.clone())
+	return q.sorted(resp), nil
+}
+
+// WalletService looks up a wallet's token balances. The wallet_tracker tool and
+// the HTTP API depend on it rather than on WalletTracker, its default
+// implementation, so other implementations and decorators adding caching or
+// metrics can be swapped in without touching them. Implementations accept an
+// address or ENS name, must be safe for concurrent use, and report wallet
+// errors with ErrInvalidWalletAddress, ErrENSNameNotFound and the other
+// sentinel errors so that callers can tell them apart.
+type WalletService interface {
+	GetWalletTokens(ctx context.Context, walletAddress string, opts ...QueryOption) (*WalletResponse, error)
+}
+
+var _ WalletService = (*WalletTracker)(nil)
+
+// sorted applies the requested token order. Responses are cached in the
+// default name order, so sorting happens on the way out.
+func (q queryOptions) sorted(resp *WalletResponse) *WalletResponse {
+	if q.sortBy != "" && (q.sortBy != SortByName || q.sortDesc) {
+		sortTokens(resp.Tokens, q.sortBy, q.sortDesc)
+	}
+	return resp
 }
 
-func (t *WalletTracker) GetWalletTokens(ctx context.Context, walletAddress string) (*WalletResponse, error) {
-	if err := validateWalletAddress(walletAddress); err != nil {
+func (t *WalletTracker) fetchWalletTokens(ctx context.Context, walletAddress, ensName string, q queryOptions) (*WalletResponse, error) {
+	txs, truncated, err := t.fetchTokenTransactions(ctx, q.chain.ID, walletAddress, q.startBlock, q.endBlock)
+	if err != nil && !errors.Is(err, ErrNoTransactions) {
 		return nil, err
 	}
 
-	txs, err := t.fetchTokenTransactions(ctx, walletAddress)
+	// Net changes over a range starting after genesis have no native
+	// counterpart: the native balance is a holding.
+	var (
+		native    *big.Int
+		nativeErr error
+	)
+	switch {
+	case q.startBlock != 0:
+	case q.endBlock == 0:
+		native, nativeErr = t.fetchNativeBalance(ctx, q.chain.ID, walletAddress)
+	case t.rpc != nil && q.chain.ID == defaultChain.ID:
+		native, nativeErr = t.fetchNativeBalanceAt(ctx, walletAddress, q.endBlock)
+	}
+	if nativeErr != nil {
+		return nil, fmt.Errorf("fetching native balance: %w", nativeErr)
+	}
+
+	// The JSON-RPC endpoint serves the default chain only; other chains keep
+	// the transfer-derived balances.
+	onChain := t.balanceStrategy == BalanceOnChain && q.chain.ID == defaultChain.ID && q.startBlock == 0
+	t.resolveIncompleteMetadata(ctx, q.chain.ID, txs)
+	tokens, skipped := summarizeTokenBalances(walletAddress, txs, summaryOptions{
+		metadata:         t.tokenMetadata,
+		chainID:          q.chain.ID,
+		decimalOverrides: t.decimalOverrides,
+		minBalance:       q.minBalance,
+		logger:           t.logger,
+		keepEmpty:        onChain,
+	})
+	if onChain {
+		if tokens, err = t.onChainBalances(ctx, walletAddress, tokens, q.endBlock, q.minBalance); err != nil {
+			return nil, err
+		}
+	} else if q.startBlock == 0 {
+		tokens = t.checkUnreliableBalances(ctx, walletAddress, tokens, q.chain, q.endBlock, q.minBalance)
+	}
+	resp := &WalletResponse{
+		Address:             walletAddress,
+		ENSName:             ensName,
+		Block:               q.endBlock,
+		StartBlock:          q.startBlock,
+		Tokens:              tokens,
+		SkippedTransactions: skipped,
+		Truncated:           truncated,
+	}
+	if native != nil {
+		resp.setNativeBalance(q.chain.NativeSymbol, native)
+	}
+	if !q.includeSpam {
+		resp.Tokens, resp.HiddenSpam = t.spam.filter(resp.Tokens)
+	}
+	if q.wrappedNativeSummary {
+		splitWrappedNative(resp, q.chain)
+	}
+	if t.prices != nil && q.startBlock == 0 {
+		t.applyPrices(ctx, q.chain, resp)
+	}
+	return resp, nil
+}
+
+// resolveWallet returns the address to query for input, which is either an
+// address or an ENS name. For a name, the name is returned too.
+func (t *WalletTracker) resolveWallet(ctx context.Context, input string) (address, ensName string, err error) {
+	if !isENSName(input) {
+		return input, "", ValidateAddress(input)
+	}
+	ensName = normalizeENSName(input)
+	address, err = t.ResolveENS(ctx, ensName)
 	if err != nil {
-		if errors.Is(err, ErrNoTransactions) {
-			return &WalletResponse{
-				Address: walletAddress,
-				Tokens:  []TokenBalance{},
-			}, nil
+		return "", "", fmt.Errorf("resolving %s: %w", ensName, err)
+	}
+	return address, ensName, nil
+}
+
+// splitWrappedNative moves the chain's wrapped native token out of Tokens and
+// into WrappedNative. A zero entry is reported when the wallet holds none.
+func splitWrappedNative(resp *WalletResponse, chain Chain) {
+	if chain.WrappedNative == "" {
+		return
+	}
+
+	wrapped := TokenBalance{
+		Address:    chain.WrappedNative,
+		Symbol:     "W" + chain.NativeSymbol,
+		Balance:    "0",
+		RawBalance: "0",
+		Decimals:   nativeDecimals,
+	}
+	tokens := resp.Tokens[:0]
+	for _, token := range resp.Tokens {
+		if strings.EqualFold(token.Address, chain.WrappedNative) {
+			wrapped = token
+			continue
 		}
+		tokens = append(tokens, token)
+	}
+	resp.Tokens = tokens
+	resp.WrappedNative = &wrapped
+}
+
+// fetchNativeBalance returns the wallet's balance of the chain's native
+// currency in wei.
+func (t *WalletTracker) fetchNativeBalance(ctx context.Context, chainID int64, walletAddress string) (*big.Int, error) {
+	params := url.Values{}
+	params.Set("module", "account")
+	params.Set("action", "balance")
+	params.Set("address", walletAddress)
+	params.Set("tag", "latest")
+
+	return t.queryBalance(ctx, chainID, params)
+}
+
+// fetchNativeBalanceAt returns the wallet's native balance in wei as of block,
+// read over JSON-RPC since Etherscan only serves historical balances to API
+// Pro keys.
+func (t *WalletTracker) fetchNativeBalanceAt(ctx context.Context, walletAddress string, block uint64) (*big.Int, error) {
+	var raw string
+	if err := t.rpc.call(ctx, "eth_getBalance", []any{walletAddress, fmt.Sprintf("0x%x", block)}, &raw); err != nil {
+		return nil, err
+	}
+	return parseHexBig(raw)
+}
+
+// queryBalance runs a balance-style action whose result is a single decimal
+// integer string.
+func (t *WalletTracker) queryBalance(ctx context.Context, chainID int64, params url.Values) (*big.Int, error) {
+	apiResp, err := t.queryEtherscan(ctx, chainID, params)
+	if err != nil {
 		return nil, err
 	}
 
-	tokens := summarizeTokenBalances(walletAddress, txs)
-	return &WalletResponse{
-		Address: walletAddress,
-		Tokens:  tokens,
-	}, nil
+	if err := apiResp.statusErr(); err != nil {
+		return nil, err
+	}
+	var raw string
+	if err := json.Unmarshal(apiResp.Result, &raw); err != nil {
+		return nil, fmt.Errorf("parsing balance: %w", err)
+	}
+
+	balance, ok := new(big.Int).SetString(strings.TrimSpace(raw), 10)
+	if !ok {
+		return nil, fmt.Errorf("parsing balance %q", raw)
+	}
+	return balance, nil
+}
+
+// fetchTokenTransactions returns the wallet's complete token transfer
+// history, oldest first, paged by block with fetchForward. The history starts
+// at startBlock, and a nonzero endBlock stops it at that block. The bool
+// reports a truncated history.
+func (t *WalletTracker) fetchTokenTransactions(ctx context.Context, chainID int64, walletAddress string, startBlock, endBlock uint64) ([]tokenTransaction, bool, error) {
+	params := accountListParams("tokentx", walletAddress)
+	if endBlock != 0 {
+		params.Set("endblock", strconv.FormatUint(endBlock, 10))
+	}
+	return fetchForward(ctx, t, chainID, params, "startblock", startBlock, t.txPageSize, tokenTransaction.blockNumber)
+}
+
+// fetchNormalTransactions returns the wallet's normal transactions, oldest
+// first, paged by block with fetchForward. The bool reports a truncated
+// history.
+func (t *WalletTracker) fetchNormalTransactions(ctx context.Context, chainID int64, walletAddress string) ([]normalTransaction, bool, error) {
+	return fetchForward(ctx, t, chainID, accountListParams("txlist", walletAddress), "startblock", 0, t.txPageSize, normalTransaction.blockNumber)
+}
+
+func accountListParams(action, walletAddress string) url.Values {
+	params := url.Values{}
+	params.Set("module", "account")
+	params.Set("action", action)
+	params.Set("address", walletAddress)
+	params.Set("startblock", "0")
+	params.Set("endblock", "999999999")
+	params.Set("sort", "asc")
+	return params
+}
+
+// fetchList queries an Etherscan list endpoint and decodes the result array into out.
+func (t *WalletTracker) fetchList(ctx context.Context, chainID int64, params url.Values, out any) error {
+	apiResp, err := t.queryEtherscan(ctx, chainID, params)
+	if err != nil {
+		return err
+	}
+
+	// The status is inspected before the result: on failure the result holds
+	// the reason as text rather than a list.
+	if err := apiResp.statusErr(); err != nil {
+		return err
+	}
+	return apiResp.decodeList(out)
+}
+
+// queryEtherscan runs one Etherscan call, retrying when it is rate limited:
+// the free tier answers bursts with HTTP 429 or with a "Max rate limit
+// reached" result. Other failures are returned immediately.
+func (t *WalletTracker) queryEtherscan(ctx context.Context, chainID int64, params url.Values) (*etherscanResponse, error) {
+	var lastErr error
+	for attempt := 0; attempt <= t.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(retryDelay(t.retryBackoff, attempt)):
+			}
+		}
+
+		apiResp, err := t.queryEtherscanOnce(ctx, chainID, params)
+		if err == nil {
+			return apiResp, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		reason := retryReason(err)
+		if t.retrySink != nil {
+			t.retrySink.ObserveRetry(reason)
+		}
+		if reason != RetryRateLimited {
+			return nil, err
+		}
+		if attempt < t.retries {
+			t.logger.Debug("Retrying etherscan request", "reason", reason, "attempt", attempt+1, "error", err)
+		}
+	}
+	return nil, lastErr
+}
+
+// validateBaseURL accepts absolute http(s) URLs, the only kind queryEtherscan
+// can call.
+func validateBaseURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBaseURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q is not an absolute http(s) URL", ErrInvalidBaseURL, raw)
+	}
+	return nil
+}
+
+// retryDelay doubles base for every attempt after the first and picks a
+// random point in the upper half, so that concurrent callers spread out.
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	d := base << (attempt - 1)
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
 }
 
-func (t *WalletTracker) fetchTokenTransactions(ctx context.Context, walletAddress string) ([]tokenTransaction, error) {
+func (t *WalletTracker) queryEtherscanOnce(ctx context.Context, chainID int64, params url.Values) (*etherscanResponse, error) {
 	endpoint, err := url.Parse(t.baseURL)
 	if err != nil {
 		return nil, fmt.Errorf("parsing etherscan base URL: %w", err)
 	}
 
 	query := endpoint.Query()
-	query.Set("chainid", "1")
-	query.Set("module", "account")
-	query.Set("action", "tokentx")
-	query.Set("address", walletAddress)
-	query.Set("startblock", "0")
-	query.Set("endblock", "999999999")
-	query.Set("sort", "asc")
+	query.Set("chainid", strconv.FormatInt(chainID, 10))
+	for key, values := range params {
+		for _, v := range values {
+			query.Add(key, v)
+		}
+	}
 	query.Set("apikey", t.apiKey)
 	endpoint.RawQuery = query.Encode()
 
@@ -106,38 +714,79 @@ func (t *WalletTracker) fetchTokenTransactions(ctx context.Context, walletAddres
 		return nil, fmt.Errorf("creating etherscan request: %w", err)
 	}
 
+	if err := t.limiter.wait(ctx); err != nil {
+		return nil, fmt.Errorf("waiting for etherscan rate limit: %w", err)
+	}
 	resp, err := t.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("calling etherscan: %w", err)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("calling etherscan: %w", err)
+		}
+		return nil, fmt.Errorf("%w: calling etherscan: %w", ErrUpstreamUnavailable, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			return nil, fmt.Errorf("%w: status 429: %s", ErrRateLimited, strings.TrimSpace(string(body)))
+		case resp.StatusCode >= 500:
+			return nil, fmt.Errorf("%w: status %d: %s", ErrUpstreamUnavailable, resp.StatusCode, strings.TrimSpace(string(body)))
+		}
 		return nil, fmt.Errorf("etherscan responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
 
+	body := bufio.NewReader(&cappedReader{r: resp.Body, remaining: t.maxRespBytes})
+	if isHTMLResponse(resp.Header.Get("Content-Type"), body) {
+		snippet, _ := io.ReadAll(io.LimitReader(body, 512))
+		return nil, fmt.Errorf("%w: received an HTML page instead of JSON: %s", ErrUpstreamUnavailable, strings.TrimSpace(string(snippet)))
+	}
+
 	var apiResp etherscanResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+	if err := json.NewDecoder(body).Decode(&apiResp); err != nil {
 		return nil, fmt.Errorf("decoding etherscan response: %w", err)
 	}
-
-	txs, err := apiResp.tokenTransactions()
-	if err != nil {
-		if errors.Is(err, ErrNoTransactions) {
-			return nil, ErrNoTransactions
-		}
+	// Rate-limit refusals fail here so that queryEtherscan retries them; any
+	// other status is left to the caller.
+	if err := apiResp.statusErr(); errors.Is(err, ErrRateLimited) {
 		return nil, err
 	}
+	return &apiResp, nil
+}
 
-	if apiResp.Status == "0" {
-		if strings.EqualFold(apiResp.Message, "No transactions found") {
-			return nil, ErrNoTransactions
-		}
-		return nil, fmt.Errorf("etherscan api error: %s", apiResp.Message)
+// cappedReader fails with ErrResponseTooLarge once more than remaining bytes
+// have been read, instead of silently truncating like io.LimitReader.
+type cappedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	if c.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > c.remaining+1 {
+		p = p[:c.remaining+1]
+	}
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	if c.remaining < 0 {
+		return n, ErrResponseTooLarge
 	}
+	return n, err
+}
 
-	return txs, nil
+// isHTMLResponse reports whether the body is an HTML page, judged by the
+// Content-Type header or, since outage pages are sometimes served with a JSON
+// content type, by a leading '<'. It only peeks, leaving the body unread.
+func isHTMLResponse(contentType string, body *bufio.Reader) bool {
+	if strings.Contains(strings.ToLower(contentType), "text/html") {
+		return true
+	}
+	peeked, _ := body.Peek(512)
+	trimmed := bytes.TrimLeft(peeked, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '<'
 }
 
 type etherscanResponse struct {
@@ -146,27 +795,60 @@ type etherscanResponse struct {
 	Result  json.RawMessage `json:"result"`
 }
 
-func (r etherscanResponse) tokenTransactions() ([]tokenTransaction, error) {
+// statusErr maps a "0" status, or a "NOTOK" message whatever the status, to
+// an error. The reason is usually in the result text, the message being a
+// bare "NOTOK"; known reasons map to ErrNoTransactions, ErrRateLimited,
+// ErrInvalidAPIKey and ErrInvalidWalletAddress so callers can tell them apart
+// with errors.Is.
+func (r etherscanResponse) statusErr() error {
+	if r.Status != "0" && !strings.EqualFold(r.Message, "NOTOK") {
+		return nil
+	}
+	// List actions report "no results" with an empty array result.
+	var text string
+	_ = json.Unmarshal(r.Result, &text)
+	reason := strings.ToLower(firstNonEmpty(text, r.Message))
+
+	switch {
+	case strings.EqualFold(r.Message, "No transactions found") || strings.EqualFold(text, "No transactions found"),
+		strings.EqualFold(r.Message, "No records found"):
+		return ErrNoTransactions
+	case strings.Contains(reason, "rate limit"):
+		return fmt.Errorf("%w: %s", ErrRateLimited, text)
+	case strings.Contains(reason, "api key"):
+		return fmt.Errorf("%w: %s", ErrInvalidAPIKey, text)
+	case strings.Contains(reason, "invalid address"):
+		return fmt.Errorf("%w: %s", ErrInvalidWalletAddress, text)
+	case text == "":
+		return fmt.Errorf("etherscan api error: %s", r.Message)
+	default:
+		return fmt.Errorf("etherscan api error: %s: %s", r.Message, text)
+	}
+}
+
+func (r etherscanResponse) decodeList(out any) error {
 	if len(r.Result) == 0 {
-		return []tokenTransaction{}, nil
+		return nil
 	}
 
 	var text string
 	if err := json.Unmarshal(r.Result, &text); err == nil {
 		if strings.EqualFold(text, "No transactions found") {
-			return nil, ErrNoTransactions
+			return ErrNoTransactions
 		}
-		return nil, fmt.Errorf("unexpected result text: %s", text)
+		return fmt.Errorf("unexpected result text: %s", text)
 	}
 
-	var txs []tokenTransaction
-	if err := json.Unmarshal(r.Result, &txs); err != nil {
-		return nil, fmt.Errorf("parsing token transactions: %w", err)
+	if err := json.Unmarshal(r.Result, out); err != nil {
+		return fmt.Errorf("parsing etherscan result: %w", err)
 	}
-	return txs, nil
+	return nil
 }
 
 type tokenTransaction struct {
+	Hash             string `json:"hash"`
+	BlockNumber      string `json:"blockNumber"`
+	TimeStamp        string `json:"timeStamp"`
 	ContractAddress  string `json:"contractAddress"`
 	TokenName        string `json:"tokenName"`
 	TokenNameAlt     string `json:"TokenName"`
@@ -180,6 +862,34 @@ type tokenTransaction struct {
 	To               string `json:"to"`
 }
 
+type normalTransaction struct {
+	Hash        string `json:"hash"`
+	BlockNumber string `json:"blockNumber"`
+	TimeStamp   string `json:"timeStamp"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Value       string `json:"value"`
+	IsError     string `json:"isError"`
+}
+
+func (tx normalTransaction) blockNumber() (uint64, error) {
+	return parseBlockNumber(tx.BlockNumber)
+}
+
+func (t tokenTransaction) blockNumber() (uint64, error) {
+	return parseBlockNumber(t.BlockNumber)
+}
+
+// parseBlockNumber parses the decimal block numbers of Etherscan's account
+// lists.
+func parseBlockNumber(s string) (uint64, error) {
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing block number %q: %w", s, err)
+	}
+	return n, nil
+}
+
 func (t tokenTransaction) displayName() string {
 	if t.TokenName != "" {
 		return t.TokenName
@@ -203,7 +913,12 @@ func (t tokenTransaction) displaySymbol() string {
 	return ""
 }
 
-func (t tokenTransaction) decimals() int {
+// decimals resolves the token's decimals. An explicit override for the
+// contract takes precedence over the decimals reported on the transfer.
+func (t tokenTransaction) decimals(overrides map[string]int) int {
+	if d, ok := overrides[strings.ToLower(t.ContractAddress)]; ok {
+		return d
+	}
 	if raw := firstNonEmpty(t.TokenDecimal, t.TokenDecimalAlt); raw != "" {
 		if parsed, err := strconv.Atoi(raw); err == nil {
 			return parsed
@@ -225,70 +940,195 @@ func (t tokenTransaction) quantity() *big.Int {
 	return value
 }
 
+func (t tokenTransaction) timestamp() time.Time {
+	return parseUnixTimestamp(t.TimeStamp)
+}
+
 type tokenAggregate struct {
-	address  string
-	name     string
-	symbol   string
-	decimals int
-	balance  *big.Int
+	address   string
+	meta      TokenMetadata
+	balance   *big.Int
+	transfers int
+	skipped   int
+	firstSeen time.Time
+	lastSeen  time.Time
 }
 
-func summarizeTokenBalances(walletAddress string, txs []tokenTransaction) []TokenBalance {
+// touch records a transfer at ts in the token's activity window; transfers
+// without a usable timestamp are left out of it.
+func (a *tokenAggregate) touch(ts time.Time) {
+	if ts.IsZero() {
+		return
+	}
+	if a.firstSeen.IsZero() || ts.Before(a.firstSeen) {
+		a.firstSeen = ts
+	}
+	if ts.After(a.lastSeen) {
+		a.lastSeen = ts
+	}
+}
+
+// summaryOptions tunes how summarizeTokenBalances nets transfers.
+type summaryOptions struct {
+	decimalOverrides map[string]int
+	// minBalance drops tokens whose balance, in whole tokens, is below it.
+	minBalance *big.Rat
+	// logger receives per-transaction diagnostics; nil discards them.
+	logger *slog.Logger
+	// keepEmpty keeps tokens whose transfers net to zero or less, for
+	// callers that look the balances up elsewhere.
+	keepEmpty bool
+	// metadata, when set, seeds and records the tokens' metadata on chainID,
+	// so a token keeps the name and decimals it was first seen with.
+	metadata *TokenMetadataCache
+	chainID  int64
+}
+
+// isNativePseudoContract reports contract addresses that some Etherscan
+// variants use for native-token entries in tokentx: empty, the zero address,
+// or the 0xEeee...EEeE placeholder.
+func isNativePseudoContract(contract string) bool {
+	contract = strings.ToLower(strings.TrimSpace(contract))
+	switch contract {
+	case "", "0x", "0x0000000000000000000000000000000000000000", "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee":
+		return true
+	}
+	return false
+}
+
+// summarizeTokenBalances nets the wallet's transfers per token contract. It also
+// returns how many transactions were skipped because their quantity could not
+// be parsed, so callers can flag potentially incomplete balances.
+func summarizeTokenBalances(walletAddress string, txs []tokenTransaction, opts summaryOptions) ([]TokenBalance, int) {
 	if len(txs) == 0 {
-		return []TokenBalance{}
+		return []TokenBalance{}, 0
 	}
 
 	wallet := strings.ToLower(walletAddress)
 	aggregates := make(map[string]*tokenAggregate)
+	skipped := 0
 
 	for _, tx := range txs {
-		qty := tx.quantity()
-		if qty == nil {
-			log.Printf("Skipping transaction with invalid quantity for contract %s", tx.ContractAddress)
+		if isNativePseudoContract(tx.ContractAddress) {
+			// Native movements reported through tokentx are not tokens; the
+			// native balance comes from the normal transaction list instead.
 			continue
 		}
 
-		agg, ok := aggregates[tx.ContractAddress]
+		// Etherscan does not report contracts in a consistent case, so
+		// transfers are netted under the lowercased address.
+		key := strings.ToLower(tx.ContractAddress)
+		agg, ok := aggregates[key]
 		if !ok {
 			agg = &tokenAggregate{
-				address:  tx.ContractAddress,
-				name:     tx.displayName(),
-				symbol:   tx.displaySymbol(),
-				decimals: tx.decimals(),
-				balance:  big.NewInt(0),
+				address: displayAddress(tx.ContractAddress),
+				balance: big.NewInt(0),
 			}
-			aggregates[tx.ContractAddress] = agg
+			if opts.metadata != nil {
+				agg.meta, _ = opts.metadata.Get(opts.chainID, tx.ContractAddress)
+			}
+			aggregates[key] = agg
+		}
+		agg.meta = agg.meta.merge(tx.metadata())
+
+		qty := tx.quantity()
+		if qty == nil {
+			if opts.logger != nil {
+				opts.logger.Debug("Skipping transaction with invalid quantity", "contract", tx.ContractAddress, "hash", tx.Hash,
+					"value", firstNonEmpty(tx.TokenQuantity, tx.TokenQuantityAlt))
+			}
+			agg.skipped++
+			skipped++
+			continue
 		}
 
 		to := strings.ToLower(tx.To)
 		from := strings.ToLower(tx.From)
 
 		switch {
+		case to == wallet && from == wallet:
+			// Self-transfers leave the balance untouched but still count as activity.
 		case to == wallet:
 			agg.balance.Add(agg.balance, qty)
 		case from == wallet:
 			agg.balance.Sub(agg.balance, qty)
+		default:
+			continue
 		}
+		agg.transfers++
+		agg.touch(tx.timestamp())
 	}
 
 	result := make([]TokenBalance, 0, len(aggregates))
 	for _, agg := range aggregates {
-		if agg.balance.Sign() == 0 {
+		if opts.metadata != nil {
+			agg.meta = opts.metadata.Remember(opts.chainID, agg.address, agg.meta)
+		}
+		decimals := agg.meta.Decimals
+		if d, ok := opts.decimalOverrides[strings.ToLower(agg.address)]; ok {
+			decimals = d
+		}
+		// Tokens with skipped transfers are kept even when they net to
+		// nothing, since their real balance is unknown.
+		if !opts.keepEmpty && agg.skipped == 0 && (agg.balance.Sign() == 0 || belowMinBalance(agg.balance, decimals, opts.minBalance)) {
 			continue
 		}
-		result = append(result, TokenBalance{
-			Address: agg.address,
-			Name:    agg.name,
-			Symbol:  agg.symbol,
-			Balance: formatTokenBalance(agg.balance, agg.decimals),
-		})
+		token := TokenBalance{
+			Address:          agg.address,
+			Name:             agg.meta.displayName(agg.address),
+			Symbol:           agg.meta.Symbol,
+			Balance:          formatTokenBalance(agg.balance, decimals),
+			RawBalance:       agg.balance.String(),
+			Decimals:         decimals,
+			TransferCount:    agg.transfers,
+			SkippedTransfers: agg.skipped,
+		}
+		if !agg.firstSeen.IsZero() {
+			first, last := agg.firstSeen, agg.lastSeen
+			token.FirstSeen, token.LastActivity = &first, &last
+		}
+		result = append(result, token)
 	}
 
+	// Ties on the name are broken by contract so the order, and with it HTTP
+	// pagination, is stable across calls.
 	sort.Slice(result, func(i, j int) bool {
-		return strings.ToLower(result[i].Name) < strings.ToLower(result[j].Name)
+		return compareTokenNames(result[i], result[j]) < 0
 	})
 
-	return result
+	return result, skipped
+}
+
+// belowMinBalance compares a raw balance, scaled by decimals, with min exactly.
+// A nil min never filters.
+func belowMinBalance(balance *big.Int, decimals int, min *big.Rat) bool {
+	if min == nil {
+		return false
+	}
+	value := new(big.Rat).SetInt(balance)
+	if decimals > 0 {
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+		value.Quo(value, new(big.Rat).SetInt(scale))
+	}
+	return value.Cmp(min) < 0
+}
+
+// parseMinBalance parses a minimum balance given as a plain non-negative
+// decimal such as "0.01". Empty and zero thresholds yield nil, which keeps
+// every nonzero balance.
+func parseMinBalance(raw string) (*big.Rat, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	min, ok := new(big.Rat).SetString(raw)
+	if !ok || strings.ContainsAny(raw, "/eE") || min.Sign() < 0 {
+		return nil, fmt.Errorf("invalid minimum balance %q: expected a non-negative decimal such as 0.01", raw)
+	}
+	if min.Sign() == 0 {
+		return nil, nil
+	}
+	return min, nil
 }
 
 func formatTokenBalance(balance *big.Int, decimals int) string {
@@ -333,54 +1173,245 @@ func firstNonEmpty(values ...string) string {
 	return ""
 }
 
-func validateWalletAddress(address string) error {
-	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
-		return ErrInvalidWalletAddress
+func parseUnixTimestamp(raw string) time.Time {
+	secs, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil || secs <= 0 {
+		return time.Time{}
 	}
-	return nil
+	return time.Unix(secs, 0).UTC()
 }
 
-func walletHandler(tracker *WalletTracker) http.HandlerFunc {
+// walletHandler serves /wallet/{address} and /wallet/{chain}/{address} from
+// wallets, on the home chain unless the path names another.
+func walletHandler(wallets WalletService, home Chain, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		walletAddress := vars["address"]
 
-		if err := validateWalletAddress(walletAddress); err != nil {
-			log.Printf("Invalid Ethereum address format received: %s", walletAddress)
+		chain := home
+		if raw, ok := vars["chain"]; ok {
+			c, err := LookupChain(raw)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Unsupported chain %q", raw), http.StatusBadRequest)
+				return
+			}
+			chain = c
+		}
+
+		if err := ValidateAddress(walletAddress); err != nil && !isENSName(walletAddress) {
+			logger.Info("Invalid Ethereum address format received", "address", walletAddress)
 			http.Error(w, "Invalid Ethereum address format. Expected 42 characters starting with 0x", http.StatusBadRequest)
 			return
 		}
 
-		walletData, err := tracker.GetWalletTokens(r.Context(), walletAddress)
+		sortBy, err := parseSortBy(r.URL.Query().Get("sort_by"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid sort_by parameter: %v", err), http.StatusBadRequest)
+			return
+		}
+		sortDesc := false
+		if raw := r.URL.Query().Get("sort_desc"); raw != "" {
+			if sortDesc, err = strconv.ParseBool(raw); err != nil {
+				http.Error(w, fmt.Sprintf("Invalid sort_desc parameter %q", raw), http.StatusBadRequest)
+				return
+			}
+		}
+		sortOpt := SortTokens(sortBy, sortDesc)
+
+		limit, offset, err := parsePageParams(r.URL.Query())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid pagination parameters: %v", err), http.StatusBadRequest)
+			return
+		}
+		paginated := limit > 0 || offset > 0
+
+		format := r.URL.Query().Get("format")
+		if format != "" && format != FormatJSON && format != FormatCSV {
+			http.Error(w, fmt.Sprintf("Unsupported format %q. Expected json or csv", format), http.StatusBadRequest)
+			return
+		}
+
+		if r.URL.Query().Has("chains") {
+			if format == FormatCSV {
+				http.Error(w, "The csv format is not available for multi-chain queries", http.StatusBadRequest)
+				return
+			}
+			if paginated {
+				http.Error(w, "limit and offset are not available for multi-chain queries", http.StatusBadRequest)
+				return
+			}
+			if _, ok := vars["chain"]; ok {
+				http.Error(w, "Use either a chain in the path or the chains query parameter, not both", http.StatusBadRequest)
+				return
+			}
+			multiChainHandler(wallets, logger, w, r, walletAddress, sortOpt)
+			return
+		}
+
+		walletData, err := wallets.GetWalletTokens(r.Context(), walletAddress, OnChain(chain), sortOpt)
 		if err != nil {
 			if errors.Is(err, ErrNoTransactions) {
 				walletData = &WalletResponse{Address: walletAddress, Tokens: []TokenBalance{}}
 			} else if errors.Is(err, ErrInvalidWalletAddress) {
 				http.Error(w, "Invalid Ethereum address format. Expected 42 characters starting with 0x", http.StatusBadRequest)
 				return
+			} else if errors.Is(err, ErrENSNameNotFound) {
+				http.Error(w, fmt.Sprintf("ENS name %q does not resolve to an address", walletAddress), http.StatusNotFound)
+				return
+			} else if r.Context().Err() != nil {
+				// The client went away; every upstream call derives from its
+				// context, so there is nothing left to do or report.
+				logger.Info("Request cancelled", "address", walletAddress, "error", r.Context().Err())
+				return
 			} else {
-				log.Printf("Error fetching wallet data for address %s: %v", walletAddress, err)
-				http.Error(w, "Failed to fetch wallet token data. Please try again later.", http.StatusInternalServerError)
+				logger.Error("Error fetching wallet data", "address", walletAddress, "error", err)
+				writeUpstreamError(w, err)
+				return
+			}
+		}
+
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(walletData.Tokens)))
+		if paginated {
+			walletData.Tokens = paginateTokens(walletData.Tokens, limit, offset)
+		}
+
+		if format == FormatCSV {
+			var buf bytes.Buffer
+			if err := EncodeCSV(&buf, walletData); err != nil {
+				logger.Error("Error encoding CSV response", "address", walletAddress, "error", err)
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 				return
 			}
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "wallet-"+walletData.Address+".csv"))
+			w.Write(buf.Bytes())
+			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(walletData); err != nil {
-			log.Printf("Error encoding JSON response for address %s: %v", walletAddress, err)
+			logger.Error("Error encoding JSON response", "address", walletAddress, "error", err)
 			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		}
 	}
 }
 
-func setupRoutes(tracker *WalletTracker) *mux.Router {
+// multiChainHandler serves /wallet/{address}?chains=1,137,... with the
+// combined portfolio across the listed chains.
+func multiChainHandler(wallets WalletService, logger *slog.Logger, w http.ResponseWriter, r *http.Request, walletAddress string, opts ...QueryOption) {
+	chains, err := parseChainList(r.URL.Query().Get("chains"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid chains parameter: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	walletData, err := multiChainTokens(r.Context(), wallets, walletAddress, chains, opts...)
+	if err != nil {
+		if r.Context().Err() != nil {
+			logger.Info("Request cancelled", "address", walletAddress, "error", r.Context().Err())
+			return
+		}
+		logger.Error("Error fetching multi-chain wallet data", "address", walletAddress, "error", err)
+		writeUpstreamError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(walletData); err != nil {
+		logger.Error("Error encoding JSON response", "address", walletAddress, "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// parsePageParams reads the optional limit and offset query parameters. A
+// zero limit means no limit.
+func parsePageParams(query url.Values) (limit, offset int, err error) {
+	if raw := query.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 {
+			return 0, 0, fmt.Errorf("limit %q is not a positive integer", raw)
+		}
+	}
+	if raw := query.Get("offset"); raw != "" {
+		if offset, err = strconv.Atoi(raw); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("offset %q is not a non-negative integer", raw)
+		}
+	}
+	return limit, offset, nil
+}
+
+// paginateTokens returns the page of tokens starting at offset, at most limit
+// long when limit is positive.
+func paginateTokens(tokens []TokenBalance, limit, offset int) []TokenBalance {
+	if offset >= len(tokens) {
+		return []TokenBalance{}
+	}
+	tokens = tokens[offset:]
+	if limit > 0 && limit < len(tokens) {
+		tokens = tokens[:limit]
+	}
+	return tokens
+}
+
+// writeUpstreamError answers a failed lookup with a status that tells clients
+// whether retrying can help: 429 while rate limited, 503 during an Etherscan
+// outage, and 500 otherwise, including a rejected API key, which only the
+// operator can fix.
+func writeUpstreamError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrRateLimited):
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "Etherscan rate limit reached. Please retry shortly.", http.StatusTooManyRequests)
+	case errors.Is(err, ErrUpstreamUnavailable):
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "Etherscan is temporarily unavailable. Please try again later.", http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "Failed to fetch wallet token data. Please try again later.", http.StatusInternalServerError)
+	}
+}
+
+// setupRoutes builds the HTTP API. Wallet lookups go through wallets, which is
+// usually tracker itself; the readiness check, CORS policy and metrics
+// endpoint come from tracker.
+func setupRoutes(tracker *WalletTracker, wallets WalletService) *mux.Router {
+	handler := walletHandler(wallets, tracker.chain(), tracker.logger)
 	r := mux.NewRouter()
-	r.HandleFunc("/wallet/{address}", walletHandler(tracker)).Methods("GET")
+	// OPTIONS is routed so the CORS middleware can answer preflights; it never
+	// reaches the handlers.
+	r.HandleFunc("/wallet/{address}", handler).Methods("GET", "OPTIONS")
+	r.HandleFunc("/wallet/{chain}/{address}", handler).Methods("GET", "OPTIONS")
+	r.HandleFunc("/healthz", healthzHandler).Methods("GET")
+	r.HandleFunc("/readyz", readyzHandler(newReadinessCheck(tracker, defaultReadyTTL))).Methods("GET")
+	if tracker.metrics != nil {
+		r.Handle("/metrics", tracker.metrics).Methods("GET")
+	}
+	r.Use(tracker.cors.middleware())
 	return r
 }
 
-func startServer(tracker *WalletTracker) {
-	router := setupRoutes(tracker)
-	fmt.Println("Starting server on :8080")
-	log.Fatal(http.ListenAndServe(":8080", router))
+// startServer serves the HTTP API on addr in the background, or on :8080 when
+// addr is empty. The listener is bound before returning so an unusable address
+// fails here; stop the server with Shutdown.
+func startServer(tracker *WalletTracker, wallets WalletService, addr string) (*http.Server, error) {
+	if addr == "" {
+		addr = defaultListenAddr
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	srv := &http.Server{
+		Addr:              listener.Addr().String(),
+		Handler:           setupRoutes(tracker, wallets),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Log rather than print: stdout carries the MCP protocol.
+	tracker.logger.Info("HTTP server listening", "addr", srv.Addr)
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			tracker.logger.Error("HTTP server error", "error", err)
+		}
+	}()
+	return srv, nil
 }
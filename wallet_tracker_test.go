@@ -1,9 +1,23 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestGetWalletTokens(t *testing.T) {
@@ -33,3 +47,978 @@ func TestGetWalletTokens(t *testing.T) {
 		t.Logf("  - %s (%s): %s (Contract: %s)", token.Name, token.Symbol, token.Balance, token.Address)
 	}
 }
+
+func TestNewWalletTrackerEmptyAPIKey(t *testing.T) {
+	for _, key := range []string{"", "   "} {
+		if _, err := NewWalletTracker(key); !errors.Is(err, ErrEmptyAPIKey) {
+			t.Fatalf("NewWalletTracker(%q): expected ErrEmptyAPIKey, got %v", key, err)
+		}
+	}
+}
+
+func newTestTracker(t *testing.T, handler http.HandlerFunc) *WalletTracker {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	// The fake Etherscan has no rate limit to respect.
+	tracker, err := NewWalletTracker("test-key", WithBaseURL(srv.URL), WithRateLimit(0))
+	if err != nil {
+		t.Fatalf("Failed to create wallet tracker: %v", err)
+	}
+	return tracker
+}
+
+// withNativeBalance answers Etherscan's account/balance action with wei and
+// passes every other request to next.
+func withNativeBalance(wei string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("action") == "balance" {
+			fmt.Fprintf(w, `{"status":"1","message":"OK","result":%q}`, wei)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func TestSummarizeTokenBalancesSelfTransfer(t *testing.T) {
+	wallet := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	other := "0x1111111111111111111111111111111111111111"
+	contract := "0xc0ffee0000000000000000000000000000000000"
+
+	txs := []tokenTransaction{
+		{ContractAddress: contract, TokenName: "Test", TokenSymbol: "TST", TokenDecimal: "0", TokenQuantity: "10", From: other, To: wallet},
+		{ContractAddress: contract, TokenName: "Test", TokenSymbol: "TST", TokenDecimal: "0", TokenQuantity: "4", From: wallet, To: strings.ToLower(wallet)},
+	}
+
+	tokens, _ := summarizeTokenBalances(wallet, txs, summaryOptions{})
+	if len(tokens) != 1 {
+		t.Fatalf("expected 1 token, got %d", len(tokens))
+	}
+	if tokens[0].Balance != "10" {
+		t.Fatalf("self-transfer changed balance: got %s, want 10", tokens[0].Balance)
+	}
+	if tokens[0].TransferCount != 2 {
+		t.Fatalf("self-transfer not counted: got %d transfers, want 2", tokens[0].TransferCount)
+	}
+}
+
+func TestWalletRouteChain(t *testing.T) {
+	var gotChainID string
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		gotChainID = r.URL.Query().Get("chainid")
+		fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+	}))
+	router := setupRoutes(tracker, tracker)
+
+	tests := []struct {
+		path        string
+		wantStatus  int
+		wantChainID string
+	}{
+		{path: "/wallet/" + testWalletA, wantStatus: http.StatusOK, wantChainID: "1"},
+		{path: "/wallet/polygon/" + testWalletA, wantStatus: http.StatusOK, wantChainID: "137"},
+		{path: "/wallet/42161/" + testWalletA, wantStatus: http.StatusOK, wantChainID: "42161"},
+		{path: "/wallet/dogechain/" + testWalletA, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		gotChainID = ""
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != tt.wantStatus {
+			t.Fatalf("%s: expected status %d, got %d", tt.path, tt.wantStatus, rec.Code)
+		}
+		if gotChainID != tt.wantChainID {
+			t.Fatalf("%s: expected chainid %q, got %q", tt.path, tt.wantChainID, gotChainID)
+		}
+	}
+}
+
+func TestSummarizeTokenBalancesCountsMalformedQuantities(t *testing.T) {
+	wallet := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	other := "0x1111111111111111111111111111111111111111"
+	contract := "0xc0ffee0000000000000000000000000000000000"
+	broken := "0xbad0000000000000000000000000000000000000"
+
+	txs := []tokenTransaction{
+		{ContractAddress: contract, TokenName: "Test", TokenDecimal: "0", TokenQuantity: "10", From: other, To: wallet},
+		{ContractAddress: contract, TokenName: "Test", TokenDecimal: "0", TokenQuantity: "12abc", From: other, To: wallet},
+		{ContractAddress: "0xd00d000000000000000000000000000000000000", TokenName: "Clean", TokenDecimal: "0", TokenQuantity: "4", From: other, To: wallet},
+		{ContractAddress: broken, TokenName: "Broken", TokenDecimal: "0", TokenQuantity: "", From: other, To: wallet},
+	}
+
+	tokens, skipped := summarizeTokenBalances(wallet, txs, summaryOptions{})
+	if skipped != 2 {
+		t.Fatalf("expected 2 skipped transactions, got %d", skipped)
+	}
+	perToken := make(map[string]TokenBalance)
+	for _, token := range tokens {
+		perToken[token.Name] = token
+	}
+	if len(tokens) != 3 || perToken["Test"].Balance != "10" || perToken["Test"].SkippedTransfers != 1 || perToken["Clean"].SkippedTransfers != 0 {
+		t.Fatalf("unexpected tokens: %+v", tokens)
+	}
+	// A token whose only transfer was skipped nets to zero but is kept, since
+	// its real balance is unknown.
+	if perToken["Broken"].Balance != "0" || perToken["Broken"].SkippedTransfers != 1 {
+		t.Fatalf("expected the unparseable token to be reported, got %+v", perToken["Broken"])
+	}
+
+	text := formatWalletResponse(&WalletResponse{Address: wallet, Tokens: tokens, SkippedTransactions: skipped}, formatOptions{})
+	if !strings.Contains(text, "2 transaction(s) with malformed quantities were skipped") {
+		t.Fatalf("expected skipped note in output, got:\n%s", text)
+	}
+	if !strings.Contains(text, "- Test: 10 (incomplete: 1 transfer(s) skipped)\n") || strings.Contains(text, "- Clean: 4 (") {
+		t.Fatalf("expected only the affected tokens to be marked, got:\n%s", text)
+	}
+
+	// The skip is only logged at debug level.
+	var logs bytes.Buffer
+	info := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	summarizeTokenBalances(wallet, txs, summaryOptions{logger: info})
+	if logs.Len() != 0 {
+		t.Fatalf("expected no info-level logs, got %s", logs.String())
+	}
+	debug := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	summarizeTokenBalances(wallet, txs, summaryOptions{logger: debug})
+	if !strings.Contains(logs.String(), "level=DEBUG") || !strings.Contains(logs.String(), "contract="+contract) || !strings.Contains(logs.String(), "value=12abc") {
+		t.Fatalf("expected a debug log naming the contract and value, got %s", logs.String())
+	}
+}
+
+func TestSummarizeTokenBalancesSkipsNativePseudoEntries(t *testing.T) {
+	wallet := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	other := "0x1111111111111111111111111111111111111111"
+	contract := "0xc0ffee0000000000000000000000000000000000"
+
+	txs := []tokenTransaction{
+		{ContractAddress: "", TokenSymbol: "ETH", TokenDecimal: "18", TokenQuantity: "1000000000000000000", From: other, To: wallet},
+		{ContractAddress: "0x0000000000000000000000000000000000000000", TokenDecimal: "18", TokenQuantity: "5", From: other, To: wallet},
+		{ContractAddress: "0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE", TokenSymbol: "ETH", TokenDecimal: "18", TokenQuantity: "7", From: other, To: wallet},
+		{ContractAddress: contract, TokenName: "Test", TokenSymbol: "TST", TokenDecimal: "0", TokenQuantity: "3", From: other, To: wallet},
+	}
+
+	tokens, skipped := summarizeTokenBalances(wallet, txs, summaryOptions{})
+	if len(tokens) != 1 || !strings.EqualFold(tokens[0].Address, contract) {
+		t.Fatalf("expected only the real token, got %+v", tokens)
+	}
+	if skipped != 0 {
+		t.Fatalf("native entries should not count as malformed, got %d skipped", skipped)
+	}
+}
+
+func TestSummarizeTokenBalancesMixedCaseContracts(t *testing.T) {
+	wallet := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	other := "0x1111111111111111111111111111111111111111"
+	const usdc = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+
+	txs := []tokenTransaction{
+		{ContractAddress: strings.ToLower(usdc), TokenName: "USD Coin", TokenSymbol: "USDC", TokenDecimal: "6", TokenQuantity: "5000000", From: other, To: wallet},
+		{ContractAddress: "0x" + strings.ToUpper(usdc[2:]), TokenName: "USD Coin", TokenSymbol: "USDC", TokenDecimal: "6", TokenQuantity: "2000000", From: wallet, To: other},
+		{ContractAddress: usdc, TokenName: "USD Coin", TokenSymbol: "USDC", TokenDecimal: "6", TokenQuantity: "500000", From: other, To: wallet},
+	}
+
+	tokens, _ := summarizeTokenBalances(wallet, txs, summaryOptions{})
+	if len(tokens) != 1 {
+		t.Fatalf("expected one token across differently cased contracts, got %+v", tokens)
+	}
+	if tokens[0].Address != usdc || tokens[0].Balance != "3.5" || tokens[0].TransferCount != 3 {
+		t.Fatalf("expected the checksummed contract with a netted 3.5 balance, got %+v", tokens[0])
+	}
+}
+
+func TestSummarizeTokenBalancesActivityWindow(t *testing.T) {
+	wallet := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	other := "0x1111111111111111111111111111111111111111"
+	contract := "0xc0ffee0000000000000000000000000000000000"
+
+	txs := []tokenTransaction{
+		{TimeStamp: "1700000500", ContractAddress: contract, TokenSymbol: "TST", TokenDecimal: "0", TokenQuantity: "1", From: wallet, To: other},
+		{TimeStamp: "1700000000", ContractAddress: contract, TokenSymbol: "TST", TokenDecimal: "0", TokenQuantity: "5", From: other, To: wallet},
+		{TimeStamp: "", ContractAddress: contract, TokenSymbol: "TST", TokenDecimal: "0", TokenQuantity: "1", From: other, To: wallet},
+		// Not the wallet's transfer, so outside its activity window.
+		{TimeStamp: "1800000000", ContractAddress: contract, TokenSymbol: "TST", TokenDecimal: "0", TokenQuantity: "9", From: other, To: other},
+	}
+
+	tokens, _ := summarizeTokenBalances(wallet, txs, summaryOptions{})
+	if len(tokens) != 1 || tokens[0].FirstSeen == nil || tokens[0].LastActivity == nil {
+		t.Fatalf("expected one token with an activity window, got %+v", tokens)
+	}
+	if !tokens[0].FirstSeen.Equal(time.Unix(1700000000, 0)) || !tokens[0].LastActivity.Equal(time.Unix(1700000500, 0)) {
+		t.Fatalf("unexpected activity window %v - %v", tokens[0].FirstSeen, tokens[0].LastActivity)
+	}
+
+	encoded, err := json.Marshal(tokens[0])
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"first_seen":"2023-11-14T22:13:20Z"`) {
+		t.Fatalf("expected first_seen in the JSON, got %s", encoded)
+	}
+
+	undated, _ := summarizeTokenBalances(wallet, txs[2:3], summaryOptions{})
+	if len(undated) != 1 || undated[0].FirstSeen != nil || undated[0].LastActivity != nil {
+		t.Fatalf("expected no activity window without timestamps, got %+v", undated)
+	}
+}
+
+func TestSummarizeTokenBalancesDecimalsOverride(t *testing.T) {
+	wallet := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	other := "0x1111111111111111111111111111111111111111"
+	contract := "0xC0FFEE0000000000000000000000000000000000"
+
+	txs := []tokenTransaction{
+		{ContractAddress: contract, TokenName: "Broken", TokenDecimal: "0", TokenQuantity: "1500", From: other, To: wallet},
+	}
+
+	tracker, err := NewWalletTracker("key", WithDecimalsOverrides(map[string]int{strings.ToLower(contract): 3}))
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+
+	tokens, _ := summarizeTokenBalances(wallet, txs, summaryOptions{decimalOverrides: tracker.decimalOverrides})
+	if len(tokens) != 1 || tokens[0].Balance != "1.5" {
+		t.Fatalf("expected overridden balance 1.5, got %+v", tokens)
+	}
+}
+
+func TestSummarizeTokenBalancesRawAndFormatted(t *testing.T) {
+	wallet := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	other := "0x1111111111111111111111111111111111111111"
+	contract := "0xc0ffee0000000000000000000000000000000000"
+
+	txs := []tokenTransaction{
+		{ContractAddress: contract, TokenName: "USD Coin", TokenSymbol: "USDC", TokenDecimal: "6", TokenQuantity: "1234500000", From: other, To: wallet},
+		{ContractAddress: contract, TokenName: "USD Coin", TokenSymbol: "USDC", TokenDecimal: "6", TokenQuantity: "1", From: wallet, To: other},
+	}
+
+	tokens, _ := summarizeTokenBalances(wallet, txs, summaryOptions{})
+	if len(tokens) != 1 {
+		t.Fatalf("expected 1 token, got %d", len(tokens))
+	}
+	token := tokens[0]
+	if token.RawBalance != "1234499999" || token.Decimals != 6 || token.Balance != "1234.499999" {
+		t.Fatalf("unexpected balance representations: %+v", token)
+	}
+
+	raw, ok := new(big.Int).SetString(token.RawBalance, 10)
+	if !ok || formatTokenBalance(raw, token.Decimals) != token.Balance {
+		t.Fatalf("raw balance %s with %d decimals does not match %s", token.RawBalance, token.Decimals, token.Balance)
+	}
+
+	encoded, err := json.Marshal(token)
+	if err != nil {
+		t.Fatalf("marshalling token: %v", err)
+	}
+	for _, field := range []string{`"balance":"1234.499999"`, `"raw_balance":"1234499999"`, `"decimals":6`} {
+		if !strings.Contains(string(encoded), field) {
+			t.Fatalf("expected %s in %s", field, encoded)
+		}
+	}
+}
+
+func TestWalletHandlerStopsUpstreamCallsOnClientCancel(t *testing.T) {
+	var calls atomic.Int32
+	upstreamStarted := make(chan struct{}, 1)
+	upstreamCancelled := make(chan struct{})
+
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		upstreamStarted <- struct{}{}
+		<-r.Context().Done()
+		close(upstreamCancelled)
+	})
+	router := setupRoutes(tracker, tracker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/wallet/"+testWalletA, nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(rec, req)
+		close(done)
+	}()
+
+	select {
+	case <-upstreamStarted:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler never called upstream")
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler did not return after client cancellation")
+	}
+	select {
+	case <-upstreamCancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream request was not aborted after client cancellation")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected exactly 1 upstream call, got %d", got)
+	}
+}
+
+func TestSplitWrappedNative(t *testing.T) {
+	weth := strings.ToLower(defaultChain.WrappedNative)
+	resp := &WalletResponse{
+		Address: testWalletA,
+		Tokens: []TokenBalance{
+			{Address: "0xdac17f958d2ee523a2206206994597c13d831ec7", Name: "Tether USD", Symbol: "USDT", Balance: "10"},
+			{Address: weth, Name: "Wrapped Ether", Symbol: "WETH", Balance: "1.5"},
+		},
+	}
+
+	splitWrappedNative(resp, defaultChain)
+	if resp.WrappedNative == nil || resp.WrappedNative.Balance != "1.5" {
+		t.Fatalf("expected WETH summary of 1.5, got %+v", resp.WrappedNative)
+	}
+	if len(resp.Tokens) != 1 || resp.Tokens[0].Symbol != "USDT" {
+		t.Fatalf("expected WETH removed from tokens, got %+v", resp.Tokens)
+	}
+
+	empty := &WalletResponse{Address: testWalletA, Tokens: []TokenBalance{}}
+	splitWrappedNative(empty, defaultChain)
+	if empty.WrappedNative == nil || empty.WrappedNative.Balance != "0" {
+		t.Fatalf("expected explicit zero wrapped balance, got %+v", empty.WrappedNative)
+	}
+}
+
+func TestFetchTokenTransactionsHTMLOutagePage(t *testing.T) {
+	for _, contentType := range []string{"text/html; charset=utf-8", "application/json"} {
+		t.Run(contentType, func(t *testing.T) {
+			tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", contentType)
+				fmt.Fprint(w, "\n<!DOCTYPE html><html><body>Etherscan is under maintenance</body></html>")
+			})
+
+			_, _, err := tracker.fetchTokenTransactions(context.Background(), defaultChain.ID, testWalletA, 0, 0)
+			if !errors.Is(err, ErrUpstreamUnavailable) {
+				t.Fatalf("expected ErrUpstreamUnavailable, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "under maintenance") {
+				t.Fatalf("expected a body snippet in the error, got %q", err)
+			}
+		})
+	}
+}
+
+func TestQueryEtherscanRejectsOversizedResponse(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[{"hash":"%s"}]}`, strings.Repeat("a", 4096))
+	})
+	WithMaxResponseBytes(1024)(tracker)
+
+	_, _, err := tracker.fetchTokenTransactions(context.Background(), defaultChain.ID, testWalletA, 0, 0)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+
+	WithMaxResponseBytes(1 << 20)(tracker)
+	if _, _, err := tracker.fetchTokenTransactions(context.Background(), defaultChain.ID, testWalletA, 0, 0); err != nil {
+		t.Fatalf("expected the response to fit under a larger limit, got %v", err)
+	}
+}
+
+func TestWalletResponseTokenMap(t *testing.T) {
+	weth := defaultChain.WrappedNative
+	resp := &WalletResponse{
+		Address: testWalletA,
+		Tokens: []TokenBalance{
+			{Address: "0xdAC17F958D2ee523a2206206994597C13D831ec7", Name: "Tether USD", Symbol: "USDT", Balance: "10"},
+			{Address: weth, Name: "Wrapped Ether", Symbol: "WETH", Balance: "1.5"},
+		},
+	}
+
+	tokens := resp.TokenMap()
+	if len(tokens) != len(resp.Tokens) {
+		t.Fatalf("expected %d entries, got %d", len(resp.Tokens), len(tokens))
+	}
+	for _, token := range resp.Tokens {
+		if got, ok := tokens[strings.ToLower(token.Address)]; !ok || got != token {
+			t.Fatalf("map entry for %s = %+v, want %+v", token.Address, got, token)
+		}
+	}
+
+	splitWrappedNative(resp, defaultChain)
+	if got := resp.TokenMap()[strings.ToLower(weth)]; got.Balance != "1.5" {
+		t.Fatalf("expected the wrapped native token in the map, got %+v", got)
+	}
+}
+
+func TestGetWalletTokensReportsNativeBalance(t *testing.T) {
+	tracker := newTestTracker(t, withNativeBalance("1234000000000000000", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+	}))
+
+	resp, err := tracker.GetWalletTokens(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetWalletTokens returned error: %v", err)
+	}
+	if resp.NativeBalance != "1.234" || resp.NativeSymbol != "ETH" || len(resp.Tokens) != 0 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if resp.NativeRawBalance != "1234000000000000000" || resp.NativeDecimals != 18 {
+		t.Fatalf("expected the exact wei balance with 18 decimals, got %q, %d", resp.NativeRawBalance, resp.NativeDecimals)
+	}
+
+	text := formatWalletResponse(resp, formatOptions{Labels: LabelDefault})
+	if want := "Wallet Address: " + testWalletA + "\nETH: 1.234\nNo token balances found."; text != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", text, want)
+	}
+}
+
+func TestWithChainID(t *testing.T) {
+	var chainIDs []string
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		chainIDs = append(chainIDs, r.URL.Query().Get("chainid"))
+		if r.URL.Query().Get("action") == "balance" {
+			fmt.Fprint(w, `{"status":"1","message":"OK","result":"0"}`)
+			return
+		}
+		fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+	})
+	WithChainID(137)(tracker)
+
+	resp, err := tracker.GetWalletTokens(context.Background(), testWalletA)
+	if err != nil {
+		t.Fatalf("GetWalletTokens returned error: %v", err)
+	}
+	if resp.NativeSymbol != "POL" {
+		t.Fatalf("expected the polygon native symbol, got %q", resp.NativeSymbol)
+	}
+	for _, id := range chainIDs {
+		if id != "137" {
+			t.Fatalf("expected every request to use chainid 137, got %v", chainIDs)
+		}
+	}
+
+	if _, err := tracker.walletArg("wallet_address", "ethereum:"+testWalletA+"@137"); err != nil {
+		t.Fatalf("expected a payment URI for the tracker's chain to pass, got %v", err)
+	}
+
+	WithChainID(999999)(tracker)
+	if tracker.chainID != 137 {
+		t.Fatalf("expected an unsupported chain ID to be ignored, got %d", tracker.chainID)
+	}
+}
+
+func TestFetchTokenTransactionsPaginates(t *testing.T) {
+	// Blocks 1-5 hold one transfer each, except block 3 which holds two and
+	// straddles the first page boundary.
+	history := []string{"1", "2", "3", "3", "4", "5"}
+	var startBlocks []string
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		start := r.URL.Query().Get("startblock")
+		startBlocks = append(startBlocks, start)
+		from, _ := strconv.Atoi(start)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+		var entries []string
+		for i, block := range history {
+			if n, _ := strconv.Atoi(block); n >= from && len(entries) < limit {
+				entries = append(entries, fmt.Sprintf(`{"hash":"0x%d","blockNumber":"%s"}`, i, block))
+			}
+		}
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[%s]}`, strings.Join(entries, ","))
+	})
+	tracker.txPageSize = 3
+
+	txs, truncated, err := tracker.fetchTokenTransactions(context.Background(), defaultChain.ID, testWalletA, 0, 0)
+	if err != nil {
+		t.Fatalf("fetchTokenTransactions returned error: %v", err)
+	}
+	if truncated {
+		t.Fatal("expected the complete history without truncation")
+	}
+	var hashes []string
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	if got := strings.Join(hashes, ","); got != "0x0,0x1,0x2,0x3,0x4,0x5" {
+		t.Fatalf("expected every transfer exactly once, got %s", got)
+	}
+	if got := strings.Join(startBlocks, ","); got != "0,3,4" {
+		t.Fatalf("expected start blocks 0,3,4, got %s", got)
+	}
+
+	WithMaxTransferPages(2)(tracker)
+	txs, truncated, err = tracker.fetchTokenTransactions(context.Background(), defaultChain.ID, testWalletA, 0, 0)
+	if err != nil || !truncated || len(txs) != 5 {
+		t.Fatalf("expected 5 transfers truncated at the page cap, got %d (truncated %v, err %v)", len(txs), truncated, err)
+	}
+
+	resp := &WalletResponse{Address: testWalletA, Truncated: true}
+	if text := formatWalletResponse(resp, formatOptions{}); !strings.Contains(text, "Warning: the transfer history is longer") {
+		t.Fatalf("expected a truncation warning, got:\n%s", text)
+	}
+}
+
+func TestQueryEtherscanRetriesRateLimits(t *testing.T) {
+	var calls atomic.Int32
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		case 2:
+			fmt.Fprint(w, `{"status":"0","message":"NOTOK","result":"Max calls per sec rate limit reached (5/sec)"}`)
+		default:
+			fmt.Fprint(w, `{"status":"1","message":"OK","result":[{"hash":"0x1","blockNumber":"1"}]}`)
+		}
+	})
+	WithEtherscanRetries(2, time.Millisecond)(tracker)
+
+	txs, _, err := tracker.fetchTokenTransactions(context.Background(), defaultChain.ID, testWalletA, 0, 0)
+	if err != nil || len(txs) != 1 {
+		t.Fatalf("expected success on the third attempt, got %d transfers (err %v)", len(txs), err)
+	}
+
+	calls.Store(0)
+	WithEtherscanRetries(1, time.Millisecond)(tracker)
+	_, _, err = tracker.fetchTokenTransactions(context.Background(), defaultChain.ID, testWalletA, 0, 0)
+	if !errors.Is(err, ErrRateLimited) || !strings.Contains(err.Error(), "5/sec") {
+		t.Fatalf("expected ErrRateLimited once retries ran out, got %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected 2 attempts, got %d", n)
+	}
+}
+
+func TestSummarizeTokenBalancesMinBalance(t *testing.T) {
+	wallet := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	other := "0x3333333333333333333333333333333333333333"
+
+	txs := []tokenTransaction{
+		// 0.0099 USDC of dust, 0.01 of USDT exactly at the threshold, and a
+		// whole token with no decimals.
+		{ContractAddress: "0x0000000000000000000000000000000000000001", TokenName: "USD Coin", TokenDecimal: "6", TokenQuantity: "9900", From: other, To: wallet},
+		{ContractAddress: "0x0000000000000000000000000000000000000002", TokenName: "Tether", TokenDecimal: "6", TokenQuantity: "10000", From: other, To: wallet},
+		{ContractAddress: "0x0000000000000000000000000000000000000003", TokenName: "Whole", TokenDecimal: "0", TokenQuantity: "1", From: other, To: wallet},
+	}
+
+	min, err := parseMinBalance("0.01")
+	if err != nil {
+		t.Fatalf("parseMinBalance returned error: %v", err)
+	}
+	tokens, _ := summarizeTokenBalances(wallet, txs, summaryOptions{minBalance: min})
+	var names []string
+	for _, token := range tokens {
+		names = append(names, token.Name)
+	}
+	if got := strings.Join(names, ","); got != "Tether,Whole" {
+		t.Fatalf("expected only balances of at least 0.01, got %s", got)
+	}
+
+	for _, raw := range []string{"", " 0 ", "0.000"} {
+		if min, err := parseMinBalance(raw); err != nil || min != nil {
+			t.Fatalf("parseMinBalance(%q): expected no threshold, got %v (err %v)", raw, min, err)
+		}
+	}
+	for _, raw := range []string{"-1", "1/3", "1e-3", "dust"} {
+		if _, err := parseMinBalance(raw); err == nil {
+			t.Fatalf("parseMinBalance(%q): expected an error", raw)
+		}
+	}
+}
+
+type countingTransport struct {
+	calls atomic.Int32
+	next  http.RoundTripper
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.calls.Add(1)
+	return c.next.RoundTrip(req)
+}
+
+// scriptedTransport answers requests with canned responses in order, without a
+// server, and repeats the last one once they run out.
+type scriptedTransport struct {
+	mu        sync.Mutex
+	responses []scriptedResponse
+	requests  []*http.Request
+}
+
+type scriptedResponse struct {
+	status int
+	body   string
+}
+
+func (s *scriptedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	next := s.responses[min(len(s.requests), len(s.responses))-1]
+	return &http.Response{
+		StatusCode: next.status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(next.body)),
+		Request:    req,
+	}, nil
+}
+
+func TestFetchTokenTransactionsResponses(t *testing.T) {
+	const (
+		usdc = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+		dai  = "0x6b175474e89094c44da98b954eedeac495271d0f"
+	)
+	transfer := func(block int, contract, symbol, value, from, to string) string {
+		return fmt.Sprintf(`{"hash":"0x%d","blockNumber":"%d","contractAddress":%q,"tokenName":%q,"tokenSymbol":%q,"tokenDecimal":"6","value":%q,"from":%q,"to":%q}`,
+			block, block, contract, symbol, symbol, value, from, to)
+	}
+	ok := func(entries ...string) scriptedResponse {
+		return scriptedResponse{http.StatusOK, `{"status":"1","message":"OK","result":[` + strings.Join(entries, ",") + `]}`}
+	}
+	other := "0x3333333333333333333333333333333333333333"
+
+	tests := []struct {
+		name      string
+		pageSize  int
+		responses []scriptedResponse
+		wantErr   error
+		errText   string
+		wantTxs   int
+		wantCalls int
+		tokens    map[string]string
+	}{
+		{
+			name:      "no transactions found",
+			responses: []scriptedResponse{{http.StatusOK, `{"status":"0","message":"No transactions found","result":[]}`}},
+			wantErr:   ErrNoTransactions,
+			wantCalls: 1,
+		},
+		{
+			name:      "rate limit message",
+			responses: []scriptedResponse{{http.StatusOK, `{"status":"0","message":"NOTOK","result":"Max calls per sec rate limit reached (5/sec)"}`}},
+			wantErr:   ErrRateLimited,
+			wantCalls: 1,
+		},
+		{
+			name:      "http 429",
+			responses: []scriptedResponse{{http.StatusTooManyRequests, "Too Many Requests"}},
+			wantErr:   ErrRateLimited,
+			wantCalls: 1,
+		},
+		{
+			name:      "malformed json",
+			responses: []scriptedResponse{{http.StatusOK, `{"status":"1","message":"OK","result":[{"hash":`}},
+			errText:   "decoding etherscan response",
+			wantCalls: 1,
+		},
+		{
+			name: "multi-token history",
+			responses: []scriptedResponse{ok(
+				transfer(1, usdc, "USDC", "5000000", other, testWalletA),
+				transfer(2, dai, "DAI", "2500000", other, testWalletA),
+				transfer(3, usdc, "USDC", "1500000", testWalletA, other),
+			)},
+			wantTxs:   3,
+			wantCalls: 1,
+			tokens:    map[string]string{"USDC": "3.5", "DAI": "2.5"},
+		},
+		{
+			// A full page is followed by a query from
+			// its last block, whose transfers come from the second page only.
+			name:     "partial pages",
+			pageSize: 2,
+			responses: []scriptedResponse{
+				ok(transfer(1, usdc, "USDC", "1000000", other, testWalletA), transfer(2, usdc, "USDC", "1000000", other, testWalletA)),
+				ok(transfer(2, usdc, "USDC", "1000000", other, testWalletA), transfer(3, dai, "DAI", "1000000", other, testWalletA)),
+				ok(transfer(3, dai, "DAI", "1000000", other, testWalletA)),
+			},
+			wantTxs:   3,
+			wantCalls: 3,
+			tokens:    map[string]string{"USDC": "2", "DAI": "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &scriptedTransport{responses: tt.responses}
+			tracker, err := NewWalletTracker("test-key",
+				WithHTTPClient(&http.Client{Transport: transport}),
+				WithBaseURL("http://etherscan.test/api"),
+				WithEtherscanRetries(0, 0),
+				WithRateLimit(0))
+			if err != nil {
+				t.Fatalf("NewWalletTracker returned error: %v", err)
+			}
+			if tt.pageSize > 0 {
+				tracker.txPageSize = tt.pageSize
+			}
+
+			txs, _, err := tracker.fetchTokenTransactions(context.Background(), tracker.chainID, testWalletA, 0, 0)
+			switch {
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			case tt.errText != "" && (err == nil || !strings.Contains(err.Error(), tt.errText)):
+				t.Fatalf("expected an error containing %q, got %v", tt.errText, err)
+			case tt.wantErr == nil && tt.errText == "" && err != nil:
+				t.Fatalf("fetchTokenTransactions returned error: %v", err)
+			}
+			if len(txs) != tt.wantTxs {
+				t.Fatalf("expected %d transfers, got %d", tt.wantTxs, len(txs))
+			}
+			if len(transport.requests) != tt.wantCalls {
+				t.Fatalf("expected %d requests, got %d", tt.wantCalls, len(transport.requests))
+			}
+			if query := transport.requests[0].URL.Query(); query.Get("action") != "tokentx" || query.Get("apikey") != "test-key" {
+				t.Fatalf("unexpected query %s", transport.requests[0].URL.RawQuery)
+			}
+
+			if tt.tokens == nil {
+				return
+			}
+			tokens, skipped := summarizeTokenBalances(testWalletA, txs, summaryOptions{})
+			if skipped != 0 || len(tokens) != len(tt.tokens) {
+				t.Fatalf("expected %d tokens, got %+v (%d skipped)", len(tt.tokens), tokens, skipped)
+			}
+			for _, token := range tokens {
+				if tt.tokens[token.Symbol] != token.Balance {
+					t.Errorf("%s: expected balance %s, got %s", token.Symbol, tt.tokens[token.Symbol], token.Balance)
+				}
+			}
+		})
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"0","message":"No transactions found","result":[]}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	transport := &countingTransport{next: http.DefaultTransport}
+	client := &http.Client{Transport: transport}
+	tracker, err := NewWalletTracker("test-key", WithHTTPClient(client), WithHTTPTimeout(time.Minute), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+
+	if tracker.client != client || client.Timeout != 0 {
+		t.Fatalf("expected the injected client to be used unchanged")
+	}
+	if _, err := tracker.GetWalletTokens(context.Background(), testWalletA); err != nil {
+		t.Fatalf("GetWalletTokens returned error: %v", err)
+	}
+	if transport.calls.Load() == 0 {
+		t.Fatalf("expected requests to go through the injected client")
+	}
+}
+
+func TestWithBaseURL(t *testing.T) {
+	tracker, err := NewWalletTracker("test-key")
+	if err != nil || tracker.baseURL != etherscanBaseURL {
+		t.Fatalf("expected the Etherscan API by default, got %q, %v", tracker.baseURL, err)
+	}
+
+	var gotPath string
+	srv := httptest.NewServer(withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Write([]byte(`{"status":"0","message":"No transactions found","result":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+	tracker, err = NewWalletTracker("test-key", WithBaseURL(" "+srv.URL+"/api "), WithRateLimit(0))
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+	if _, err := tracker.GetWalletTokens(context.Background(), testWalletA); err != nil {
+		t.Fatalf("GetWalletTokens returned error: %v", err)
+	}
+	if gotPath != "/api" {
+		t.Fatalf("expected calls to the custom base URL, got path %q", gotPath)
+	}
+
+	for _, bad := range []string{"", "eth.blockscout.com/api", "ftp://example.com/api", "https://", "http://[::1"} {
+		if _, err := NewWalletTracker("test-key", WithBaseURL(bad)); !errors.Is(err, ErrInvalidBaseURL) {
+			t.Errorf("%q: expected ErrInvalidBaseURL, got %v", bad, err)
+		}
+	}
+}
+
+func TestWithHTTPTimeout(t *testing.T) {
+	tracker, err := NewWalletTracker("test-key")
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+	if tracker.client.Timeout != defaultHTTPTimeout {
+		t.Fatalf("expected default timeout %s, got %s", defaultHTTPTimeout, tracker.client.Timeout)
+	}
+
+	tracker, err = NewWalletTracker("test-key", WithHTTPTimeout(50*time.Millisecond), WithHTTPTimeout(-time.Second))
+	if err != nil {
+		t.Fatalf("NewWalletTracker returned error: %v", err)
+	}
+	if tracker.client.Timeout != 50*time.Millisecond {
+		t.Fatalf("expected timeout 50ms, got %s", tracker.client.Timeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}).GetWalletTokens(ctx, testWalletA)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the caller's deadline to cancel the lookup, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("cancellation took %s", elapsed)
+	}
+}
+
+func TestStartServer(t *testing.T) {
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	srv, err := startServer(tracker, tracker, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("startServer returned error: %v", err)
+	}
+
+	resp, err := http.Get("http://" + srv.Addr + "/healthz")
+	if err != nil {
+		t.Fatalf("GET /healthz failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+	if _, err := http.Get("http://" + srv.Addr + "/healthz"); err == nil {
+		t.Fatalf("expected the server to stop accepting connections after Shutdown")
+	}
+
+	if _, err := startServer(tracker, tracker, "256.0.0.1:0"); err == nil {
+		t.Fatalf("expected an unusable address to fail")
+	}
+}
+
+func TestFetchTokenTransactionsTypedErrors(t *testing.T) {
+	tests := []struct {
+		body string
+		want error
+	}{
+		{`{"status":"0","message":"No transactions found","result":[]}`, ErrNoTransactions},
+		{`{"status":"0","message":"NOTOK","result":"Invalid API Key"}`, ErrInvalidAPIKey},
+		{`{"status":"0","message":"NOTOK","result":"Missing/Invalid API Key"}`, ErrInvalidAPIKey},
+		{`{"status":"0","message":"NOTOK","result":"Error! Invalid address format"}`, ErrInvalidWalletAddress},
+		{`{"status":"0","message":"NOTOK","result":"Max rate limit reached"}`, ErrRateLimited},
+		// Some endpoints and proxies send the NOTOK envelope without a "0"
+		// status; the reason must still be surfaced.
+		{`{"message":"NOTOK","result":"Invalid API Key"}`, ErrInvalidAPIKey},
+		{`{"status":"1","message":"NOTOK","result":"Max calls per sec rate limit reached (5/sec)"}`, ErrRateLimited},
+	}
+
+	for _, tt := range tests {
+		tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, tt.body)
+		})
+		WithEtherscanRetries(0, 0)(tracker)
+
+		txs, _, err := tracker.fetchTokenTransactions(context.Background(), tracker.chainID, testWalletA, 0, 0)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v (txs %v)", tt.body, tt.want, err, txs)
+		}
+	}
+
+	tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"0","message":"NOTOK","result":"Something unexpected"}`)
+	})
+	_, _, err := tracker.fetchTokenTransactions(context.Background(), tracker.chainID, testWalletA, 0, 0)
+	if err == nil || !strings.Contains(err.Error(), "Something unexpected") {
+		t.Fatalf("expected an untyped error carrying the reason, got %v", err)
+	}
+
+	tracker = newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"1","message":"NOTOK","result":"Something unexpected"}`)
+	})
+	_, _, err = tracker.fetchTokenTransactions(context.Background(), tracker.chainID, testWalletA, 0, 0)
+	if err == nil || strings.Contains(err.Error(), "unexpected result text") || !strings.Contains(err.Error(), "NOTOK: Something unexpected") {
+		t.Fatalf("expected the NOTOK reason as an etherscan api error, got %v", err)
+	}
+}
+
+func TestWalletHandlerMapsUpstreamErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   int
+	}{
+		{http.StatusTooManyRequests, `Too Many Requests`, http.StatusTooManyRequests},
+		{http.StatusBadGateway, `Bad Gateway`, http.StatusServiceUnavailable},
+		{http.StatusOK, `{"status":"0","message":"NOTOK","result":"Invalid API Key"}`, http.StatusInternalServerError},
+		{http.StatusOK, `{"status":"0","message":"NOTOK","result":"Error! Invalid address format"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		tracker := newTestTracker(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			fmt.Fprint(w, tt.body)
+		})
+		WithEtherscanRetries(0, 0)(tracker)
+
+		rec := httptest.NewRecorder()
+		setupRoutes(tracker, tracker).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wallet/"+testWalletA, nil))
+		if rec.Code != tt.want {
+			t.Errorf("upstream %d %q: expected %d, got %d", tt.status, tt.body, tt.want, rec.Code)
+		}
+	}
+}
+
+func TestWalletRoutePagination(t *testing.T) {
+	tracker := newTestTracker(t, withNativeBalance("0", func(w http.ResponseWriter, r *http.Request) {
+		var txs []string
+		for i, name := range []string{"Delta", "Alpha", "Charlie", "Bravo"} {
+			txs = append(txs, fmt.Sprintf(`{"blockNumber":"%d","contractAddress":"0xc0ffee000000000000000000000000000000000%d","tokenName":"%s","tokenDecimal":"0","value":"1","from":"%s","to":"%s"}`, i+1, i, name, testWalletB, testWalletA))
+		}
+		fmt.Fprintf(w, `{"status":"1","message":"OK","result":[%s]}`, strings.Join(txs, ","))
+	}))
+	router := setupRoutes(tracker, tracker)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"?limit=2", []string{"Alpha", "Bravo"}},
+		{"?limit=2&offset=2", []string{"Charlie", "Delta"}},
+		{"?offset=3", []string{"Delta"}},
+		{"?offset=10", []string{}},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wallet/"+testWalletA+tt.query, nil))
+		if got := rec.Header().Get("X-Total-Count"); got != "4" {
+			t.Fatalf("%s: expected X-Total-Count 4, got %q", tt.query, got)
+		}
+		var resp WalletResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decoding response: %v", tt.query, err)
+		}
+		got := []string{}
+		for _, token := range resp.Tokens {
+			got = append(got, token.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Fatalf("%s: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	for _, query := range []string{"?limit=0", "?limit=abc", "?offset=-1", "?limit=1&chains=1,137"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wallet/"+testWalletA+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// BalanceChange is a token held in both snapshots whose balance moved. Delta
+// and RawDelta, the same change in base units, are computed on the raw
+// integer balances, so they are only set when both snapshots carry raw
+// balances with the same decimals.
+type BalanceChange struct {
+	Address  string `json:"address"`
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+	Delta    string `json:"delta,omitempty"`
+	RawDelta string `json:"raw_delta,omitempty"`
+}
+
+// WalletDiff is what changed in a wallet between two snapshots. Tokens are
+// matched by contract address, in any case.
+type WalletDiff struct {
+	Address string `json:"address"`
+	// Native is set when the native currency balance moved.
+	Native  *BalanceChange  `json:"native,omitempty"`
+	Added   []TokenBalance  `json:"added"`
+	Removed []TokenBalance  `json:"removed"`
+	Changed []BalanceChange `json:"changed"`
+}
+
+func (d *WalletDiff) Empty() bool {
+	return d.Native == nil && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffWallets compares two snapshots of the same wallet by token contract:
+// tokens only in current are added, tokens only in previous removed, and
+// tokens in both whose balance differs changed. Tokens dropping to a zero
+// balance are reported as changed, not removed, as long as they are still
+// listed.
+func DiffWallets(previous, current *WalletResponse) WalletDiff {
+	diff := WalletDiff{
+		Address: current.Address,
+		Added:   []TokenBalance{},
+		Removed: []TokenBalance{},
+		Changed: []BalanceChange{},
+	}
+
+	if previous.NativeBalance != "" && current.NativeBalance != "" && (previous.NativeBalance != current.NativeBalance || previous.NativeRawBalance != current.NativeRawBalance) {
+		diff.Native = &BalanceChange{
+			Symbol:   current.NativeSymbol,
+			Previous: previous.NativeBalance,
+			Current:  current.NativeBalance,
+		}
+		if delta, ok := rawDelta(previous.NativeRawBalance, previous.NativeDecimals, current.NativeRawBalance, current.NativeDecimals); ok {
+			diff.Native.Delta = signedBalance(delta, current.NativeDecimals)
+			diff.Native.RawDelta = delta.String()
+		} else {
+			diff.Native.Delta = decimalDelta(previous.NativeBalance, current.NativeBalance)
+		}
+	}
+
+	before := make(map[string]TokenBalance, len(previous.Tokens))
+	for _, token := range previous.Tokens {
+		before[strings.ToLower(token.Address)] = token
+	}
+
+	for _, token := range current.Tokens {
+		key := strings.ToLower(token.Address)
+		prev, ok := before[key]
+		if !ok {
+			diff.Added = append(diff.Added, token)
+			continue
+		}
+		delete(before, key)
+
+		if prev.Balance == token.Balance && prev.RawBalance == token.RawBalance {
+			continue
+		}
+		change := BalanceChange{
+			Address:  token.Address,
+			Name:     token.Name,
+			Symbol:   token.Symbol,
+			Previous: prev.Balance,
+			Current:  token.Balance,
+		}
+		if delta, ok := rawDelta(prev.RawBalance, prev.Decimals, token.RawBalance, token.Decimals); ok {
+			change.Delta = signedBalance(delta, token.Decimals)
+			change.RawDelta = delta.String()
+		}
+		diff.Changed = append(diff.Changed, change)
+	}
+
+	for _, token := range before {
+		diff.Removed = append(diff.Removed, token)
+	}
+	sort.Slice(diff.Removed, func(i, j int) bool {
+		return strings.ToLower(diff.Removed[i].Name) < strings.ToLower(diff.Removed[j].Name)
+	})
+
+	return diff
+}
+
+// rawDelta subtracts two raw balances, which must share their decimals.
+func rawDelta(previous string, previousDecimals int, current string, currentDecimals int) (*big.Int, bool) {
+	if previousDecimals != currentDecimals {
+		return nil, false
+	}
+	before, ok := new(big.Int).SetString(previous, 10)
+	if !ok {
+		return nil, false
+	}
+	after, ok := new(big.Int).SetString(current, 10)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Int).Sub(after, before), true
+}
+
+// signedBalance formats delta like a balance, with a + sign when positive.
+func signedBalance(delta *big.Int, decimals int) string {
+	formatted := formatTokenBalance(delta, decimals)
+	if delta.Sign() > 0 {
+		formatted = "+" + formatted
+	}
+	return formatted
+}
+
+// decimalDelta subtracts two formatted balances exactly.
+func decimalDelta(previous, current string) string {
+	before, ok := new(big.Rat).SetString(previous)
+	if !ok {
+		return ""
+	}
+	after, ok := new(big.Rat).SetString(current)
+	if !ok {
+		return ""
+	}
+
+	delta := new(big.Rat).Sub(after, before)
+	formatted := strings.TrimRight(strings.TrimRight(delta.FloatString(nativeDecimals), "0"), ".")
+	if delta.Sign() > 0 {
+		formatted = "+" + formatted
+	}
+	return formatted
+}
+
+type WalletChangesRequest struct {
+	WalletAddress string `json:"wallet_address" description:"The cryptocurrency wallet address to watch"`
+	Previous      string `json:"previous,omitempty" description:"The snapshot JSON returned by a previous wallet_changes call; omit on the first call to get the full wallet"`
+}
+
+func registerWalletChanges(ctx context.Context, server *mcp_golang.Server, tracker *WalletTracker) error {
+	return server.RegisterTool("wallet_changes", "Report what changed in a wallet's token balances since a previous snapshot", trackCall(ctx, tracker, func(ctx context.Context, req WalletChangesRequest) (*mcp_golang.ToolResponse, error) {
+		wallet, err := tracker.walletArg("wallet_address", req.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		var previous *WalletResponse
+		if raw := strings.TrimSpace(req.Previous); raw != "" {
+			previous = &WalletResponse{}
+			if err := json.Unmarshal([]byte(raw), previous); err != nil {
+				return nil, fmt.Errorf("parsing previous snapshot: %w", err)
+			}
+			if !strings.EqualFold(previous.Address, wallet) {
+				return nil, fmt.Errorf("previous snapshot is for %s, not %s", previous.Address, wallet)
+			}
+		}
+
+		current, err := tracker.GetWalletTokens(ctx, wallet)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := formatWalletChanges(previous, current)
+		if err != nil {
+			return nil, err
+		}
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(content)), nil
+	}))
+}
+
+// formatWalletChanges renders the diff against previous, or the full wallet
+// when there is no previous snapshot, followed by the snapshot to pass to the
+// next call.
+func formatWalletChanges(previous, current *WalletResponse) (string, error) {
+	snapshot, err := json.Marshal(current)
+	if err != nil {
+		return "", fmt.Errorf("encoding snapshot: %w", err)
+	}
+
+	var body string
+	if previous == nil {
+		body = formatWalletResponse(current, formatOptions{Labels: LabelDefault})
+	} else {
+		diff := DiffWallets(previous, current)
+		body = formatWalletDiff(&diff)
+	}
+	return fmt.Sprintf("%s\nSnapshot: %s", body, snapshot), nil
+}
+
+func formatWalletDiff(diff *WalletDiff) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Wallet Address: %s\n", diff.Address))
+
+	if diff.Empty() {
+		builder.WriteString("No changes since previous snapshot.")
+		return builder.String()
+	}
+
+	builder.WriteString("Changes since previous snapshot:\n")
+	if diff.Native != nil {
+		builder.WriteString(fmt.Sprintf("- %s: %s -> %s (%s)\n", firstNonEmpty(diff.Native.Symbol, defaultChain.NativeSymbol), diff.Native.Previous, diff.Native.Current, diff.Native.Delta))
+	}
+	for _, token := range diff.Added {
+		builder.WriteString(fmt.Sprintf("- added %s: %s\n", tokenLabel(token, LabelDefault), token.Balance))
+	}
+	for _, token := range diff.Removed {
+		builder.WriteString(fmt.Sprintf("- removed %s (was %s)\n", tokenLabel(token, LabelDefault), token.Balance))
+	}
+	for _, change := range diff.Changed {
+		label := tokenLabel(TokenBalance{Address: change.Address, Name: change.Name, Symbol: change.Symbol}, LabelDefault)
+		line := fmt.Sprintf("- %s: %s -> %s", label, change.Previous, change.Current)
+		if change.Delta != "" {
+			line += fmt.Sprintf(" (%s)", change.Delta)
+		}
+		builder.WriteString(line + "\n")
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
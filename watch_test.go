@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestDiffWallets(t *testing.T) {
+	usdc := TokenBalance{Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Name: "USD Coin", Symbol: "USDC", Balance: "10", RawBalance: "10000000", Decimals: 6}
+	dai := TokenBalance{Address: "0x6B175474E89094C44Da98b954EedeAC495271d0F", Name: "Dai", Symbol: "DAI", Balance: "5", RawBalance: "5000000000000000000", Decimals: 18}
+	link := TokenBalance{Address: "0x514910771AF9Ca656af840dff83E8264EcF986CA", Name: "ChainLink", Symbol: "LINK", Balance: "1", RawBalance: "1000000000000000000", Decimals: 18}
+
+	previous := &WalletResponse{Address: testWalletA, Tokens: []TokenBalance{usdc, dai}}
+
+	spent := usdc
+	spent.Address = strings.ToLower(usdc.Address)
+	spent.Balance, spent.RawBalance = "7.5", "7500000"
+	current := &WalletResponse{Address: testWalletA, Tokens: []TokenBalance{spent, link}}
+
+	diff := DiffWallets(previous, current)
+	if len(diff.Added) != 1 || diff.Added[0].Symbol != "LINK" {
+		t.Fatalf("expected LINK to be added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Symbol != "DAI" {
+		t.Fatalf("expected DAI to be removed, got %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 {
+		t.Fatalf("expected one balance change, got %+v", diff.Changed)
+	}
+	if got := diff.Changed[0]; got.Previous != "10" || got.Current != "7.5" || got.Delta != "-2.5" || got.RawDelta != "-2500000" {
+		t.Fatalf("unexpected USDC change: %+v", got)
+	}
+
+	if diff.Native != nil {
+		t.Fatalf("expected no native change without native balances, got %+v", diff.Native)
+	}
+
+	previous.NativeBalance, current.NativeBalance = "1.5", "0.25"
+	if native := DiffWallets(previous, current).Native; native == nil || native.Delta != "-1.25" {
+		t.Fatalf("expected a native change of -1.25, got %+v", native)
+	}
+
+	if same := DiffWallets(current, current); !same.Empty() {
+		t.Fatalf("expected no changes against itself, got %+v", same)
+	}
+
+	// Deltas come from the raw amounts, so a change too small to show in
+	// the formatted balance is still exact.
+	previous.setNativeBalance("ETH", big.NewInt(1000000000000000000))
+	current.setNativeBalance("ETH", big.NewInt(1000000000000000001))
+	if native := DiffWallets(previous, current).Native; native == nil || native.RawDelta != "1" || native.Delta != "+0.000000000000000001" {
+		t.Fatalf("expected a native change of 1 wei, got %+v", native)
+	}
+}
+
+func TestDiffWalletsZeroBalanceAndDecimals(t *testing.T) {
+	token := TokenBalance{Address: "0xc0ffee0000000000000000000000000000000000", Name: "Test", Symbol: "TST", Balance: "3", RawBalance: "300", Decimals: 2}
+	emptied := token
+	emptied.Balance, emptied.RawBalance = "0", "0"
+
+	diff := DiffWallets(&WalletResponse{Address: testWalletA, Tokens: []TokenBalance{token}}, &WalletResponse{Address: testWalletA, Tokens: []TokenBalance{emptied}})
+	if len(diff.Removed) != 0 || len(diff.Changed) != 1 || diff.Changed[0].Delta != "-3" || diff.Changed[0].RawDelta != "-300" {
+		t.Fatalf("expected a token at zero to be changed, got %+v", diff)
+	}
+
+	// Raw amounts at different scales cannot be subtracted.
+	rescaled := token
+	rescaled.Balance, rescaled.RawBalance, rescaled.Decimals = "3.5", "3500", 3
+	diff = DiffWallets(&WalletResponse{Address: testWalletA, Tokens: []TokenBalance{token}}, &WalletResponse{Address: testWalletA, Tokens: []TokenBalance{rescaled}})
+	if len(diff.Changed) != 1 || diff.Changed[0].Delta != "" || diff.Changed[0].RawDelta != "" {
+		t.Fatalf("expected no delta across decimals, got %+v", diff.Changed)
+	}
+}
+
+func TestFormatWalletChangesRoundTripsSnapshot(t *testing.T) {
+	current := &WalletResponse{
+		Address: testWalletA,
+		Tokens:  []TokenBalance{{Address: "0xc0ffee0000000000000000000000000000000000", Name: "Test", Symbol: "TST", Balance: "3", RawBalance: "3", Decimals: 0}},
+	}
+
+	first, err := formatWalletChanges(nil, current)
+	if err != nil {
+		t.Fatalf("formatWalletChanges returned error: %v", err)
+	}
+	if !strings.Contains(first, "- Test (TST): 3") {
+		t.Fatalf("expected the full wallet on the first call, got:\n%s", first)
+	}
+
+	_, raw, ok := strings.Cut(first, "Snapshot: ")
+	if !ok {
+		t.Fatalf("expected a snapshot line, got:\n%s", first)
+	}
+	var previous WalletResponse
+	if err := json.Unmarshal([]byte(raw), &previous); err != nil {
+		t.Fatalf("snapshot is not valid JSON: %v", err)
+	}
+
+	second, err := formatWalletChanges(&previous, current)
+	if err != nil {
+		t.Fatalf("formatWalletChanges returned error: %v", err)
+	}
+	if !strings.Contains(second, "No changes since previous snapshot.") {
+		t.Fatalf("expected no changes, got:\n%s", second)
+	}
+}